@@ -0,0 +1,202 @@
+// Package admission evaluates operations against a central policy before they
+// are run by the engine, so that platform teams can enforce standards like
+// pinned images or approved registries.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+)
+
+type Operation string
+
+const (
+	OperationFrom    Operation = "from"
+	OperationPublish Operation = "publish"
+	OperationExec    Operation = "exec"
+)
+
+// Request describes an operation to be admitted.
+type Request struct {
+	Operation Operation `json:"operation"`
+
+	// The image reference being pulled or pushed, for from and publish.
+	ImageRef string `json:"imageRef,omitempty"`
+
+	// The command being run, for exec.
+	Args []string `json:"args,omitempty"`
+
+	// Whether the command is granted root capabilities, for exec.
+	Privileged bool `json:"privileged,omitempty"`
+}
+
+// Policy configures the checks performed on each request.
+type Policy struct {
+	// Deny pulling images that are not pinned to a digest.
+	RequirePinnedImages bool `json:"requirePinnedImages,omitempty"`
+
+	// Registries (e.g. docker.io) or repository prefixes (e.g.
+	// ghcr.io/my-org) that images may be pulled from and published to. An
+	// empty list allows any registry.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+
+	// Deny running commands with insecure root capabilities.
+	DenyPrivileged bool `json:"denyPrivileged,omitempty"`
+
+	// URL that each request is POSTed to after passing the above checks.
+	// The webhook must respond with a Response.
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// Response is returned by a webhook.
+type Response struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// DeniedError is returned when a request is denied by the policy.
+type DeniedError struct {
+	Request Request
+	Reason  string
+}
+
+func (err *DeniedError) Error() string {
+	return fmt.Sprintf("admission denied %s: %s", err.Request.Operation, err.Reason)
+}
+
+const webhookTimeout = 10 * time.Second
+
+// Controller admits requests according to a policy.
+type Controller struct {
+	policy Policy
+	client *http.Client
+}
+
+func NewController(policy Policy) *Controller {
+	return &Controller{
+		policy: policy,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Load reads a JSON policy from the given path.
+func Load(path string) (*Controller, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return NewController(policy), nil
+}
+
+// Admit returns an error if the request is denied. A nil controller admits
+// every request.
+func (c *Controller) Admit(ctx context.Context, req Request) error {
+	if c == nil {
+		return nil
+	}
+
+	if err := c.check(req); err != nil {
+		return err
+	}
+
+	if c.policy.Webhook != "" {
+		return c.callWebhook(ctx, req)
+	}
+
+	return nil
+}
+
+func (c *Controller) check(req Request) error {
+	switch req.Operation {
+	case OperationFrom, OperationPublish:
+		ref, err := reference.ParseNormalizedNamed(req.ImageRef)
+		if err != nil {
+			return &DeniedError{Request: req, Reason: fmt.Sprintf("invalid image reference: %s", err)}
+		}
+
+		if !c.registryAllowed(ref) {
+			return &DeniedError{Request: req, Reason: fmt.Sprintf("%s is not in an allowed registry", ref.Name())}
+		}
+
+		if req.Operation == OperationFrom && c.policy.RequirePinnedImages {
+			if _, pinned := ref.(reference.Canonical); !pinned {
+				return &DeniedError{Request: req, Reason: fmt.Sprintf("%s is not pinned to a digest", req.ImageRef)}
+			}
+		}
+	case OperationExec:
+		if req.Privileged && c.policy.DenyPrivileged {
+			return &DeniedError{Request: req, Reason: "privileged commands are not allowed"}
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) registryAllowed(ref reference.Named) bool {
+	if len(c.policy.AllowedRegistries) == 0 {
+		return true
+	}
+
+	name := ref.Name()
+	for _, allowed := range c.policy.AllowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if name == allowed || strings.HasPrefix(name, allowed+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Controller) callWebhook(ctx context.Context, req Request) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.policy.Webhook, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("admission webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("admission webhook: unexpected response: %s", resp.Status)
+	}
+
+	var res Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("admission webhook: decode response: %w", err)
+	}
+
+	if !res.Allowed {
+		reason := res.Reason
+		if reason == "" {
+			reason = "denied by webhook"
+		}
+
+		return &DeniedError{Request: req, Reason: reason}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const pinned = "alpine@sha256:82d1e9d7ed48a7523bdebc18cf6290bdb97b82302a8a9c27d4fe885949ea94d1"
+
+func TestAdmitPinnedImages(t *testing.T) {
+	t.Parallel()
+
+	c := NewController(Policy{RequirePinnedImages: true})
+
+	var denied *DeniedError
+	require.ErrorAs(t, c.Admit(context.Background(), Request{
+		Operation: OperationFrom,
+		ImageRef:  "alpine:3.16",
+	}), &denied)
+
+	require.NoError(t, c.Admit(context.Background(), Request{
+		Operation: OperationFrom,
+		ImageRef:  pinned,
+	}))
+
+	// publishing a tag is fine
+	require.NoError(t, c.Admit(context.Background(), Request{
+		Operation: OperationPublish,
+		ImageRef:  "ghcr.io/my-org/app:latest",
+	}))
+}
+
+func TestAdmitAllowedRegistries(t *testing.T) {
+	t.Parallel()
+
+	c := NewController(Policy{
+		AllowedRegistries: []string{"docker.io/library", "ghcr.io/my-org/"},
+	})
+
+	for _, ref := range []string{"alpine", pinned, "ghcr.io/my-org/app:v1"} {
+		require.NoError(t, c.Admit(context.Background(), Request{
+			Operation: OperationFrom,
+			ImageRef:  ref,
+		}), ref)
+	}
+
+	for _, ref := range []string{"ghcr.io/other-org/app", "ghcr.io/my-org-fork/app", "quay.io/my-org/app"} {
+		var denied *DeniedError
+		require.ErrorAs(t, c.Admit(context.Background(), Request{
+			Operation: OperationPublish,
+			ImageRef:  ref,
+		}), &denied, ref)
+	}
+}
+
+func TestAdmitPrivileged(t *testing.T) {
+	t.Parallel()
+
+	c := NewController(Policy{DenyPrivileged: true})
+
+	require.NoError(t, c.Admit(context.Background(), Request{
+		Operation: OperationExec,
+		Args:      []string{"echo"},
+	}))
+
+	var denied *DeniedError
+	require.ErrorAs(t, c.Admit(context.Background(), Request{
+		Operation:  OperationExec,
+		Args:       []string{"echo"},
+		Privileged: true,
+	}), &denied)
+
+	// nil controllers admit everything
+	var nilController *Controller
+	require.NoError(t, nilController.Admit(context.Background(), Request{
+		Operation:  OperationExec,
+		Privileged: true,
+	}))
+}
+
+func TestAdmitWebhook(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res := Response{Allowed: true}
+		if len(req.Args) > 0 && req.Args[0] == "rm" {
+			res = Response{Allowed: false, Reason: "no deleting"}
+		}
+
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{"webhook": "`+srv.URL+`"}`), 0o600))
+
+	c, err := Load(policyPath)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Admit(context.Background(), Request{
+		Operation: OperationExec,
+		Args:      []string{"echo"},
+	}))
+
+	err = c.Admit(context.Background(), Request{
+		Operation: OperationExec,
+		Args:      []string{"rm", "-rf", "/"},
+	})
+	require.ErrorContains(t, err, "no deleting")
+}
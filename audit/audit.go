@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog"
+)
+
+// Entry is a single audited API operation.
+type Entry struct {
+	Timestamp time.Time `json:"ts"`
+
+	// The client that requested the operation.
+	Client Client `json:"client"`
+
+	// The resolver that was called, in Type.field form (e.g.
+	// Container.withExec), or "terminal" for interactive terminals.
+	Operation string `json:"op"`
+
+	// The arguments passed to the resolver, with secrets redacted.
+	Args map[string]any `json:"args,omitempty"`
+
+	// The error returned by the resolver, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Client identifies the source of a request.
+type Client struct {
+	Addr      string `json:"addr,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// Sink is a destination for audit entries.
+type Sink interface {
+	Write(Entry) error
+	Close() error
+}
+
+// Log writes audit entries to a set of sinks.
+type Log struct {
+	sinks  []Sink
+	logger *zerolog.Logger
+	mu     sync.Mutex
+}
+
+func New(sinks ...Sink) *Log {
+	return &Log{sinks: sinks}
+}
+
+// Open configures a Log from a comma-separated list of sink URLs.
+//
+// file:// URLs (or plain paths) are appended to as JSON lines. http:// and
+// https:// URLs receive batches of JSON lines via POST.
+func Open(config string) (*Log, error) {
+	sinks := []Sink{}
+	for _, dest := range strings.Split(config, ",") {
+		dest = strings.TrimSpace(dest)
+		if dest == "" {
+			continue
+		}
+
+		sink, err := openSink(dest)
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, fmt.Errorf("audit sink %q: %w", dest, err)
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	return New(sinks...), nil
+}
+
+func openSink(dest string) (Sink, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		return NewFileSink(u.Path)
+	case "http", "https":
+		return NewHTTPSink(u.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// SetLogger configures the logger that entries which can't be written are
+// reported to. Sinks which write in the background, like HTTPSink, report to
+// it too.
+func (log *Log) SetLogger(logger *zerolog.Logger) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.logger = logger
+
+	for _, sink := range log.sinks {
+		if s, ok := sink.(interface{ SetLogger(*zerolog.Logger) }); ok {
+			s.SetLogger(logger)
+		}
+	}
+}
+
+// Write sends the entry to every sink. Errors are logged rather than failing
+// the audited operation.
+func (log *Log) Write(entry Entry) {
+	if log == nil {
+		return
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	for _, sink := range log.sinks {
+		if err := sink.Write(entry); err != nil {
+			loggerOrNop(log.logger).Error().Err(err).Str("op", entry.Operation).Msg("audit: write entry")
+		}
+	}
+}
+
+func (log *Log) Close() error {
+	if log == nil {
+		return nil
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range log.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// loggerOrNop returns logger, or a logger that discards everything if it's
+// nil.
+func loggerOrNop(logger *zerolog.Logger) *zerolog.Logger {
+	if logger == nil {
+		nop := zerolog.Nop()
+		return &nop
+	}
+	return logger
+}
+
+type logKey struct{}
+
+type clientKey struct{}
+
+// ToContext returns a context that records operations to the given log.
+func ToContext(ctx context.Context, log *Log) context.Context {
+	return context.WithValue(ctx, logKey{}, log)
+}
+
+// WithClient returns a context that attributes operations to the given
+// client.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// Record writes an entry for the operation to the log in the context, if any.
+func Record(ctx context.Context, op string, args any, opErr error) {
+	log, ok := ctx.Value(logKey{}).(*Log)
+	if !ok || log == nil {
+		return
+	}
+
+	client, _ := ctx.Value(clientKey{}).(Client)
+
+	entry := Entry{
+		Timestamp: time.Now().UTC(),
+		Client:    client,
+		Operation: op,
+		Args:      redactArgs(args),
+	}
+
+	if opErr != nil {
+		entry.Error = opErr.Error()
+	}
+
+	log.Write(entry)
+}
+
+// Redacted is recorded in place of the values of sensitive arguments.
+const Redacted = "***"
+
+// sensitiveArgs are the names of string arguments that may carry secrets, e.g.
+// file contents, command lines, stdin or environment variable values.
+var sensitiveArgs = map[string]bool{
+	"args":      true,
+	"contents":  true,
+	"plaintext": true,
+	"stdin":     true,
+	"value":     true,
+}
+
+// IsSensitiveArg reports whether the value of an argument with the given name
+// and schema type must be redacted. An empty type name means the type is
+// unknown, in which case the argument is judged by its name alone.
+func IsSensitiveArg(name, typeName string) bool {
+	if !sensitiveArgs[name] {
+		return false
+	}
+
+	return typeName == "" || typeName == "String"
+}
+
+// maxArgLength is the length beyond which string arguments (typically IDs)
+// are recorded as a digest instead.
+const maxArgLength = 256
+
+// redactArgs converts args into a loggable map.
+//
+// Callers are expected to have replaced sensitive arguments with Redacted
+// already; see IsSensitiveArg. Arguments are also marshaled with their custom
+// JSON serialization, which scrubs any secret plaintext values that remain.
+func redactArgs(args any) map[string]any {
+	argBytes, err := json.Marshal(args)
+	if err != nil {
+		return map[string]any{"error": "failed to marshal args"}
+	}
+
+	argMap := map[string]any{}
+	if err := json.Unmarshal(argBytes, &argMap); err != nil {
+		// not an object, e.g. null
+		return nil
+	}
+
+	for k, v := range argMap {
+		argMap[k] = shorten(v)
+	}
+
+	if len(argMap) == 0 {
+		return nil
+	}
+
+	return argMap
+}
+
+func shorten(val any) any {
+	switch x := val.(type) {
+	case string:
+		if len(x) > maxArgLength {
+			return digest.FromString(x).String()
+		}
+		return x
+	case []any:
+		for i, v := range x {
+			x[i] = shorten(v)
+		}
+		return x
+	case map[string]any:
+		for k, v := range x {
+			x[k] = shorten(v)
+		}
+		return x
+	default:
+		return val
+	}
+}
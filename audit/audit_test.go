@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type plaintext string
+
+func (plaintext) MarshalText() ([]byte, error) {
+	return []byte("***"), nil
+}
+
+func TestRecordToFile(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "audit", "log.json")
+
+	log, err := Open(logPath)
+	require.NoError(t, err)
+
+	ctx := ToContext(context.Background(), log)
+	ctx = WithClient(ctx, Client{Addr: "10.0.0.1", UserAgent: "sdk"})
+
+	type setSecretArgs struct {
+		Name      string
+		Plaintext plaintext
+	}
+
+	Record(ctx, "Query.setSecret", setSecretArgs{
+		Name:      "token",
+		Plaintext: "hunter2",
+	}, nil)
+
+	Record(ctx, "Container.from", struct {
+		Address string
+	}{
+		Address: strings.Repeat("x", maxArgLength+1),
+	}, errors.New("pull failed"))
+
+	require.NoError(t, log.Close())
+
+	// entries are appended to existing logs
+	log, err = Open("file://" + logPath)
+	require.NoError(t, err)
+	Record(ToContext(context.Background(), log), "Query.directory", nil, nil)
+	require.NoError(t, log.Close())
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.NotContains(t, string(content), "hunter2")
+
+	entries := readEntries(t, strings.NewReader(string(content)))
+	require.Len(t, entries, 3)
+
+	require.Equal(t, "Query.setSecret", entries[0].Operation)
+	require.Equal(t, "10.0.0.1", entries[0].Client.Addr)
+	require.Equal(t, "sdk", entries[0].Client.UserAgent)
+	require.Equal(t, "token", entries[0].Args["Name"])
+	require.Equal(t, "***", entries[0].Args["Plaintext"])
+	require.Empty(t, entries[0].Error)
+
+	require.Equal(t, "Container.from", entries[1].Operation)
+	require.Contains(t, entries[1].Args["Address"], "sha256:")
+	require.Equal(t, "pull failed", entries[1].Error)
+
+	require.Equal(t, "Query.directory", entries[2].Operation)
+	require.Empty(t, entries[2].Args)
+}
+
+func TestIsSensitiveArg(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsSensitiveArg("contents", "String"))
+	require.True(t, IsSensitiveArg("args", "String"))
+	require.True(t, IsSensitiveArg("plaintext", ""))
+	require.False(t, IsSensitiveArg("path", "String"))
+
+	// IDs are content-addressed and safe to record
+	require.False(t, IsSensitiveArg("value", "SecretID"))
+}
+
+func TestRecordToHTTP(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var received []Entry
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, readEntries(t, r.Body)...)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	log, err := Open(srv.URL)
+	require.NoError(t, err)
+
+	ctx := ToContext(context.Background(), log)
+	Record(ctx, "Container.withExec", nil, nil)
+	Record(ctx, "Container.publish", nil, nil)
+
+	// closing flushes pending entries
+	require.NoError(t, log.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 2)
+	require.Equal(t, "Container.withExec", received[0].Operation)
+	require.Equal(t, "Container.publish", received[1].Operation)
+}
+
+func TestRecordToHTTPFailure(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	log, err := Open(srv.URL)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	log.SetLogger(&logger)
+
+	ctx := ToContext(context.Background(), log)
+	Record(ctx, "Container.withExec", nil, nil)
+
+	require.NoError(t, log.Close())
+
+	// entries written after closing are dropped
+	Record(ctx, "Container.publish", nil, nil)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	require.Equal(t, "error", line["level"])
+	require.Equal(t, "audit: unexpected response", line["message"])
+	require.Equal(t, "500 Internal Server Error", line["status"])
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	require.Equal(t, "error", line["level"])
+	require.Equal(t, "audit: write entry", line["message"])
+	require.Equal(t, "Container.publish", line["op"])
+}
+
+func TestRecordWithoutLog(t *testing.T) {
+	t.Parallel()
+
+	// no-op, should not panic
+	Record(context.Background(), "Query.container", nil, nil)
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := Open("ftp://example.com/audit")
+	require.Error(t, err)
+}
+
+func readEntries(t *testing.T, r io.Reader) []Entry {
+	entries := []Entry{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// FileSink appends entries to a file as JSON lines.
+type FileSink struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+func (sink *FileSink) Write(entry Entry) error {
+	return sink.enc.Encode(entry)
+}
+
+func (sink *FileSink) Close() error {
+	return sink.f.Close()
+}
+
+const (
+	httpFlushInterval = time.Second
+	httpQueueSize     = 2048
+)
+
+// HTTPSink POSTs batches of entries to an endpoint as JSON lines.
+type HTTPSink struct {
+	url string
+
+	mu     sync.Mutex
+	logger *zerolog.Logger
+	queue  []Entry
+	closed bool
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	sink := &HTTPSink{
+		url:    url,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go sink.start()
+
+	return sink
+}
+
+func (sink *HTTPSink) Write(entry Entry) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.closed {
+		return fmt.Errorf("sink closed")
+	}
+
+	if len(sink.queue) >= httpQueueSize {
+		return fmt.Errorf("queue full; dropping entry for %s", entry.Operation)
+	}
+
+	sink.queue = append(sink.queue, entry)
+
+	return nil
+}
+
+// SetLogger configures the logger that failed deliveries are reported to.
+func (sink *HTTPSink) SetLogger(logger *zerolog.Logger) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	sink.logger = logger
+}
+
+func (sink *HTTPSink) start() {
+	defer close(sink.doneCh)
+
+	for {
+		select {
+		case <-time.After(httpFlushInterval):
+			sink.send()
+		case <-sink.stopCh:
+			// On stop, send the current queue and exit
+			sink.send()
+			return
+		}
+	}
+}
+
+func (sink *HTTPSink) send() {
+	sink.mu.Lock()
+	queue := append([]Entry{}, sink.queue...)
+	sink.queue = []Entry{}
+	logger := loggerOrNop(sink.logger)
+	sink.mu.Unlock()
+
+	if len(queue) == 0 {
+		return
+	}
+
+	payload := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(payload)
+	for _, entry := range queue {
+		if err := enc.Encode(entry); err != nil {
+			logger.Error().Err(err).Str("op", entry.Operation).Msg("audit: encode entry")
+			continue
+		}
+	}
+
+	resp, err := http.Post(sink.url, "application/x-ndjson", payload) //nolint:gosec
+	if err != nil {
+		logger.Error().Err(err).Str("url", sink.url).Int("entries", len(queue)).Msg("audit: post entries")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logger.Error().Str("url", sink.url).Str("status", resp.Status).Int("entries", len(queue)).Msg("audit: unexpected response")
+	}
+}
+
+func (sink *HTTPSink) Close() error {
+	sink.mu.Lock()
+	if sink.closed {
+		sink.mu.Unlock()
+		return nil
+	}
+	sink.closed = true
+	sink.mu.Unlock()
+
+	// Flush entries in queue
+	close(sink.stopCh)
+
+	// Wait for completion
+	<-sink.doneCh
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/docker/cli/cli/config/configfile"
+	"github.com/google/go-containerregistry/pkg/authn"
 	bkauth "github.com/moby/buildkit/session/auth"
 	"github.com/moby/buildkit/session/auth/authprovider"
 	"google.golang.org/grpc"
@@ -35,6 +36,9 @@ type RegistryAuthProvider struct {
 	// Memory map credential storage.
 	credentials map[string]*bkauth.CredentialsResponse
 
+	// Deny all registry requests.
+	offline bool
+
 	// Mutex to handle concurrency.
 	m sync.RWMutex
 }
@@ -153,6 +157,27 @@ func (r *RegistryAuthProvider) RemoveCredential(address string) error {
 	return nil
 }
 
+// SetOffline configures the provider to deny every registry authentication
+// request, so that pulls and pushes which reach a registry requiring auth
+// fail instead of using the network.
+func (r *RegistryAuthProvider) SetOffline(offline bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.offline = offline
+}
+
+func (r *RegistryAuthProvider) checkOffline(host string) error {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	if r.offline {
+		return status.Errorf(codes.PermissionDenied, "network access is disabled: cannot reach registry %s", host)
+	}
+
+	return nil
+}
+
 func (r *RegistryAuthProvider) Register(server *grpc.Server) {
 	bkauth.RegisterAuthServer(server, r)
 }
@@ -181,6 +206,10 @@ func (r *RegistryAuthProvider) credential(domain string) *bkauth.CredentialsResp
 // If the address isn't registered in the memory map, it will search
 // on DockerAuthProvider.
 func (r *RegistryAuthProvider) Credentials(ctx context.Context, req *bkauth.CredentialsRequest) (*bkauth.CredentialsResponse, error) {
+	if err := r.checkOffline(req.GetHost()); err != nil {
+		return nil, err
+	}
+
 	memoryCredential := r.credential(req.GetHost())
 	if memoryCredential != nil {
 		return memoryCredential, nil
@@ -190,6 +219,10 @@ func (r *RegistryAuthProvider) Credentials(ctx context.Context, req *bkauth.Cred
 }
 
 func (r *RegistryAuthProvider) FetchToken(ctx context.Context, req *bkauth.FetchTokenRequest) (*bkauth.FetchTokenResponse, error) {
+	if err := r.checkOffline(req.GetHost()); err != nil {
+		return nil, err
+	}
+
 	memoryCredential := r.credential(req.GetHost())
 	if memoryCredential != nil {
 		return nil, status.Errorf(codes.Unavailable, "secret is store in memory")
@@ -199,6 +232,10 @@ func (r *RegistryAuthProvider) FetchToken(ctx context.Context, req *bkauth.Fetch
 }
 
 func (r *RegistryAuthProvider) GetTokenAuthority(ctx context.Context, req *bkauth.GetTokenAuthorityRequest) (*bkauth.GetTokenAuthorityResponse, error) {
+	if err := r.checkOffline(req.GetHost()); err != nil {
+		return nil, err
+	}
+
 	memoryCredential := r.credential(req.GetHost())
 	if memoryCredential != nil {
 		return nil, status.Errorf(codes.Unavailable, "secret is store in memory")
@@ -215,3 +252,24 @@ func (r *RegistryAuthProvider) VerifyTokenAuthority(ctx context.Context, req *bk
 
 	return r.dockerAuthProvider.VerifyTokenAuthority(ctx, req)
 }
+
+var _ authn.Keychain = &RegistryAuthProvider{}
+
+// Resolve implements authn.Keychain, so that registry operations performed
+// outside of Buildkit use the same credentials: those added in memory, then
+// those in the Docker config.
+func (r *RegistryAuthProvider) Resolve(res authn.Resource) (authn.Authenticator, error) {
+	if err := r.checkOffline(res.RegistryStr()); err != nil {
+		return nil, err
+	}
+
+	memoryCredential := r.credential(res.RegistryStr())
+	if memoryCredential != nil {
+		return authn.FromConfig(authn.AuthConfig{
+			Username: memoryCredential.Username,
+			Password: memoryCredential.Secret,
+		}), nil
+	}
+
+	return authn.DefaultKeychain.Resolve(res)
+}
@@ -132,3 +132,33 @@ func TestRegistryAuthProvider(t *testing.T) {
 		require.Equal(t, testRegistrySecret, credentialsRes.Secret)
 	})
 }
+
+func TestRegistryAuthProviderOffline(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cfg := configfile.ConfigFile{}
+	registry := NewRegistryAuthProvider(&cfg)
+
+	err := registry.AddCredential(testRegistryAddress, testRegistryUser, testRegistrySecret)
+	require.NoError(t, err)
+
+	registry.SetOffline(true)
+
+	_, err = registry.Credentials(ctx, &auth.CredentialsRequest{
+		Host: testRegistryAddress,
+	})
+	require.Error(t, err)
+
+	_, err = registry.FetchToken(ctx, &auth.FetchTokenRequest{
+		Host: testRegistryAddress,
+	})
+	require.Error(t, err)
+
+	registry.SetOffline(false)
+
+	credentialsRes, err := registry.Credentials(ctx, &auth.CredentialsRequest{
+		Host: testRegistryAddress,
+	})
+	require.NoError(t, err)
+	require.Equal(t, testRegistryUser, credentialsRes.Username)
+}
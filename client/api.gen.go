@@ -0,0 +1,4090 @@
+// Code generated by dagger. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/dagger/dagger/client/internal/querybuilder"
+)
+
+// A global cache volume identifier.
+type CacheID string
+
+// A unique container identifier. Null designates an empty container (scratch).
+type ContainerID string
+
+// A content-addressed directory identifier.
+type DirectoryID string
+
+// A file identifier.
+type FileID string
+
+// The platform config OS and architecture in a Container.
+//
+// The format is [os]/[platform]/[version] (e.g., "darwin/arm64/v7", "windows/amd64", "linux/arm64").
+type Platform string
+
+// A unique project command identifier.
+type ProjectCommandID string
+
+// A unique project identifier.
+type ProjectID string
+
+// A unique identifier for a secret.
+type SecretID string
+
+// A unique service identifier.
+type ServiceID string
+
+// A content-addressed socket identifier.
+type SocketID string
+
+// A unique stack identifier.
+type StackID string
+
+// Key value object that represents a build argument.
+type BuildArg struct {
+	// The build argument name.
+	Name string `json:"name"`
+
+	// The build argument value.
+	Value string `json:"value"`
+}
+
+// A request for GPU devices.
+type GPURequest struct {
+	// The number of GPUs to request. Defaults to all GPUs on the worker.
+	Count int `json:"count"`
+
+	// The GPU vendor (e.g., "nvidia"). Defaults to nvidia, which is currently the
+	// only supported vendor.
+	Vendor string `json:"vendor"`
+}
+
+// Key value object that represents a Pipeline label.
+type PipelineLabel struct {
+	// Label name.
+	Name string `json:"name"`
+
+	// Label value.
+	Value string `json:"value"`
+}
+
+// A directory whose contents persist across runs.
+type CacheVolume struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id *CacheID
+}
+
+func (r *CacheVolume) ID(ctx context.Context) (CacheID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response CacheID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *CacheVolume) XXX_GraphQLType() string {
+	return "CacheVolume"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *CacheVolume) XXX_GraphQLIDType() string {
+	return "CacheID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *CacheVolume) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// An OCI-compatible container, also known as a docker container.
+type Container struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	endpoint     *string
+	envVariable  *string
+	exitCode     *int
+	export       *bool
+	exportLayout *bool
+	hostname     *string
+	id           *ContainerID
+	imageRef     *string
+	label        *string
+	platform     *Platform
+	publish      *string
+	stderr       *string
+	stdout       *string
+	stopSignal   *string
+	sync         *ContainerID
+	user         *string
+	workdir      *string
+}
+type WithContainerFunc func(r *Container) *Container
+
+func (r *Container) With(f WithContainerFunc) *Container {
+	return f(r)
+}
+
+// Turns the container into a service which runs its default command, and
+// can be started and stopped explicitly.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) AsService() *Service {
+	q := r.q.Select("asService")
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerAsTarballOpts contains options for Container.AsTarball
+type ContainerAsTarballOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
+}
+
+// Returns a File representing the container serialized to a tarball, in the
+// same format as export.
+func (r *Container) AsTarball(opts ...ContainerAsTarballOpts) *File {
+	q := r.q.Select("asTarball")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerBuildOpts contains options for Container.Build
+type ContainerBuildOpts struct {
+	// Path to the Dockerfile to use.
+	//
+	// Default: './Dockerfile'.
+	Dockerfile string
+	// Additional build arguments.
+	BuildArgs []BuildArg
+	// Target build stage to build.
+	Target string
+	// Secrets to pass to the build.
+	//
+	// They will be mounted at /run/secrets/[secret-name].
+	Secrets []*Secret
+}
+
+// Initializes this container from a Dockerfile build.
+func (r *Container) Build(context *Directory, opts ...ContainerBuildOpts) *Container {
+	q := r.q.Select("build")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dockerfile` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Dockerfile) {
+			q = q.Arg("dockerfile", opts[i].Dockerfile)
+		}
+		// `buildArgs` optional argument
+		if !querybuilder.IsZeroValue(opts[i].BuildArgs) {
+			q = q.Arg("buildArgs", opts[i].BuildArgs)
+		}
+		// `target` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Target) {
+			q = q.Arg("target", opts[i].Target)
+		}
+		// `secrets` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Secrets) {
+			q = q.Arg("secrets", opts[i].Secrets)
+		}
+	}
+	q = q.Arg("context", context)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves the changes made to this container's root filesystem on top of
+// its base image (e.g., by commands and written files), or the whole root
+// filesystem if it has no base image. Deleted files are not included.
+func (r *Container) Changes() *Directory {
+	q := r.q.Select("changes")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves default arguments for future commands.
+func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
+	q := r.q.Select("defaultArgs")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a directory at the given path.
+//
+// Mounts are included.
+func (r *Container) Directory(path string) *Directory {
+	q := r.q.Select("directory")
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerEndpointOpts contains options for Container.Endpoint
+type ContainerEndpointOpts struct {
+	// The exposed port number for the endpoint
+	Port int
+	// Return a URL with the given scheme, eg. http for http://
+	Scheme string
+}
+
+// Retrieves an endpoint that clients can use to reach this container.
+//
+// If no port is specified, the first exposed port is used. If none exist an error is returned.
+//
+// If a scheme is specified, a URL is returned. Otherwise, a host:port pair is returned.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) Endpoint(ctx context.Context, opts ...ContainerEndpointOpts) (string, error) {
+	if r.endpoint != nil {
+		return *r.endpoint, nil
+	}
+	q := r.q.Select("endpoint")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `port` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Port) {
+			q = q.Arg("port", opts[i].Port)
+		}
+		// `scheme` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Scheme) {
+			q = q.Arg("scheme", opts[i].Scheme)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves entrypoint to be prepended to the arguments of all commands.
+func (r *Container) Entrypoint(ctx context.Context) ([]string, error) {
+	q := r.q.Select("entrypoint")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the value of the specified environment variable.
+func (r *Container) EnvVariable(ctx context.Context, name string) (string, error) {
+	if r.envVariable != nil {
+		return *r.envVariable, nil
+	}
+	q := r.q.Select("envVariable")
+	q = q.Arg("name", name)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the list of environment variables passed to commands.
+func (r *Container) EnvVariables(ctx context.Context) ([]EnvVariable, error) {
+	q := r.q.Select("envVariables")
+
+	q = q.Select("name value")
+
+	type envVariables struct {
+		Name  string
+		Value string
+	}
+
+	convert := func(fields []envVariables) []EnvVariable {
+		out := []EnvVariable{}
+
+		for i := range fields {
+			out = append(out, EnvVariable{name: &fields[i].Name, value: &fields[i].Value})
+		}
+
+		return out
+	}
+	var response []envVariables
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// ContainerExecOpts contains options for Container.Exec
+type ContainerExecOpts struct {
+	// Command to run instead of the container's default command (e.g., ["run", "main.go"]).
+	Args []string
+	// Content to write to the command's standard input before closing (e.g., "Hello world").
+	Stdin string
+	// Redirect the command's standard output to a file in the container (e.g., "/tmp/stdout").
+	RedirectStdout string
+	// Redirect the command's standard error to a file in the container (e.g., "/tmp/stderr").
+	RedirectStderr string
+	// Provide dagger access to the executed command.
+	// Do not use this option unless you trust the command being executed.
+	// The command being executed WILL BE GRANTED FULL ACCESS TO YOUR HOST FILESYSTEM.
+	ExperimentalPrivilegedNesting bool
+}
+
+// Retrieves this container after executing the specified command inside it.
+//
+// Deprecated: Replaced by WithExec.
+func (r *Container) Exec(opts ...ContainerExecOpts) *Container {
+	q := r.q.Select("exec")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `args` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Args) {
+			q = q.Arg("args", opts[i].Args)
+		}
+		// `stdin` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Stdin) {
+			q = q.Arg("stdin", opts[i].Stdin)
+		}
+		// `redirectStdout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RedirectStdout) {
+			q = q.Arg("redirectStdout", opts[i].RedirectStdout)
+		}
+		// `redirectStderr` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RedirectStderr) {
+			q = q.Arg("redirectStderr", opts[i].RedirectStderr)
+		}
+		// `experimentalPrivilegedNesting` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalPrivilegedNesting) {
+			q = q.Arg("experimentalPrivilegedNesting", opts[i].ExperimentalPrivilegedNesting)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Resource usage statistics of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) ExecStats() *ExecStats {
+	q := r.q.Select("execStats")
+
+	return &ExecStats{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Exit code of the last executed command. Zero means success.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) ExitCode(ctx context.Context) (int, error) {
+	if r.exitCode != nil {
+		return *r.exitCode, nil
+	}
+	q := r.q.Select("exitCode")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerExportOpts contains options for Container.Export
+type ContainerExportOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the exported image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the exported image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
+}
+
+// Writes the container as an OCI tarball to the destination file path on the host for the specified platform variants.
+//
+// Return true on success.
+// It can also publishes platform variants.
+func (r *Container) Export(ctx context.Context, path string, opts ...ContainerExportOpts) (bool, error) {
+	if r.export != nil {
+		return *r.export, nil
+	}
+	q := r.q.Select("export")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+	q = q.Arg("path", path)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerExportLayoutOpts contains options for Container.ExportLayout
+type ContainerExportLayoutOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the exported image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+}
+
+// Writes the container to an OCI image layout directory on the host for the
+// specified platform variants, for use with tools like skopeo and crane.
+//
+// Return true on success.
+func (r *Container) ExportLayout(ctx context.Context, path string, opts ...ContainerExportLayoutOpts) (bool, error) {
+	if r.exportLayout != nil {
+		return *r.exportLayout, nil
+	}
+	q := r.q.Select("exportLayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+	}
+	q = q.Arg("path", path)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the list of exposed ports.
+//
+// This includes ports already exposed by the image, even if not
+// explicitly added with dagger.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) ExposedPorts(ctx context.Context) ([]Port, error) {
+	q := r.q.Select("exposedPorts")
+
+	q = q.Select("description port protocol")
+
+	type exposedPorts struct {
+		Description string
+		Port        int
+		Protocol    NetworkProtocol
+	}
+
+	convert := func(fields []exposedPorts) []Port {
+		out := []Port{}
+
+		for i := range fields {
+			out = append(out, Port{description: &fields[i].Description, port: &fields[i].Port, protocol: &fields[i].Protocol})
+		}
+
+		return out
+	}
+	var response []exposedPorts
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// Retrieves a file at the given path.
+//
+// Mounts are included.
+func (r *Container) File(path string) *File {
+	q := r.q.Select("file")
+	q = q.Arg("path", path)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Initializes this container from a pulled base image.
+func (r *Container) From(address string) *Container {
+	q := r.q.Select("from")
+	q = q.Arg("address", address)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container's root filesystem. Mounts are not included.
+//
+// Deprecated: Replaced by Rootfs.
+func (r *Container) FS() *Directory {
+	q := r.q.Select("fs")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves a hostname which can be used by clients to reach this container.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) Hostname(ctx context.Context) (string, error) {
+	if r.hostname != nil {
+		return *r.hostname, nil
+	}
+	q := r.q.Select("hostname")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A unique identifier for this container.
+func (r *Container) ID(ctx context.Context) (ContainerID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response ContainerID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Container) XXX_GraphQLType() string {
+	return "Container"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Container) XXX_GraphQLIDType() string {
+	return "ContainerID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Container) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// The unique image reference which can only be retrieved immediately after the 'Container.From' call.
+func (r *Container) ImageRef(ctx context.Context) (string, error) {
+	if r.imageRef != nil {
+		return *r.imageRef, nil
+	}
+	q := r.q.Select("imageRef")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerImportOpts contains options for Container.Import
+type ContainerImportOpts struct {
+	// Identifies the tag to import from the archive, if the archive bundles
+	// multiple tags.
+	Tag string
+}
+
+// Reads the container from an OCI tarball.
+//
+// NOTE: this involves unpacking the tarball to an OCI store on the host at
+// $XDG_CACHE_DIR/dagger/oci. This directory can be removed whenever you like.
+func (r *Container) Import(source *File, opts ...ContainerImportOpts) *Container {
+	q := r.q.Select("import")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `tag` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Tag) {
+			q = q.Arg("tag", opts[i].Tag)
+		}
+	}
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerImportLayoutOpts contains options for Container.ImportLayout
+type ContainerImportLayoutOpts struct {
+	// Identifies the tag to import from the layout, if the layout contains
+	// multiple tags.
+	Tag string
+}
+
+// Reads the container from an OCI image layout directory on the host.
+//
+// NOTE: this involves copying the layout's blobs to an OCI store on the host at
+// $XDG_CACHE_DIR/dagger/oci. This directory can be removed whenever you like.
+func (r *Container) ImportLayout(path string, opts ...ContainerImportLayoutOpts) *Container {
+	q := r.q.Select("importLayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `tag` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Tag) {
+			q = q.Arg("tag", opts[i].Tag)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves the value of the specified label.
+func (r *Container) Label(ctx context.Context, name string) (string, error) {
+	if r.label != nil {
+		return *r.label, nil
+	}
+	q := r.q.Select("label")
+	q = q.Arg("name", name)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the list of labels passed to container.
+func (r *Container) Labels(ctx context.Context) ([]Label, error) {
+	q := r.q.Select("labels")
+
+	q = q.Select("name value")
+
+	type labels struct {
+		Name  string
+		Value string
+	}
+
+	convert := func(fields []labels) []Label {
+		out := []Label{}
+
+		for i := range fields {
+			out = append(out, Label{name: &fields[i].Name, value: &fields[i].Value})
+		}
+
+		return out
+	}
+	var response []labels
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// Retrieves the container's mounts, including what kind of source each is mounted from.
+func (r *Container) MountPoints(ctx context.Context) ([]Mount, error) {
+	q := r.q.Select("mountPoints")
+
+	q = q.Select("path sourcePath type")
+
+	type mountPoints struct {
+		Path       string
+		SourcePath string
+		Type       MountType
+	}
+
+	convert := func(fields []mountPoints) []Mount {
+		out := []Mount{}
+
+		for i := range fields {
+			out = append(out, Mount{path: &fields[i].Path, sourcePath: &fields[i].SourcePath, type_: &fields[i].Type})
+		}
+
+		return out
+	}
+	var response []mountPoints
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// Retrieves the list of paths where a directory is mounted.
+func (r *Container) Mounts(ctx context.Context) ([]string, error) {
+	q := r.q.Select("mounts")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerPipelineOpts contains options for Container.Pipeline
+type ContainerPipelineOpts struct {
+	// Pipeline description.
+	Description string
+	// Pipeline labels.
+	Labels []PipelineLabel
+}
+
+// Creates a named sub-pipeline
+func (r *Container) Pipeline(name string, opts ...ContainerPipelineOpts) *Container {
+	q := r.q.Select("pipeline")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `description` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Description) {
+			q = q.Arg("description", opts[i].Description)
+		}
+		// `labels` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Labels) {
+			q = q.Arg("labels", opts[i].Labels)
+		}
+	}
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The platform this container executes and publishes as.
+func (r *Container) Platform(ctx context.Context) (Platform, error) {
+	if r.platform != nil {
+		return *r.platform, nil
+	}
+	q := r.q.Select("platform")
+
+	var response Platform
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerPublishOpts contains options for Container.Publish
+type ContainerPublishOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the published image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the published image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
+	// Attach a SLSA provenance attestation to the published image.
+	// Requires OCI media types, which are used by default when this is set.
+	Provenance bool
+	// Sign the published image with this PEM-encoded private key, pushing the
+	// signature like cosign sign does. Encrypted cosign keys are supported.
+	//
+	// The signature isn't recorded in a transparency log, so verify it with
+	// cosign verify --insecure-ignore-tlog.
+	SigningKey *Secret
+	// Password decrypting signingKey, if it's an encrypted cosign key.
+	SigningPassword *Secret
+}
+
+// Publishes this container as a new image to the specified address.
+//
+// Publish returns a fully qualified ref.
+// It can also publish platform variants.
+func (r *Container) Publish(ctx context.Context, address string, opts ...ContainerPublishOpts) (string, error) {
+	if r.publish != nil {
+		return *r.publish, nil
+	}
+	q := r.q.Select("publish")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
+		// `signingKey` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningKey) {
+			q = q.Arg("signingKey", opts[i].SigningKey)
+		}
+		// `signingPassword` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningPassword) {
+			q = q.Arg("signingPassword", opts[i].SigningPassword)
+		}
+	}
+	q = q.Arg("address", address)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves this container's root filesystem. Mounts are not included.
+func (r *Container) Rootfs() *Directory {
+	q := r.q.Select("rootfs")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The error stream of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) Stderr(ctx context.Context) (string, error) {
+	if r.stderr != nil {
+		return *r.stderr, nil
+	}
+	q := r.q.Select("stderr")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The output stream of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) Stdout(ctx context.Context) (string, error) {
+	if r.stdout != nil {
+		return *r.stdout, nil
+	}
+	q := r.q.Select("stdout")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the signal used to stop the container (e.g., "SIGTERM").
+func (r *Container) StopSignal(ctx context.Context) (string, error) {
+	if r.stopSignal != nil {
+		return *r.stopSignal, nil
+	}
+	q := r.q.Select("stopSignal")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Forces evaluation of the pipeline in the engine.
+//
+// It doesn't run the default command if no exec has been set.
+func (r *Container) Sync(ctx context.Context) (*Container, error) {
+	q := r.q.Select("sync")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// Retrieves the user to be set for all commands.
+func (r *Container) User(ctx context.Context) (string, error) {
+	if r.user != nil {
+		return *r.user, nil
+	}
+	q := r.q.Select("user")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the paths declared as volumes in the image config.
+func (r *Container) Volumes(ctx context.Context) ([]string, error) {
+	q := r.q.Select("volumes")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves this container plus the given OCI annotation.
+//
+// Unlike labels, annotations are not part of the image config: they are set on
+// the image manifest, and on the index for multi-platform images, when the
+// container is published or exported.
+func (r *Container) WithAnnotation(name string, value string) *Container {
+	q := r.q.Select("withAnnotation")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithDNSOpts contains options for Container.WithDNS
+type ContainerWithDNSOpts struct {
+	// Nameserver IP addresses (e.g., ["10.0.0.2"]).
+	Nameservers []string
+	// Domains to search when resolving short names (e.g., ["corp.internal"]).
+	SearchDomains []string
+	// Resolver options (e.g., ["ndots:2"]).
+	Options []string
+}
+
+// Retrieves this container with the given DNS resolver configuration for its
+// subsequent commands. Unset arguments keep the engine's defaults.
+//
+// Note that overriding the nameservers may prevent service hostnames from
+// resolving, unless the given servers forward to the engine's resolver.
+func (r *Container) WithDNS(opts ...ContainerWithDNSOpts) *Container {
+	q := r.q.Select("withDNS")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `nameservers` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Nameservers) {
+			q = q.Arg("nameservers", opts[i].Nameservers)
+		}
+		// `searchDomains` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SearchDomains) {
+			q = q.Arg("searchDomains", opts[i].SearchDomains)
+		}
+		// `options` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Options) {
+			q = q.Arg("options", opts[i].Options)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithDefaultArgsOpts contains options for Container.WithDefaultArgs
+type ContainerWithDefaultArgsOpts struct {
+	// Arguments to prepend to future executions (e.g., ["-v", "--no-cache"]).
+	Args []string
+}
+
+// Configures default arguments for future commands.
+func (r *Container) WithDefaultArgs(opts ...ContainerWithDefaultArgsOpts) *Container {
+	q := r.q.Select("withDefaultArgs")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `args` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Args) {
+			q = q.Arg("args", opts[i].Args)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithDirectoryOpts contains options for Container.WithDirectory
+type ContainerWithDirectoryOpts struct {
+	// Patterns to exclude in the written directory (e.g., ["node_modules/**", ".gitignore", ".git/"]).
+	Exclude []string
+	// Patterns to include in the written directory (e.g., ["*.go", "go.mod", "go.sum"]).
+	Include []string
+	// A user:group to set for the directory and its contents.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a directory written at the given path.
+func (r *Container) WithDirectory(path string, directory *Directory, opts ...ContainerWithDirectoryOpts) *Container {
+	q := r.q.Select("withDirectory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("directory", directory)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container but with a different command entrypoint.
+func (r *Container) WithEntrypoint(args []string) *Container {
+	q := r.q.Select("withEntrypoint")
+	q = q.Arg("args", args)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithEnvVariableOpts contains options for Container.WithEnvVariable
+type ContainerWithEnvVariableOpts struct {
+	// Replace ${VAR} or $VAR in the value according to the current environment
+	// variables defined in the container (e.g., "/opt/bin:$PATH").
+	Expand bool
+}
+
+// Retrieves this container plus the given environment variable.
+func (r *Container) WithEnvVariable(name string, value string, opts ...ContainerWithEnvVariableOpts) *Container {
+	q := r.q.Select("withEnvVariable")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithExecOpts contains options for Container.WithExec
+type ContainerWithExecOpts struct {
+	// If the container has an entrypoint, ignore it for args rather than using it to wrap them.
+	SkipEntrypoint bool
+	// Content to write to the command's standard input before closing (e.g., "Hello world").
+	Stdin string
+	// Redirect the command's standard output to a file in the container (e.g., "/tmp/stdout").
+	RedirectStdout string
+	// Redirect the command's standard error to a file in the container (e.g., "/tmp/stderr").
+	RedirectStderr string
+	// Provides dagger access to the executed command.
+	//
+	// Do not use this option unless you trust the command being executed.
+	// The command being executed WILL BE GRANTED FULL ACCESS TO YOUR HOST FILESYSTEM.
+	ExperimentalPrivilegedNesting bool
+	// Execute the command with all root capabilities. This is similar to running a command
+	// with "sudo" or executing `docker run` with the `--privileged` flag. Containerization
+	// does not provide any security guarantees when using this option. It should only be used
+	// when absolutely necessary and only with trusted commands.
+	InsecureRootCapabilities bool
+	// GPUs to make available to the command.
+	//
+	// Requires a worker with the NVIDIA Container Toolkit installed.
+	Gpu GPURequest
+	// Kill the command if it is still running after this many seconds. The exec
+	// then fails with exit code 124, or, with allowFailure, reports timedOut in
+	// its execStats.
+	Timeout int
+	// Maximum number of CPUs the command may use (e.g., 1.5).
+	CPULimit float64
+	// Maximum amount of memory the command may use, in MiB (e.g., 512).
+	// The command is killed if it exceeds this limit.
+	MemoryLimit int
+	// Don't fail if the command exits with a non-zero code. The exit code and
+	// output can then be inspected with exitCode, stdout and stderr.
+	AllowFailure bool
+}
+
+// Retrieves this container after executing the specified command inside it.
+func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Container {
+	q := r.q.Select("withExec")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `skipEntrypoint` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SkipEntrypoint) {
+			q = q.Arg("skipEntrypoint", opts[i].SkipEntrypoint)
+		}
+		// `stdin` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Stdin) {
+			q = q.Arg("stdin", opts[i].Stdin)
+		}
+		// `redirectStdout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RedirectStdout) {
+			q = q.Arg("redirectStdout", opts[i].RedirectStdout)
+		}
+		// `redirectStderr` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RedirectStderr) {
+			q = q.Arg("redirectStderr", opts[i].RedirectStderr)
+		}
+		// `experimentalPrivilegedNesting` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalPrivilegedNesting) {
+			q = q.Arg("experimentalPrivilegedNesting", opts[i].ExperimentalPrivilegedNesting)
+		}
+		// `insecureRootCapabilities` optional argument
+		if !querybuilder.IsZeroValue(opts[i].InsecureRootCapabilities) {
+			q = q.Arg("insecureRootCapabilities", opts[i].InsecureRootCapabilities)
+		}
+		// `gpu` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Gpu) {
+			q = q.Arg("gpu", opts[i].Gpu)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `cpuLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CPULimit) {
+			q = q.Arg("cpuLimit", opts[i].CPULimit)
+		}
+		// `memoryLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MemoryLimit) {
+			q = q.Arg("memoryLimit", opts[i].MemoryLimit)
+		}
+		// `allowFailure` optional argument
+		if !querybuilder.IsZeroValue(opts[i].AllowFailure) {
+			q = q.Arg("allowFailure", opts[i].AllowFailure)
+		}
+	}
+	q = q.Arg("args", args)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithExposedPortOpts contains options for Container.WithExposedPort
+type ContainerWithExposedPortOpts struct {
+	// Transport layer network protocol
+	Protocol NetworkProtocol
+	// Optional port description
+	Description string
+	// Don't wait for the port to accept connections before running commands that use the container as a service
+	SkipHealthcheck bool
+}
+
+// Expose a network port.
+//
+// Exposed ports serve two purposes:
+//   - For health checks and introspection, when running services
+//   - For setting the EXPOSE OCI field when publishing the container
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithExposedPort(port int, opts ...ContainerWithExposedPortOpts) *Container {
+	q := r.q.Select("withExposedPort")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `protocol` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Protocol) {
+			q = q.Arg("protocol", opts[i].Protocol)
+		}
+		// `description` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Description) {
+			q = q.Arg("description", opts[i].Description)
+		}
+		// `skipHealthcheck` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SkipHealthcheck) {
+			q = q.Arg("skipHealthcheck", opts[i].SkipHealthcheck)
+		}
+	}
+	q = q.Arg("port", port)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container plus an /etc/hosts entry for its subsequent
+// commands, e.g. to reach internal hosts or stub out external services.
+func (r *Container) WithExtraHost(hostname string, ip string) *Container {
+	q := r.q.Select("withExtraHost")
+	q = q.Arg("hostname", hostname)
+	q = q.Arg("ip", ip)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Initializes this container from this DirectoryID.
+//
+// Deprecated: Replaced by WithRootfs.
+func (r *Container) WithFS(id *Directory) *Container {
+	q := r.q.Select("withFS")
+	q = q.Arg("id", id)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithFileOpts contains options for Container.WithFile
+type ContainerWithFileOpts struct {
+	// Permission given to the copied file (e.g., 0600).
+	//
+	// Default: 0644.
+	Permissions int
+	// A user:group to set for the file.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus the contents of the given file copied to the given path.
+func (r *Container) WithFile(path string, source *File, opts ...ContainerWithFileOpts) *Container {
+	q := r.q.Select("withFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with the given hostname for its subsequent
+// commands, instead of the one computed from its definition.
+//
+// When the container is used as a service, it is still registered under its
+// computed hostname, and is also reachable via this hostname from the
+// containers it's bound to. Services with the same hostname don't collide.
+func (r *Container) WithHostname(hostname string) *Container {
+	q := r.q.Select("withHostname")
+	q = q.Arg("hostname", hostname)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container plus the given label.
+func (r *Container) WithLabel(name string, value string) *Container {
+	q := r.q.Select("withLabel")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithMountedCacheOpts contains options for Container.WithMountedCache
+type ContainerWithMountedCacheOpts struct {
+	// Identifier of the directory to use as the cache volume's root.
+	Source *Directory
+	// Sharing mode of the cache volume.
+	Sharing CacheSharingMode
+	// A user:group to set for the mounted cache directory.
+	//
+	// Note that this changes the ownership of the specified mount along with the
+	// initial filesystem provided by source (if any). It does not have any effect
+	// if/when the cache has already been created.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a cache volume mounted at the given path.
+func (r *Container) WithMountedCache(path string, cache *CacheVolume, opts ...ContainerWithMountedCacheOpts) *Container {
+	q := r.q.Select("withMountedCache")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `source` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Source) {
+			q = q.Arg("source", opts[i].Source)
+		}
+		// `sharing` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Sharing) {
+			q = q.Arg("sharing", opts[i].Sharing)
+		}
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("cache", cache)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithMountedDirectoryOpts contains options for Container.WithMountedDirectory
+type ContainerWithMountedDirectoryOpts struct {
+	// Patterns to exclude in the mounted directory (e.g., ["node_modules/**", ".gitignore", ".git/"]).
+	Exclude []string
+	// Patterns to include in the mounted directory (e.g., ["*.go", "go.mod", "go.sum"]).
+	Include []string
+	// A user:group to set for the mounted directory and its contents.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Mount the directory read-only, so that it cannot be modified by
+	// commands run in the container.
+	Readonly bool
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a directory mounted at the given path.
+func (r *Container) WithMountedDirectory(path string, source *Directory, opts ...ContainerWithMountedDirectoryOpts) *Container {
+	q := r.q.Select("withMountedDirectory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `readonly` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Readonly) {
+			q = q.Arg("readonly", opts[i].Readonly)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithMountedFileOpts contains options for Container.WithMountedFile
+type ContainerWithMountedFileOpts struct {
+	// A user or user:group to set for the mounted file.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Mount the file read-only, so that it cannot be modified by
+	// commands run in the container.
+	Readonly bool
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a file mounted at the given path.
+func (r *Container) WithMountedFile(path string, source *File, opts ...ContainerWithMountedFileOpts) *Container {
+	q := r.q.Select("withMountedFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `readonly` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Readonly) {
+			q = q.Arg("readonly", opts[i].Readonly)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithMountedSecretOpts contains options for Container.WithMountedSecret
+type ContainerWithMountedSecretOpts struct {
+	// A user:group to set for the mounted secret.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a secret mounted into a file at the given path.
+func (r *Container) WithMountedSecret(path string, source *Secret, opts ...ContainerWithMountedSecretOpts) *Container {
+	q := r.q.Select("withMountedSecret")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithMountedTempOpts contains options for Container.WithMountedTemp
+type ContainerWithMountedTempOpts struct {
+	// Maximum size of the temporary directory in bytes. Unlimited if not set.
+	Size int
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a temporary directory mounted at the given path.
+func (r *Container) WithMountedTemp(path string, opts ...ContainerWithMountedTempOpts) *Container {
+	q := r.q.Select("withMountedTemp")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `size` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Size) {
+			q = q.Arg("size", opts[i].Size)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithNewFileOpts contains options for Container.WithNewFile
+type ContainerWithNewFileOpts struct {
+	// Content of the file to write (e.g., "Hello world!").
+	Contents string
+	// Permission given to the written file (e.g., 0600).
+	//
+	// Default: 0644.
+	Permissions int
+	// A user:group to set for the file.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a new file written at the given path.
+func (r *Container) WithNewFile(path string, opts ...ContainerWithNewFileOpts) *Container {
+	q := r.q.Select("withNewFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `contents` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Contents) {
+			q = q.Arg("contents", opts[i].Contents)
+		}
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with a registry authentication for a given address.
+func (r *Container) WithRegistryAuth(address string, username string, secret *Secret) *Container {
+	q := r.q.Select("withRegistryAuth")
+	q = q.Arg("address", address)
+	q = q.Arg("username", username)
+	q = q.Arg("secret", secret)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Initializes this container from this DirectoryID.
+func (r *Container) WithRootfs(id *Directory) *Container {
+	q := r.q.Select("withRootfs")
+	q = q.Arg("id", id)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container plus an env variable containing the given secret.
+func (r *Container) WithSecretVariable(name string, secret *Secret) *Container {
+	q := r.q.Select("withSecretVariable")
+	q = q.Arg("name", name)
+	q = q.Arg("secret", secret)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Establish a runtime dependency on a service.
+//
+// The service will be started automatically when needed and detached when it is
+// no longer needed, executing the default command if none is set.
+//
+// The service will be reachable from the container via the provided hostname alias.
+//
+// The service dependency will also convey to any files or directories produced by the container.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithServiceBinding(alias string, service *Container) *Container {
+	q := r.q.Select("withServiceBinding")
+	q = q.Arg("alias", alias)
+	q = q.Arg("service", service)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Establishes a runtime dependency on each service in a stack, so that they
+// are started before this container runs its next command and stopped once
+// it is no longer needed.
+//
+// Each service will be reachable from the container via its name in the stack.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithStack(stack *Stack) *Container {
+	q := r.q.Select("withStack")
+	q = q.Arg("stack", stack)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Limits how long to wait for the container's exposed ports to accept
+// connections when it's started as a service.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithStartTimeout(timeout int) *Container {
+	q := r.q.Select("withStartTimeout")
+	q = q.Arg("timeout", timeout)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with a different signal used to stop it.
+func (r *Container) WithStopSignal(signal string) *Container {
+	q := r.q.Select("withStopSignal")
+	q = q.Arg("signal", signal)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithUnixSocketOpts contains options for Container.WithUnixSocket
+type ContainerWithUnixSocketOpts struct {
+	// A user:group to set for the mounted socket.
+	//
+	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
+	//
+	// If the group is omitted, it defaults to the same as the user.
+	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container plus a socket forwarded to the given Unix socket path.
+func (r *Container) WithUnixSocket(path string, source *Socket, opts ...ContainerWithUnixSocketOpts) *Container {
+	q := r.q.Select("withUnixSocket")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `owner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Owner) {
+			q = q.Arg("owner", opts[i].Owner)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with a different command user.
+func (r *Container) WithUser(name string) *Container {
+	q := r.q.Select("withUser")
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container plus the given path declared as a volume in its
+// image config. This is metadata only: no mount is added to the container.
+func (r *Container) WithVolume(path string) *Container {
+	q := r.q.Select("withVolume")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithWorkdirOpts contains options for Container.WithWorkdir
+type ContainerWithWorkdirOpts struct {
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
+// Retrieves this container with a different working directory.
+func (r *Container) WithWorkdir(path string, opts ...ContainerWithWorkdirOpts) *Container {
+	q := r.q.Select("withWorkdir")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with its entrypoint removed, so that future
+// executions run their arguments directly.
+func (r *Container) WithoutEntrypoint() *Container {
+	q := r.q.Select("withoutEntrypoint")
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container minus the given environment variable.
+func (r *Container) WithoutEnvVariable(name string) *Container {
+	q := r.q.Select("withoutEnvVariable")
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithoutExposedPortOpts contains options for Container.WithoutExposedPort
+type ContainerWithoutExposedPortOpts struct {
+	// Port protocol to unexpose
+	Protocol NetworkProtocol
+}
+
+// Unexpose a previously exposed port.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithoutExposedPort(port int, opts ...ContainerWithoutExposedPortOpts) *Container {
+	q := r.q.Select("withoutExposedPort")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `protocol` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Protocol) {
+			q = q.Arg("protocol", opts[i].Protocol)
+		}
+	}
+	q = q.Arg("port", port)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container minus the given environment label.
+func (r *Container) WithoutLabel(name string) *Container {
+	q := r.q.Select("withoutLabel")
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container after unmounting everything at the given path.
+func (r *Container) WithoutMount(path string) *Container {
+	q := r.q.Select("withoutMount")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container without the registry authentication of a given address.
+func (r *Container) WithoutRegistryAuth(address string) *Container {
+	q := r.q.Select("withoutRegistryAuth")
+	q = q.Arg("address", address)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with a previously added Unix socket removed.
+func (r *Container) WithoutUnixSocket(path string) *Container {
+	q := r.q.Select("withoutUnixSocket")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container minus the given path declared as a volume in its
+// image config.
+func (r *Container) WithoutVolume(path string) *Container {
+	q := r.q.Select("withoutVolume")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves the working directory for all commands.
+func (r *Container) Workdir(ctx context.Context) (string, error) {
+	if r.workdir != nil {
+		return *r.workdir, nil
+	}
+	q := r.q.Select("workdir")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A directory.
+type Directory struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	digest  *string
+	export  *bool
+	id      *DirectoryID
+	publish *string
+	sync    *DirectoryID
+}
+type WithDirectoryFunc func(r *Directory) *Directory
+
+func (r *Directory) With(f WithDirectoryFunc) *Directory {
+	return f(r)
+}
+
+// DirectoryArchiveOpts contains options for Directory.Archive
+type DirectoryArchiveOpts struct {
+	// Format of the archive.
+	//
+	// Defaults to TAR.
+	Format ArchiveFormat
+	// Compression of the archive. TAR supports GZIP_COMPRESSION,
+	// ZSTD_COMPRESSION and NO_COMPRESSION, while ZIP supports
+	// DEFLATE_COMPRESSION and NO_COMPRESSION.
+	//
+	// Defaults to GZIP_COMPRESSION for TAR, and DEFLATE_COMPRESSION for ZIP.
+	Compression ArchiveCompression
+}
+
+// Retrieves the contents of this directory as an archive file.
+func (r *Directory) Archive(opts ...DirectoryArchiveOpts) *File {
+	q := r.q.Select("archive")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `format` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Format) {
+			q = q.Arg("format", opts[i].Format)
+		}
+		// `compression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Compression) {
+			q = q.Arg("compression", opts[i].Compression)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Gets the difference between this directory and an another directory.
+func (r *Directory) Diff(other *Directory) *Directory {
+	q := r.q.Select("diff")
+	q = q.Arg("other", other)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A digest of the directory's contents (e.g., "sha256:...").
+//
+// It only depends on the paths, permissions, ownership and contents of the
+// files, so it's stable across builds that produce the same result.
+func (r *Directory) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a directory at the given path.
+func (r *Directory) Directory(path string) *Directory {
+	q := r.q.Select("directory")
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryDockerBuildOpts contains options for Directory.DockerBuild
+type DirectoryDockerBuildOpts struct {
+	// Path to the Dockerfile to use (e.g., "frontend.Dockerfile").
+	//
+	// Defaults: './Dockerfile'.
+	Dockerfile string
+	// The platform to build.
+	Platform Platform
+	// Build arguments to use in the build.
+	BuildArgs []BuildArg
+	// Target build stage to build.
+	Target string
+	// Secrets to pass to the build.
+	//
+	// They will be mounted at /run/secrets/[secret-name].
+	Secrets []*Secret
+}
+
+// Builds a new Docker container from this directory.
+func (r *Directory) DockerBuild(opts ...DirectoryDockerBuildOpts) *Container {
+	q := r.q.Select("dockerBuild")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dockerfile` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Dockerfile) {
+			q = q.Arg("dockerfile", opts[i].Dockerfile)
+		}
+		// `platform` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Platform) {
+			q = q.Arg("platform", opts[i].Platform)
+		}
+		// `buildArgs` optional argument
+		if !querybuilder.IsZeroValue(opts[i].BuildArgs) {
+			q = q.Arg("buildArgs", opts[i].BuildArgs)
+		}
+		// `target` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Target) {
+			q = q.Arg("target", opts[i].Target)
+		}
+		// `secrets` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Secrets) {
+			q = q.Arg("secrets", opts[i].Secrets)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryEntriesOpts contains options for Directory.Entries
+type DirectoryEntriesOpts struct {
+	// Location of the directory to look at (e.g., "/src").
+	Path string
+}
+
+// Returns a list of files and directories at the given path.
+func (r *Directory) Entries(ctx context.Context, opts ...DirectoryEntriesOpts) ([]string, error) {
+	q := r.q.Select("entries")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `path` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Path) {
+			q = q.Arg("path", opts[i].Path)
+		}
+	}
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Writes the contents of the directory to a path on the host.
+func (r *Directory) Export(ctx context.Context, path string) (bool, error) {
+	if r.export != nil {
+		return *r.export, nil
+	}
+	q := r.q.Select("export")
+	q = q.Arg("path", path)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a file at the given path.
+func (r *Directory) File(path string) *File {
+	q := r.q.Select("file")
+	q = q.Arg("path", path)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Returns the paths of the files and directories that match the given
+// pattern, searching recursively.
+func (r *Directory) Glob(ctx context.Context, pattern string) ([]string, error) {
+	q := r.q.Select("glob")
+	q = q.Arg("pattern", pattern)
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The content-addressed identifier of the directory.
+func (r *Directory) ID(ctx context.Context) (DirectoryID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response DirectoryID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Directory) XXX_GraphQLType() string {
+	return "Directory"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Directory) XXX_GraphQLIDType() string {
+	return "DirectoryID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Directory) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// DirectoryPipelineOpts contains options for Directory.Pipeline
+type DirectoryPipelineOpts struct {
+	// Pipeline description.
+	Description string
+	// Pipeline labels.
+	Labels []PipelineLabel
+}
+
+// Creates a named sub-pipeline
+func (r *Directory) Pipeline(name string, opts ...DirectoryPipelineOpts) *Directory {
+	q := r.q.Select("pipeline")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `description` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Description) {
+			q = q.Arg("description", opts[i].Description)
+		}
+		// `labels` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Labels) {
+			q = q.Arg("labels", opts[i].Labels)
+		}
+	}
+	q = q.Arg("name", name)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryPublishOpts contains options for Directory.Publish
+type DirectoryPublishOpts struct {
+	// Media type of the layer, which must be a gzipped layer type.
+	//
+	// Defaults to application/vnd.oci.image.layer.v1.tar+gzip.
+	MediaType string
+}
+
+// Publishes this directory to a registry as an OCI artifact, with its contents
+// archived into a single gzipped tar layer.
+//
+// Returns a fully qualified reference to the artifact, including its digest.
+func (r *Directory) Publish(ctx context.Context, address string, opts ...DirectoryPublishOpts) (string, error) {
+	if r.publish != nil {
+		return *r.publish, nil
+	}
+	q := r.q.Select("publish")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `mediaType` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaType) {
+			q = q.Arg("mediaType", opts[i].MediaType)
+		}
+	}
+	q = q.Arg("address", address)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Forces evaluation of the directory in the engine, surfacing any errors
+// building it.
+func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
+	q := r.q.Select("sync")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// DirectoryWithDirectoryOpts contains options for Directory.WithDirectory
+type DirectoryWithDirectoryOpts struct {
+	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
+	Exclude []string
+	// Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
+	Include []string
+}
+
+// Retrieves this directory plus a directory written at the given path.
+func (r *Directory) WithDirectory(path string, directory *Directory, opts ...DirectoryWithDirectoryOpts) *Directory {
+	q := r.q.Select("withDirectory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("directory", directory)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryWithFileOpts contains options for Directory.WithFile
+type DirectoryWithFileOpts struct {
+	// Permission given to the copied file (e.g., 0600).
+	//
+	// Default: 0644.
+	Permissions int
+}
+
+// Retrieves this directory plus the contents of the given file copied to the given path.
+func (r *Directory) WithFile(path string, source *File, opts ...DirectoryWithFileOpts) *Directory {
+	q := r.q.Select("withFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("source", source)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryWithNewDirectoryOpts contains options for Directory.WithNewDirectory
+type DirectoryWithNewDirectoryOpts struct {
+	// Permission granted to the created directory (e.g., 0777).
+	//
+	// Default: 0755.
+	Permissions int
+}
+
+// Retrieves this directory plus a new directory created at the given path.
+func (r *Directory) WithNewDirectory(path string, opts ...DirectoryWithNewDirectoryOpts) *Directory {
+	q := r.q.Select("withNewDirectory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// DirectoryWithNewFileOpts contains options for Directory.WithNewFile
+type DirectoryWithNewFileOpts struct {
+	// Permission given to the copied file (e.g., 0600).
+	//
+	// Default: 0644.
+	Permissions int
+}
+
+// Retrieves this directory plus a new file written at the given path.
+func (r *Directory) WithNewFile(path string, contents string, opts ...DirectoryWithNewFileOpts) *Directory {
+	q := r.q.Select("withNewFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("contents", contents)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this directory with all file/dir timestamps set to the given time.
+func (r *Directory) WithTimestamps(timestamp int) *Directory {
+	q := r.q.Select("withTimestamps")
+	q = q.Arg("timestamp", timestamp)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this directory with the directory at the given path removed.
+func (r *Directory) WithoutDirectory(path string) *Directory {
+	q := r.q.Select("withoutDirectory")
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this directory with the file at the given path removed.
+func (r *Directory) WithoutFile(path string) *Directory {
+	q := r.q.Select("withoutFile")
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A simple key value object that represents an environment variable.
+type EnvVariable struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	name  *string
+	value *string
+}
+
+// The environment variable name.
+func (r *EnvVariable) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.q.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The environment variable value.
+func (r *EnvVariable) Value(ctx context.Context) (string, error) {
+	if r.value != nil {
+		return *r.value, nil
+	}
+	q := r.q.Select("value")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Resource usage statistics of an executed command.
+type ExecStats struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	duration   *float64
+	maxRSS     *int
+	oomKilled  *bool
+	signal     *string
+	systemTime *float64
+	timedOut   *bool
+	userTime   *float64
+}
+
+// Wall-clock duration of the command, in seconds.
+func (r *ExecStats) Duration(ctx context.Context) (float64, error) {
+	if r.duration != nil {
+		return *r.duration, nil
+	}
+	q := r.q.Select("duration")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Maximum resident set size of the command, in bytes.
+func (r *ExecStats) MaxRSS(ctx context.Context) (int, error) {
+	if r.maxRSS != nil {
+		return *r.maxRSS, nil
+	}
+	q := r.q.Select("maxRSS")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Whether the command or one of its children was killed for running out of memory.
+func (r *ExecStats) OomKilled(ctx context.Context) (bool, error) {
+	if r.oomKilled != nil {
+		return *r.oomKilled, nil
+	}
+	q := r.q.Select("oomKilled")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The signal that killed the command, if any (e.g., SIGKILL).
+func (r *ExecStats) Signal(ctx context.Context) (string, error) {
+	if r.signal != nil {
+		return *r.signal, nil
+	}
+	q := r.q.Select("signal")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// CPU time spent in kernel mode, in seconds.
+func (r *ExecStats) SystemTime(ctx context.Context) (float64, error) {
+	if r.systemTime != nil {
+		return *r.systemTime, nil
+	}
+	q := r.q.Select("systemTime")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Whether the command was killed for exceeding its timeout.
+func (r *ExecStats) TimedOut(ctx context.Context) (bool, error) {
+	if r.timedOut != nil {
+		return *r.timedOut, nil
+	}
+	q := r.q.Select("timedOut")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// CPU time spent in user mode, in seconds.
+func (r *ExecStats) UserTime(ctx context.Context) (float64, error) {
+	if r.userTime != nil {
+		return *r.userTime, nil
+	}
+	q := r.q.Select("userTime")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A file.
+type File struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	contents *string
+	export   *bool
+	id       *FileID
+	publish  *string
+	size     *int
+}
+
+// Retrieves the contents of the file.
+func (r *File) Contents(ctx context.Context) (string, error) {
+	if r.contents != nil {
+		return *r.contents, nil
+	}
+	q := r.q.Select("contents")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// FileExportOpts contains options for File.Export
+type FileExportOpts struct {
+	// If allowParentDirPath is true, the path argument can be a directory path, in which case
+	// the file will be created in that directory.
+	AllowParentDirPath bool
+}
+
+// Writes the file to a file path on the host.
+func (r *File) Export(ctx context.Context, path string, opts ...FileExportOpts) (bool, error) {
+	if r.export != nil {
+		return *r.export, nil
+	}
+	q := r.q.Select("export")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `allowParentDirPath` optional argument
+		if !querybuilder.IsZeroValue(opts[i].AllowParentDirPath) {
+			q = q.Arg("allowParentDirPath", opts[i].AllowParentDirPath)
+		}
+	}
+	q = q.Arg("path", path)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the content-addressed identifier of the file.
+func (r *File) ID(ctx context.Context) (FileID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response FileID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *File) XXX_GraphQLType() string {
+	return "File"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *File) XXX_GraphQLIDType() string {
+	return "FileID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *File) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// FilePublishOpts contains options for File.Publish
+type FilePublishOpts struct {
+	// Media type of the layer (e.g., "application/spdx+json").
+	//
+	// Defaults to application/vnd.oci.image.layer.v1.tar.
+	MediaType string
+}
+
+// Publishes this file to a registry as an OCI artifact, with its contents as a
+// single layer.
+//
+// Returns a fully qualified reference to the artifact, including its digest.
+func (r *File) Publish(ctx context.Context, address string, opts ...FilePublishOpts) (string, error) {
+	if r.publish != nil {
+		return *r.publish, nil
+	}
+	q := r.q.Select("publish")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `mediaType` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaType) {
+			q = q.Arg("mediaType", opts[i].MediaType)
+		}
+	}
+	q = q.Arg("address", address)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a secret referencing the contents of this file.
+//
+// Deprecated: insecure, leaves secret in cache. Superseded by SetSecret
+func (r *File) Secret() *Secret {
+	q := r.q.Select("secret")
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Gets the size of the file, in bytes.
+func (r *File) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the contents of this archive file as a directory.
+//
+// Supports tar archives, optionally compressed with gzip, bzip2, xz or zstd,
+// and zip archives.
+func (r *File) Unpack() *Directory {
+	q := r.q.Select("unpack")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this file with its created/modified timestamps set to the given time.
+func (r *File) WithTimestamps(timestamp int) *File {
+	q := r.q.Select("withTimestamps")
+	q = q.Arg("timestamp", timestamp)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A git ref (tag, branch or commit).
+type GitRef struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	digest *string
+}
+
+// The digest of the current value of this ref.
+func (r *GitRef) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// GitRefTreeOpts contains options for GitRef.Tree
+type GitRefTreeOpts struct {
+	SSHKnownHosts string
+
+	SSHAuthSocket *Socket
+}
+
+// The filesystem tree at this ref.
+func (r *GitRef) Tree(opts ...GitRefTreeOpts) *Directory {
+	q := r.q.Select("tree")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `sshKnownHosts` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SSHKnownHosts) {
+			q = q.Arg("sshKnownHosts", opts[i].SSHKnownHosts)
+		}
+		// `sshAuthSocket` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SSHAuthSocket) {
+			q = q.Arg("sshAuthSocket", opts[i].SSHAuthSocket)
+		}
+	}
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A git repository.
+type GitRepository struct {
+	q *querybuilder.Selection
+	c graphql.Client
+}
+
+// Returns details on one branch.
+func (r *GitRepository) Branch(name string) *GitRef {
+	q := r.q.Select("branch")
+	q = q.Arg("name", name)
+
+	return &GitRef{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Lists of branches on the repository.
+func (r *GitRepository) Branches(ctx context.Context) ([]string, error) {
+	q := r.q.Select("branches")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Returns details on one commit.
+func (r *GitRepository) Commit(id string) *GitRef {
+	q := r.q.Select("commit")
+	q = q.Arg("id", id)
+
+	return &GitRef{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Returns details on one tag.
+func (r *GitRepository) Tag(name string) *GitRef {
+	q := r.q.Select("tag")
+	q = q.Arg("name", name)
+
+	return &GitRef{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Lists of tags on the repository.
+func (r *GitRepository) Tags(ctx context.Context) ([]string, error) {
+	q := r.q.Select("tags")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Information about the host execution environment.
+type Host struct {
+	q *querybuilder.Selection
+	c graphql.Client
+}
+
+// HostDirectoryOpts contains options for Host.Directory
+type HostDirectoryOpts struct {
+	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
+	Exclude []string
+	// Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
+	Include []string
+}
+
+// Accesses a directory on the host.
+func (r *Host) Directory(path string, opts ...HostDirectoryOpts) *Directory {
+	q := r.q.Select("directory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Accesses an environment variable on the host.
+func (r *Host) EnvVariable(name string) *HostVariable {
+	q := r.q.Select("envVariable")
+	q = q.Arg("name", name)
+
+	return &HostVariable{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Accesses a file on the host.
+func (r *Host) File(path string) *File {
+	q := r.q.Select("file")
+	q = q.Arg("path", path)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Accesses a Unix socket on the host.
+func (r *Host) UnixSocket(path string) *Socket {
+	q := r.q.Select("unixSocket")
+	q = q.Arg("path", path)
+
+	return &Socket{
+		q: q,
+		c: r.c,
+	}
+}
+
+// HostWorkdirOpts contains options for Host.Workdir
+type HostWorkdirOpts struct {
+	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
+	Exclude []string
+	// Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
+	Include []string
+}
+
+// Retrieves the current working directory on the host.
+//
+// Deprecated: Use Directory with path set to '.' instead.
+func (r *Host) Workdir(opts ...HostWorkdirOpts) *Directory {
+	q := r.q.Select("workdir")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+	}
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// An environment variable on the host environment.
+type HostVariable struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	value *string
+}
+
+// A secret referencing the value of this variable.
+//
+// Deprecated: been superseded by SetSecret
+func (r *HostVariable) Secret() *Secret {
+	q := r.q.Select("secret")
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The value of this variable.
+func (r *HostVariable) Value(ctx context.Context) (string, error) {
+	if r.value != nil {
+		return *r.value, nil
+	}
+	q := r.q.Select("value")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A simple key value object that represents a label.
+type Label struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	name  *string
+	value *string
+}
+
+// The label name.
+func (r *Label) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.q.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The label value.
+func (r *Label) Value(ctx context.Context) (string, error) {
+	if r.value != nil {
+		return *r.value, nil
+	}
+	q := r.q.Select("value")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A mount in a container.
+type Mount struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	path       *string
+	sourcePath *string
+	type_      *MountType
+}
+
+// The path the mount is at within the container.
+func (r *Mount) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.q.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The path within the source that is mounted, if it's not the source's root.
+func (r *Mount) SourcePath(ctx context.Context) (string, error) {
+	if r.sourcePath != nil {
+		return *r.sourcePath, nil
+	}
+	q := r.q.Select("sourcePath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The kind of source that is mounted.
+func (r *Mount) Type(ctx context.Context) (MountType, error) {
+	if r.type_ != nil {
+		return *r.type_, nil
+	}
+	q := r.q.Select("type")
+
+	var response MountType
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A port exposed by a container.
+type Port struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	description *string
+	port        *int
+	protocol    *NetworkProtocol
+}
+
+// The port description.
+func (r *Port) Description(ctx context.Context) (string, error) {
+	if r.description != nil {
+		return *r.description, nil
+	}
+	q := r.q.Select("description")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The port number.
+func (r *Port) Port(ctx context.Context) (int, error) {
+	if r.port != nil {
+		return *r.port, nil
+	}
+	q := r.q.Select("port")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The transport layer network protocol.
+func (r *Port) Protocol(ctx context.Context) (NetworkProtocol, error) {
+	if r.protocol != nil {
+		return *r.protocol, nil
+	}
+	q := r.q.Select("protocol")
+
+	var response NetworkProtocol
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A collection of Dagger resources that can be queried and invoked.
+type Project struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id   *ProjectID
+	name *string
+}
+
+// Commands provided by this project
+func (r *Project) Commands(ctx context.Context) ([]ProjectCommand, error) {
+	q := r.q.Select("commands")
+
+	q = q.Select("description id name resultType")
+
+	type commands struct {
+		Description string
+		Id          ProjectCommandID
+		Name        string
+		ResultType  string
+	}
+
+	convert := func(fields []commands) []ProjectCommand {
+		out := []ProjectCommand{}
+
+		for i := range fields {
+			out = append(out, ProjectCommand{description: &fields[i].Description, id: &fields[i].Id, name: &fields[i].Name, resultType: &fields[i].ResultType})
+		}
+
+		return out
+	}
+	var response []commands
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// A unique identifier for this project.
+func (r *Project) ID(ctx context.Context) (ProjectID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response ProjectID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Project) XXX_GraphQLType() string {
+	return "Project"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Project) XXX_GraphQLIDType() string {
+	return "ProjectID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Project) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// Initialize this project from the given directory and config path
+func (r *Project) Load(source *Directory, configPath string) *Project {
+	q := r.q.Select("load")
+	q = q.Arg("source", source)
+	q = q.Arg("configPath", configPath)
+
+	return &Project{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Name of the project
+func (r *Project) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.q.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A command defined in a project that can be invoked from the CLI.
+type ProjectCommand struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	description *string
+	id          *ProjectCommandID
+	name        *string
+	resultType  *string
+}
+
+// Documentation for what this command does.
+func (r *ProjectCommand) Description(ctx context.Context) (string, error) {
+	if r.description != nil {
+		return *r.description, nil
+	}
+	q := r.q.Select("description")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Flags accepted by this command.
+func (r *ProjectCommand) Flags(ctx context.Context) ([]ProjectCommandFlag, error) {
+	q := r.q.Select("flags")
+
+	q = q.Select("description name")
+
+	type flags struct {
+		Description string
+		Name        string
+	}
+
+	convert := func(fields []flags) []ProjectCommandFlag {
+		out := []ProjectCommandFlag{}
+
+		for i := range fields {
+			out = append(out, ProjectCommandFlag{description: &fields[i].Description, name: &fields[i].Name})
+		}
+
+		return out
+	}
+	var response []flags
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// A unique identifier for this command.
+func (r *ProjectCommand) ID(ctx context.Context) (ProjectCommandID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response ProjectCommandID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ProjectCommand) XXX_GraphQLType() string {
+	return "ProjectCommand"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ProjectCommand) XXX_GraphQLIDType() string {
+	return "ProjectCommandID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ProjectCommand) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// The name of the command.
+func (r *ProjectCommand) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.q.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The name of the type returned by this command.
+func (r *ProjectCommand) ResultType(ctx context.Context) (string, error) {
+	if r.resultType != nil {
+		return *r.resultType, nil
+	}
+	q := r.q.Select("resultType")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Subcommands, if any, that this command provides.
+func (r *ProjectCommand) Subcommands(ctx context.Context) ([]ProjectCommand, error) {
+	q := r.q.Select("subcommands")
+
+	q = q.Select("description id name resultType")
+
+	type subcommands struct {
+		Description string
+		Id          ProjectCommandID
+		Name        string
+		ResultType  string
+	}
+
+	convert := func(fields []subcommands) []ProjectCommand {
+		out := []ProjectCommand{}
+
+		for i := range fields {
+			out = append(out, ProjectCommand{description: &fields[i].Description, id: &fields[i].Id, name: &fields[i].Name, resultType: &fields[i].ResultType})
+		}
+
+		return out
+	}
+	var response []subcommands
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// A flag accepted by a project command.
+type ProjectCommandFlag struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	description *string
+	name        *string
+}
+
+// Documentation for what this flag sets.
+func (r *ProjectCommandFlag) Description(ctx context.Context) (string, error) {
+	if r.description != nil {
+		return *r.description, nil
+	}
+	q := r.q.Select("description")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The name of the flag.
+func (r *ProjectCommandFlag) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.q.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Pulls an OCI artifact (e.g., one pushed by Directory.publish, File.publish, or
+// ORAS) and loads its contents as a directory.
+//
+// Each layer is written to a file named by its org.opencontainers.image.title
+// annotation; layers of published directories are unpacked instead. Layers
+// without a title are skipped.
+func (r *Client) Artifact(address string) *Directory {
+	q := r.q.Select("artifact")
+	q = q.Arg("address", address)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Constructs a cache volume for a given cache key.
+func (r *Client) CacheVolume(key string) *CacheVolume {
+	q := r.q.Select("cacheVolume")
+	q = q.Arg("key", key)
+
+	return &CacheVolume{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerOpts contains options for Query.Container
+type ContainerOpts struct {
+	ID ContainerID
+
+	Platform Platform
+}
+
+// Loads a container from ID.
+//
+// Null ID returns an empty container (scratch).
+// Optional platform argument initializes new containers to execute and publish as that platform.
+// Platform defaults to that of the builder's host.
+func (r *Client) Container(opts ...ContainerOpts) *Container {
+	q := r.q.Select("container")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+		// `platform` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Platform) {
+			q = q.Arg("platform", opts[i].Platform)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The default platform of the builder.
+func (r *Client) DefaultPlatform(ctx context.Context) (Platform, error) {
+	q := r.q.Select("defaultPlatform")
+
+	var response Platform
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// DirectoryOpts contains options for Query.Directory
+type DirectoryOpts struct {
+	ID DirectoryID
+}
+
+// Load a directory by ID. No argument produces an empty directory.
+func (r *Client) Directory(opts ...DirectoryOpts) *Directory {
+	q := r.q.Select("directory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Loads a file by ID.
+func (r *Client) File(id FileID) *File {
+	q := r.q.Select("file")
+	q = q.Arg("id", id)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// GitOpts contains options for Query.Git
+type GitOpts struct {
+	// Set to true to keep .git directory.
+	KeepGitDir bool
+	// A service which must be started before the repo is fetched.
+	ExperimentalServiceHost *Container
+}
+
+// Queries a git repository.
+func (r *Client) Git(url string, opts ...GitOpts) *GitRepository {
+	q := r.q.Select("git")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `keepGitDir` optional argument
+		if !querybuilder.IsZeroValue(opts[i].KeepGitDir) {
+			q = q.Arg("keepGitDir", opts[i].KeepGitDir)
+		}
+		// `experimentalServiceHost` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalServiceHost) {
+			q = q.Arg("experimentalServiceHost", opts[i].ExperimentalServiceHost)
+		}
+	}
+	q = q.Arg("url", url)
+
+	return &GitRepository{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Queries the host environment.
+func (r *Client) Host() *Host {
+	q := r.q.Select("host")
+
+	return &Host{
+		q: q,
+		c: r.c,
+	}
+}
+
+// HTTPOpts contains options for Query.HTTP
+type HTTPOpts struct {
+	// A service which must be started before the URL is fetched.
+	ExperimentalServiceHost *Container
+}
+
+// Returns a file containing an http remote url content.
+func (r *Client) HTTP(url string, opts ...HTTPOpts) *File {
+	q := r.q.Select("http")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `experimentalServiceHost` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalServiceHost) {
+			q = q.Arg("experimentalServiceHost", opts[i].ExperimentalServiceHost)
+		}
+	}
+	q = q.Arg("url", url)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// PipelineOpts contains options for Query.Pipeline
+type PipelineOpts struct {
+	// Pipeline description.
+	Description string
+	// Pipeline labels.
+	Labels []PipelineLabel
+}
+
+// Creates a named sub-pipeline.
+func (r *Client) Pipeline(name string, opts ...PipelineOpts) *Client {
+	q := r.q.Select("pipeline")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `description` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Description) {
+			q = q.Arg("description", opts[i].Description)
+		}
+		// `labels` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Labels) {
+			q = q.Arg("labels", opts[i].Labels)
+		}
+	}
+	q = q.Arg("name", name)
+
+	return &Client{
+		q: q,
+		c: r.c,
+	}
+}
+
+// PreloadImagesOpts contains options for Query.PreloadImages
+type PreloadImagesOpts struct {
+	// Image references to pull (e.g., "docker.io/library/alpine:3.18").
+	Addresses []string
+	// OCI tarball to load images from (e.g., one written by Container.export).
+	Archive *File
+	// Platform to pull images for. Defaults to that of the builder's host.
+	Platform Platform
+}
+
+// Loads images into the engine's cache ahead of time, so that pipelines using
+// them don't need to pull during their run.
+//
+// Returns true once every image has been loaded.
+func (r *Client) PreloadImages(ctx context.Context, opts ...PreloadImagesOpts) (bool, error) {
+	q := r.q.Select("preloadImages")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `addresses` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Addresses) {
+			q = q.Arg("addresses", opts[i].Addresses)
+		}
+		// `archive` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Archive) {
+			q = q.Arg("archive", opts[i].Archive)
+		}
+		// `platform` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Platform) {
+			q = q.Arg("platform", opts[i].Platform)
+		}
+	}
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ProjectOpts contains options for Query.Project
+type ProjectOpts struct {
+	ID ProjectID
+}
+
+// Load a project from ID.
+func (r *Client) Project(opts ...ProjectOpts) *Project {
+	q := r.q.Select("project")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &Project{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ProjectCommandOpts contains options for Query.ProjectCommand
+type ProjectCommandOpts struct {
+	ID ProjectCommandID
+}
+
+// Load a project command from ID.
+func (r *Client) ProjectCommand(opts ...ProjectCommandOpts) *ProjectCommand {
+	q := r.q.Select("projectCommand")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &ProjectCommand{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Loads a secret from its ID.
+func (r *Client) Secret(id SecretID) *Secret {
+	q := r.q.Select("secret")
+	q = q.Arg("id", id)
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Loads a service from ID.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Client) Service(id ServiceID) *Service {
+	q := r.q.Select("service")
+	q = q.Arg("id", id)
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Sets a secret given a user defined name to its plaintext and returns the secret.
+// The plaintext value is limited to a size of 128000 bytes.
+func (r *Client) SetSecret(name string, plaintext string) *Secret {
+	q := r.q.Select("setSecret")
+	q = q.Arg("name", name)
+	q = q.Arg("plaintext", plaintext)
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
+// SocketOpts contains options for Query.Socket
+type SocketOpts struct {
+	ID SocketID
+}
+
+// Loads a socket by its ID.
+func (r *Client) Socket(opts ...SocketOpts) *Socket {
+	q := r.q.Select("socket")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &Socket{
+		q: q,
+		c: r.c,
+	}
+}
+
+// StackOpts contains options for Query.Stack
+type StackOpts struct {
+	ID StackID
+}
+
+// Loads a stack from ID.
+//
+// Null ID returns an empty stack.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Client) Stack(opts ...StackOpts) *Stack {
+	q := r.q.Select("stack")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &Stack{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A reference to a secret value, which can be handled more safely than the value itself.
+type Secret struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id        *SecretID
+	plaintext *string
+}
+
+// The identifier for this secret.
+func (r *Secret) ID(ctx context.Context) (SecretID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response SecretID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Secret) XXX_GraphQLType() string {
+	return "Secret"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Secret) XXX_GraphQLIDType() string {
+	return "SecretID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Secret) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// The value of this secret.
+func (r *Secret) Plaintext(ctx context.Context) (string, error) {
+	if r.plaintext != nil {
+		return *r.plaintext, nil
+	}
+	q := r.q.Select("plaintext")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A container running its default command as a long-running service, which can
+// be started and stopped explicitly.
+type Service struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	endpoint *string
+	hostname *string
+	id       *ServiceID
+	start    *ServiceID
+	stop     *ServiceID
+}
+
+// The container that the service runs.
+func (r *Service) Container() *Container {
+	q := r.q.Select("container")
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ServiceEndpointOpts contains options for Service.Endpoint
+type ServiceEndpointOpts struct {
+	// The exposed port number for the endpoint
+	Port int
+	// Return a URL with the given scheme, eg. http for http://
+	Scheme string
+}
+
+// Retrieves an endpoint that clients can use to reach this service.
+//
+// If no port is specified, the first exposed port is used. If none exist an error is returned.
+//
+// If a scheme is specified, a URL is returned. Otherwise, a host:port pair is returned.
+func (r *Service) Endpoint(ctx context.Context, opts ...ServiceEndpointOpts) (string, error) {
+	if r.endpoint != nil {
+		return *r.endpoint, nil
+	}
+	q := r.q.Select("endpoint")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `port` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Port) {
+			q = q.Arg("port", opts[i].Port)
+		}
+		// `scheme` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Scheme) {
+			q = q.Arg("scheme", opts[i].Scheme)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a hostname which can be used by clients to reach this service.
+func (r *Service) Hostname(ctx context.Context) (string, error) {
+	if r.hostname != nil {
+		return *r.hostname, nil
+	}
+	q := r.q.Select("hostname")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A unique identifier for this service.
+func (r *Service) ID(ctx context.Context) (ServiceID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response ServiceID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Service) XXX_GraphQLType() string {
+	return "Service"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Service) XXX_GraphQLIDType() string {
+	return "ServiceID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Service) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// Starts the service and waits for its exposed ports to accept connections.
+//
+// The service keeps running until it is stopped or the session ends. Starting
+// a service which is already running has no effect.
+func (r *Service) Start(ctx context.Context) (*Service, error) {
+	q := r.q.Select("start")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// Stops the service, if it was started with start.
+func (r *Service) Stop(ctx context.Context) (*Service, error) {
+	q := r.q.Select("stop")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// A Unix or TCP/IP socket that can be mounted into a container.
+type Socket struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id *SocketID
+}
+
+// The content-addressed identifier of the socket.
+func (r *Socket) ID(ctx context.Context) (SocketID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response SocketID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Socket) XXX_GraphQLType() string {
+	return "Socket"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Socket) XXX_GraphQLIDType() string {
+	return "SocketID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Socket) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// A named set of service containers which are started and stopped together,
+// in the style of a Compose file.
+type Stack struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id *StackID
+}
+
+// A unique identifier for this stack.
+func (r *Stack) ID(ctx context.Context) (StackID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response StackID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Stack) XXX_GraphQLType() string {
+	return "Stack"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Stack) XXX_GraphQLIDType() string {
+	return "StackID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Stack) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// Retrieves the named service's container, with its dependencies bound to it.
+func (r *Stack) Service(name string) *Container {
+	q := r.q.Select("service")
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The names of the stack's services, in the order they were added.
+func (r *Stack) Services(ctx context.Context) ([]string, error) {
+	q := r.q.Select("services")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// StackWithServiceOpts contains options for Stack.WithService
+type StackWithServiceOpts struct {
+	// Names of the services which must be running before this service starts.
+	DependsOn []string
+}
+
+// Adds a service to the stack, replacing any existing service with the same name.
+//
+// The service runs the container's default command once each of the services
+// it depends on is reachable from it by their names.
+func (r *Stack) WithService(name string, container *Container, opts ...StackWithServiceOpts) *Stack {
+	q := r.q.Select("withService")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dependsOn` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DependsOn) {
+			q = q.Arg("dependsOn", opts[i].DependsOn)
+		}
+	}
+	q = q.Arg("name", name)
+	q = q.Arg("container", container)
+
+	return &Stack{
+		q: q,
+		c: r.c,
+	}
+}
+
+type ArchiveCompression string
+
+const (
+	DeflateCompression ArchiveCompression = "DEFLATE_COMPRESSION"
+	GzipCompression    ArchiveCompression = "GZIP_COMPRESSION"
+	NoCompression      ArchiveCompression = "NO_COMPRESSION"
+	ZstdCompression    ArchiveCompression = "ZSTD_COMPRESSION"
+)
+
+type ArchiveFormat string
+
+const (
+	Tar ArchiveFormat = "TAR"
+	Zip ArchiveFormat = "ZIP"
+)
+
+type CacheSharingMode string
+
+const (
+	Locked  CacheSharingMode = "LOCKED"
+	Private CacheSharingMode = "PRIVATE"
+	Shared  CacheSharingMode = "SHARED"
+)
+
+type ImageLayerCompression string
+
+const (
+	Estargz      ImageLayerCompression = "EStarGZ"
+	Gzip         ImageLayerCompression = "Gzip"
+	Uncompressed ImageLayerCompression = "Uncompressed"
+	Zstd         ImageLayerCompression = "Zstd"
+)
+
+type ImageMediaTypes string
+
+const (
+	Dockermediatypes ImageMediaTypes = "DockerMediaTypes"
+	Ocimediatypes    ImageMediaTypes = "OCIMediaTypes"
+)
+
+type MountType string
+
+const (
+	CacheMount     MountType = "CACHE_MOUNT"
+	DirectoryMount MountType = "DIRECTORY_MOUNT"
+	FileMount      MountType = "FILE_MOUNT"
+	TmpfsMount     MountType = "TMPFS_MOUNT"
+)
+
+type NetworkProtocol string
+
+const (
+	Tcp NetworkProtocol = "TCP"
+	Udp NetworkProtocol = "UDP"
+)
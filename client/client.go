@@ -0,0 +1,29 @@
+//go:generate go run ../cmd/client-gen -o api.gen.go --package client --lang go --querybuilder github.com/dagger/dagger/client/internal/querybuilder
+
+// Package client is a typed client for the API served by a Router, generated
+// from the router's schema, so that Go programs embedding the engine can
+// build queries without writing GraphQL by hand.
+package client
+
+import (
+	"github.com/Khan/genqlient/graphql"
+
+	"github.com/dagger/dagger/client/internal/querybuilder"
+	"github.com/dagger/dagger/router"
+)
+
+// Client queries a Router directly, without going through a network
+// connection.
+type Client struct {
+	q *querybuilder.Selection
+	c graphql.Client
+}
+
+// New returns a client for the given router.
+func New(r *router.Router) *Client {
+	conn := router.EngineConn(r)
+	return &Client{
+		q: querybuilder.Query(),
+		c: graphql.NewClient("http://"+conn.Host()+"/query", conn),
+	}
+}
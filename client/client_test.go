@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dagger/dagger/router"
+	"github.com/dagger/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	r := router.New("", nil)
+	require.NoError(t, r.Add(router.StaticSchema(router.StaticSchemaParams{
+		Name: "test",
+		Schema: `
+		type Query {
+			defaultPlatform: String!
+		}
+		`,
+		Resolvers: router.Resolvers{
+			"Query": router.ObjectResolver{
+				"defaultPlatform": func(p graphql.ResolveParams) (any, error) {
+					return "linux/riscv64", nil
+				},
+			},
+		},
+	})))
+
+	platform, err := New(r).DefaultPlatform(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Platform("linux/riscv64"), platform)
+}
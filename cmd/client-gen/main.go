@@ -36,6 +36,7 @@ func init() {
 	rootCmd.Flags().StringP("output", "o", "", "output file")
 	rootCmd.Flags().String("package", "", "package name")
 	rootCmd.Flags().String("lang", "", "language to generate in")
+	rootCmd.Flags().String("querybuilder", generator.DefaultQueryBuilder, "import path of the querybuilder package used by generated Go code")
 }
 
 func ClientGen(cmd *cobra.Command, args []string) error {
@@ -61,9 +62,15 @@ func ClientGen(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		queryBuilder, err := cmd.Flags().GetString("querybuilder")
+		if err != nil {
+			return err
+		}
+
 		generated, err := generate(ctx, schema, generator.Config{
-			Package: pkg,
-			Lang:    generator.SDKLang(lang),
+			Package:      pkg,
+			Lang:         generator.SDKLang(lang),
+			QueryBuilder: queryBuilder,
 		})
 		if err != nil {
 			return err
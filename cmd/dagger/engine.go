@@ -50,19 +50,7 @@ func withEngineAndTUI(
 	engineConf engine.Config,
 	fn engine.StartCallback,
 ) error {
-	if engineConf.Workdir == "" {
-		engineConf.Workdir = workdir
-	}
-
-	if engineConf.RunnerHost == "" {
-		engineConf.RunnerHost = internalengine.RunnerHost()
-	}
-
-	engineConf.DisableHostRW = disableHostRW
-
-	if engineConf.JournalFile == "" {
-		engineConf.JournalFile = os.Getenv("_EXPERIMENTAL_DAGGER_JOURNAL")
-	}
+	engineConf = configFromEnv(engineConf)
 
 	if !silent {
 		if progress == "auto" && autoTTY || progress == "tty" {
@@ -217,3 +205,47 @@ func (p progrockFileWriter) WriteStatus(update *progrock.StatusUpdate) error {
 func (p progrockFileWriter) Close() error {
 	return p.c.Close()
 }
+
+// configFromEnv fills in the engine configuration that is not set from the
+// command line and experimental environment variables.
+func configFromEnv(engineConf engine.Config) engine.Config {
+	if engineConf.Workdir == "" {
+		engineConf.Workdir = workdir
+	}
+
+	if engineConf.RunnerHost == "" {
+		engineConf.RunnerHost = internalengine.RunnerHost()
+	}
+
+	engineConf.DisableHostRW = disableHostRW
+
+	if engineConf.JournalFile == "" {
+		engineConf.JournalFile = os.Getenv("_EXPERIMENTAL_DAGGER_JOURNAL")
+	}
+
+	if engineConf.AuditLog == "" {
+		engineConf.AuditLog = os.Getenv("_EXPERIMENTAL_DAGGER_AUDIT_LOG")
+	}
+
+	if engineConf.Webhooks == "" {
+		engineConf.Webhooks = os.Getenv("_EXPERIMENTAL_DAGGER_WEBHOOKS")
+	}
+
+	if engineConf.Log.Level == "" {
+		engineConf.Log = logConfigFromEnv()
+	}
+
+	if engineConf.AdmissionPolicy == "" {
+		engineConf.AdmissionPolicy = os.Getenv("_EXPERIMENTAL_DAGGER_ADMISSION_POLICY")
+	}
+
+	if engineConf.Plugins == "" {
+		engineConf.Plugins = os.Getenv("_EXPERIMENTAL_DAGGER_PLUGINS")
+	}
+
+	if !engineConf.Offline {
+		engineConf.Offline = os.Getenv("_EXPERIMENTAL_DAGGER_OFFLINE") != ""
+	}
+
+	return engineConf
+}
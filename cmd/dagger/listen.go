@@ -18,6 +18,14 @@ import (
 var (
 	listenAddress string
 	disableHostRW bool
+	rateLimits    router.RateLimits
+	policyFile    string
+	admissionFile string
+	playground    bool
+	offline       bool
+	plugins       string
+	gwRecording   string
+	gwExecOutput  bool
 )
 
 var listenCmd = &cobra.Command{
@@ -31,11 +39,35 @@ var listenCmd = &cobra.Command{
 func init() {
 	listenCmd.Flags().StringVarP(&listenAddress, "listen", "", "127.0.0.1:8080", "Listen on network address ADDR")
 	listenCmd.Flags().BoolVar(&disableHostRW, "disable-host-read-write", false, "disable host read/write access")
+	listenCmd.Flags().Float64Var(&rateLimits.ClientRPS, "client-rate-limit", 0, "maximum queries per second accepted from a single client (0 for no limit)")
+	listenCmd.Flags().IntVar(&rateLimits.ClientBurst, "client-rate-burst", 0, "maximum burst of queries accepted from a single client")
+	listenCmd.Flags().Float64Var(&rateLimits.GlobalRPS, "global-rate-limit", 0, "maximum queries per second accepted across all clients (0 for no limit)")
+	listenCmd.Flags().IntVar(&rateLimits.GlobalBurst, "global-rate-burst", 0, "maximum burst of queries accepted across all clients")
+	listenCmd.Flags().IntVar(&rateLimits.MaxConcurrent, "max-concurrent-queries", 0, "maximum number of queries evaluated concurrently (0 for no limit)")
+	listenCmd.Flags().StringVar(&policyFile, "policy", "", "path to a JSON policy restricting fields to authenticated roles")
+	listenCmd.Flags().StringVar(&admissionFile, "admission-policy", "", "path to a JSON admission policy for image references and exec options")
+	listenCmd.Flags().BoolVar(&playground, "playground", false, "serve a GraphiQL UI for exploring the API at /playground")
+	listenCmd.Flags().BoolVar(&offline, "offline", false, "reject operations that require external network access, and run commands without network access unless their container is bound to services or exposes ports")
+	listenCmd.Flags().StringVar(&plugins, "plugins", "", "comma-separated list of plugin directories to load")
+	listenCmd.Flags().StringVar(&gwRecording, "gateway-recording", "", "save the session's gateway interactions to this path, for replaying in unit tests")
+	listenCmd.Flags().MarkHidden("gateway-recording")
+	listenCmd.Flags().BoolVar(&gwExecOutput, "gateway-recording-exec-output", false, "include the output of execs in the gateway recording")
+	listenCmd.Flags().MarkHidden("gateway-recording-exec-output")
 }
 
 func Listen(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
-	if err := withEngineAndTUI(ctx, engine.Config{}, func(ctx context.Context, r *router.Router) error {
+	if err := withEngineAndTUI(ctx, engine.Config{
+		RateLimits:       rateLimits,
+		Policy:           policyFile,
+		AdmissionPolicy:  admissionFile,
+		Playground:       playground,
+		Offline:          offline,
+		Plugins:          plugins,
+		GatewayRecording: gwRecording,
+
+		GatewayRecordExecOutput: gwExecOutput,
+	}, func(ctx context.Context, r *router.Router) error {
 		rec := progrock.RecorderFromContext(ctx)
 
 		var stderr io.Writer
@@ -65,6 +97,9 @@ func Listen(cmd *cobra.Command, args []string) {
 		}()
 
 		fmt.Fprintf(stderr, "==> server listening on http://%s/query\n", listenAddress)
+		if playground {
+			fmt.Fprintf(stderr, "==> playground available at http://%s/playground\n", listenAddress)
+		}
 
 		return srv.Serve(sessionL)
 	}); err != nil {
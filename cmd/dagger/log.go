@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/dagger/dagger/logging"
 	"github.com/rs/zerolog"
 )
 
@@ -21,3 +22,12 @@ func Logger(dest io.Writer) zerolog.Logger {
 
 	return logger
 }
+
+// logConfigFromEnv configures the engine session's structured logger.
+func logConfigFromEnv() logging.Config {
+	return logging.Config{
+		Level:  os.Getenv("_EXPERIMENTAL_DAGGER_LOG_LEVEL"),
+		Format: os.Getenv("_EXPERIMENTAL_DAGGER_LOG_FORMAT"),
+		Output: os.Getenv("_EXPERIMENTAL_DAGGER_LOG_OUTPUT"),
+	}
+}
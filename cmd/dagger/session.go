@@ -54,12 +54,18 @@ func EngineSession(cmd *cobra.Command, args []string) error {
 	}
 
 	startOpts := engine.Config{
-		Workdir:        workdir,
-		RunnerHost:     internalengine.RunnerHost(),
-		ProgrockWriter: console.NewWriter(os.Stderr),
-		SessionToken:   sessionToken.String(),
-		JournalFile:    os.Getenv("_EXPERIMENTAL_DAGGER_JOURNAL"),
-		UserAgent:      labels.AppendCILabel().AppendAnonymousGitLabels(workdir).String(),
+		Workdir:         workdir,
+		RunnerHost:      internalengine.RunnerHost(),
+		ProgrockWriter:  console.NewWriter(os.Stderr),
+		SessionToken:    sessionToken.String(),
+		JournalFile:     os.Getenv("_EXPERIMENTAL_DAGGER_JOURNAL"),
+		AuditLog:        os.Getenv("_EXPERIMENTAL_DAGGER_AUDIT_LOG"),
+		Webhooks:        os.Getenv("_EXPERIMENTAL_DAGGER_WEBHOOKS"),
+		Log:             logConfigFromEnv(),
+		AdmissionPolicy: os.Getenv("_EXPERIMENTAL_DAGGER_ADMISSION_POLICY"),
+		Offline:         os.Getenv("_EXPERIMENTAL_DAGGER_OFFLINE") != "",
+		Plugins:         os.Getenv("_EXPERIMENTAL_DAGGER_PLUGINS"),
+		UserAgent:       labels.AppendCILabel().AppendAnonymousGitLabels(workdir).String(),
 	}
 
 	signalCh := make(chan os.Signal, 1)
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/schema"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/router"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var terminalCmd = &cobra.Command{
+	Use:   "terminal <container-id> [command...]",
+	Short: "Open an interactive terminal in a container",
+	Long: `Open an interactive terminal in a container, identified by its ID.
+
+This is useful to debug a pipeline: get the ID of the container right before
+a failing exec, and explore its filesystem and environment interactively.
+
+The command runs in the container the same way withExec would run it, with
+the container's mounts, secrets, sockets and services, and is subject to the
+same admission policy. Engines serving an access policy (see 'dagger listen
+--policy') only open terminals for the session owner.`,
+	Example: `dagger terminal "$(cat ctr.id)"
+dagger terminal "$(cat ctr.id)" bash`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: Terminal,
+}
+
+func Terminal(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	treq := schema.TerminalRequest{
+		Container: core.ContainerID(args[0]),
+		Args:      args[1:],
+	}
+
+	stdinFd := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdinFd) {
+		return fmt.Errorf("stdin is not a terminal")
+	}
+
+	if cols, rows, err := term.GetSize(stdinFd); err == nil {
+		treq.Rows = uint32(rows)
+		treq.Cols = uint32(cols)
+	}
+
+	// the session is only served over an in-process connection, so that
+	// other local processes can't use it while the terminal is open
+	return engine.Start(ctx, configFromEnv(engine.Config{}), func(ctx context.Context, r *router.Router) error {
+		clientConn, serverConn := net.Pipe()
+		defer clientConn.Close()
+
+		l := newConnListener(serverConn)
+		defer l.Close()
+
+		srv := &http.Server{Handler: r, ReadHeaderTimeout: 30 * time.Second}
+		go srv.Serve(l)
+
+		return openTerminal(ctx, clientConn, treq, stdinFd)
+	})
+}
+
+func openTerminal(ctx context.Context, conn net.Conn, treq schema.TerminalRequest, stdinFd int) error {
+	body, err := json.Marshal(treq)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://dagger/terminal", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	connR := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(connR, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("open terminal: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	oldState, err := term.MakeRaw(stdinFd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(stdinFd, oldState)
+
+	go io.Copy(conn, os.Stdin)
+
+	_, err = io.Copy(os.Stdout, connR)
+	return err
+}
+
+// connListener is a net.Listener which accepts a single connection.
+type connListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+	addr   net.Addr
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	conns := make(chan net.Conn, 1)
+	conns <- conn
+	return &connListener{
+		conns:  conns,
+		closed: make(chan struct{}),
+		addr:   conn.LocalAddr(),
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
@@ -15,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -32,10 +33,19 @@ const (
 	metaMountPath = "/.dagger_meta_mount"
 	stdinPath     = metaMountPath + "/stdin"
 	exitCodePath  = metaMountPath + "/exitCode"
+	statsPath     = metaMountPath + "/stats"
 	runcPath      = "/usr/local/bin/runc"
 	shimPath      = "/_shim"
 )
 
+// stopGracePeriod is how long the command has to exit after being sent a
+// termination signal before it's killed.
+const stopGracePeriod = 10 * time.Second
+
+// timeoutExitCode is reported when an exec is killed for exceeding its
+// timeout, matching timeout(1).
+const timeoutExitCode = 124
+
 var (
 	stdoutPath = metaMountPath + "/stdout"
 	stderrPath = metaMountPath + "/stderr"
@@ -324,8 +334,52 @@ func shim() int {
 		}()
 	}
 
+	// run the command in its own process group so that signals reach any
+	// children it spawned too
+	setpgid(cmd)
+	group := newProcessGroup()
+
+	// as pid 1, the shim doesn't get the default signal handlers, so forward
+	// termination signals to the command and give it a chance to shut down
+	// cleanly before killing it
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sigCh)
+	go func() {
+		var killTimer *time.Timer
+		for sig := range sigCh {
+			group.signal(sig.(syscall.Signal))
+			if killTimer == nil {
+				killTimer = time.AfterFunc(stopGracePeriod, func() {
+					group.signal(syscall.SIGKILL)
+				})
+			}
+		}
+	}()
+
+	_, allowFailure := internalEnv("_DAGGER_ALLOW_FAILURE")
+
+	var timeout time.Duration
+	var timedOut atomic.Bool
+	if timeoutVar, found := internalEnv("_DAGGER_EXEC_TIMEOUT"); found {
+		seconds, err := strconv.Atoi(timeoutVar)
+		if err != nil {
+			panic(fmt.Errorf("invalid exec timeout: %w", err))
+		}
+		timeout = time.Duration(seconds) * time.Second
+
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			group.signal(syscall.SIGKILL)
+		})
+		defer timer.Stop()
+	}
+
+	oomKillsBefore := oomKillCount()
+	startedAt := time.Now()
+
 	exitCode := 0
-	if err := runWithNesting(ctx, cmd); err != nil {
+	if err := runWithNesting(ctx, cmd, group); err != nil {
 		exitCode = 1
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			exitCode = exiterr.ExitCode()
@@ -334,13 +388,104 @@ func shim() int {
 		}
 	}
 
+	stats := execStats(cmd, time.Since(startedAt))
+	stats.TimedOut = timedOut.Load()
+
+	// the OOM killer may have picked a child of the command rather than the
+	// command itself, so check the cgroup regardless of how it exited
+	stats.OOMKilled = oomKillCount() > oomKillsBefore
+	if stats.OOMKilled {
+		fmt.Fprintln(errWriter, "process killed: out of memory")
+	}
+
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		// report the exit code the way shells do, rather than -1
+		exitCode = 128 + int(status.Signal())
+		stats.Signal = unix.SignalName(status.Signal())
+
+		if !stats.OOMKilled && !stats.TimedOut {
+			fmt.Fprintf(errWriter, "process killed by %s\n", stats.Signal)
+		}
+	}
+
+	if stats.TimedOut {
+		exitCode = timeoutExitCode
+		fmt.Fprintf(errWriter, "exec timed out after %s\n", timeout)
+	}
+
+	if err := writeStats(stats); err != nil {
+		panic(err)
+	}
+
 	if err := os.WriteFile(exitCodePath, []byte(fmt.Sprintf("%d", exitCode)), 0o600); err != nil {
 		panic(err)
 	}
 
+	if allowFailure {
+		// the exit code is recorded in the meta mount; don't fail the solve
+		return 0
+	}
+
 	return exitCode
 }
 
+// execStats returns the duration and resource usage of the command.
+func execStats(cmd *exec.Cmd, duration time.Duration) core.ExecStats {
+	stats := core.ExecStats{
+		Duration: duration.Seconds(),
+	}
+
+	if cmd.ProcessState != nil {
+		stats.UserTime = cmd.ProcessState.UserTime().Seconds()
+		stats.SystemTime = cmd.ProcessState.SystemTime().Seconds()
+		if rusage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+			// reported in KiB on Linux
+			stats.MaxRSS = rusage.Maxrss * 1024
+		}
+	}
+
+	return stats
+}
+
+// writeStats records the exec stats in the meta mount.
+func writeStats(stats core.ExecStats) error {
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(statsPath, statsJSON, 0o600)
+}
+
+// oomKillCount returns the number of processes killed by the OOM killer in
+// the container's cgroup, supporting both cgroup v2 and v1.
+func oomKillCount() int {
+	for _, eventsPath := range []string{
+		"/sys/fs/cgroup/memory.events",
+		"/sys/fs/cgroup/memory/memory.oom_control",
+	} {
+		events, err := os.ReadFile(eventsPath)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(events), "\n") {
+			count, found := strings.CutPrefix(line, "oom_kill ")
+			if !found {
+				continue
+			}
+
+			n, err := strconv.Atoi(strings.TrimSpace(count))
+			if err != nil {
+				return 0
+			}
+			return n
+		}
+	}
+
+	return 0
+}
+
 func setupBundle() int {
 	// Figure out the path to the bundle dir, in which we can obtain the
 	// oci runtime config.json
@@ -435,6 +580,31 @@ func setupBundle() int {
 				Options:     []string{"rbind"},
 				Source:      "/run/buildkit/buildkitd.sock",
 			})
+		case strings.HasPrefix(env, gpusEnv+"="):
+			// NB: don't keep this env var, it's only for the bundling step
+			if err := requestGPUs(&spec, strings.TrimPrefix(env, gpusEnv+"=")); err != nil {
+				fmt.Fprintln(os.Stderr, "gpu:", err)
+				return 1
+			}
+		case strings.HasPrefix(env, cpuLimitEnv+"="),
+			strings.HasPrefix(env, memoryLimitEnv+"="):
+			// NB: don't keep this env var, it's only for the bundling step
+			if err := limitResources(&spec, env); err != nil {
+				fmt.Fprintln(os.Stderr, "resource limits:", err)
+				return 1
+			}
+		case strings.HasPrefix(env, hostnameEnv+"="):
+			// NB: don't keep this env var, it's only for the bundling step
+			//
+			// the exec is registered in DNS under its computed hostname, so
+			// the custom one is only seen by the exec itself
+			spec.Hostname = strings.TrimPrefix(env, hostnameEnv+"=")
+		case strings.HasPrefix(env, dnsEnv+"="):
+			// NB: don't keep this env var, it's only for the bundling step
+			if err := configureDNS(&spec, bundleDir, strings.TrimPrefix(env, dnsEnv+"=")); err != nil {
+				fmt.Fprintln(os.Stderr, "dns:", err)
+				return 1
+			}
 		case strings.HasPrefix(env, aliasPrefix):
 			// NB: don't keep this env var, it's only for the bundling step
 			// keepEnv = append(keepEnv, env)
@@ -510,6 +680,179 @@ func setupBundle() int {
 
 const aliasPrefix = "_DAGGER_HOSTNAME_ALIAS_"
 
+const dnsEnv = "_DAGGER_DNS"
+
+const hostnameEnv = "_DAGGER_HOSTNAME"
+
+const (
+	cpuLimitEnv    = "_DAGGER_CPU_LIMIT"
+	memoryLimitEnv = "_DAGGER_MEMORY_LIMIT"
+
+	// the CFS period used for CPU limits, matching docker's default
+	cpuPeriod = 100000
+)
+
+const (
+	gpusEnv = "_DAGGER_GPUS"
+
+	nvidiaHookName = "nvidia-container-runtime-hook"
+)
+
+// requestGPUs configures the spec to expose GPUs to the container, using the
+// vendor's OCI runtime hook, in the same way as the vendor's runtime wrapper.
+func requestGPUs(spec *specs.Spec, request string) error {
+	vendor, count, ok := strings.Cut(request, "=")
+	if !ok {
+		return fmt.Errorf("malformed GPU request: %s", request)
+	}
+
+	if vendor != "nvidia" {
+		return fmt.Errorf("unsupported GPU vendor %q", vendor)
+	}
+
+	hookPath, err := exec.LookPath(nvidiaHookName)
+	if err != nil {
+		return fmt.Errorf("GPUs requested but %s is not installed on the worker: %w", nvidiaHookName, err)
+	}
+
+	devices := count
+	if count != "all" {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return fmt.Errorf("malformed GPU count: %w", err)
+		}
+
+		indexes := make([]string, n)
+		for i := range indexes {
+			indexes[i] = strconv.Itoa(i)
+		}
+
+		devices = strings.Join(indexes, ",")
+	}
+
+	spec.Process.Env = append(spec.Process.Env, "NVIDIA_VISIBLE_DEVICES="+devices)
+
+	hasCapabilities := false
+	for _, env := range spec.Process.Env {
+		if strings.HasPrefix(env, "NVIDIA_DRIVER_CAPABILITIES=") {
+			hasCapabilities = true
+			break
+		}
+	}
+	if !hasCapabilities {
+		spec.Process.Env = append(spec.Process.Env, "NVIDIA_DRIVER_CAPABILITIES=compute,utility")
+	}
+
+	if spec.Hooks == nil {
+		spec.Hooks = &specs.Hooks{}
+	}
+
+	spec.Hooks.Prestart = append(spec.Hooks.Prestart, specs.Hook{ //nolint:staticcheck // the hook only supports prestart
+		Path: hookPath,
+		Args: []string{nvidiaHookName, "prestart"},
+	})
+
+	return nil
+}
+
+// limitResources sets the cgroup CPU or memory limit of the container.
+func limitResources(spec *specs.Spec, env string) error {
+	name, val, _ := strings.Cut(env, "=")
+
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &specs.LinuxResources{}
+	}
+	resources := spec.Linux.Resources
+
+	switch name {
+	case cpuLimitEnv:
+		cpus, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("malformed CPU limit: %w", err)
+		}
+		quota := int64(cpus * cpuPeriod)
+		period := uint64(cpuPeriod)
+		if resources.CPU == nil {
+			resources.CPU = &specs.LinuxCPU{}
+		}
+		resources.CPU.Quota = &quota
+		resources.CPU.Period = &period
+	case memoryLimitEnv:
+		mib, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("malformed memory limit: %w", err)
+		}
+		limit := mib * 1024 * 1024
+		if resources.Memory == nil {
+			resources.Memory = &specs.LinuxMemory{}
+		}
+		resources.Memory.Limit = &limit
+		// also limit swap, otherwise the limit is only on resident memory
+		resources.Memory.Swap = &limit
+	}
+
+	return nil
+}
+
+// configureDNS points the container's /etc/resolv.conf at a copy of the
+// worker's, with the requested settings replacing the corresponding lines. The
+// copy lives in the bundle dir since the original is shared by all containers.
+func configureDNS(spec *specs.Spec, bundleDir string, config string) error {
+	var dns core.DNSConfig
+	if err := json.Unmarshal([]byte(config), &dns); err != nil {
+		return fmt.Errorf("malformed DNS config: %w", err)
+	}
+
+	var mnt *specs.Mount
+	for i := range spec.Mounts {
+		if spec.Mounts[i].Destination == "/etc/resolv.conf" {
+			mnt = &spec.Mounts[i]
+		}
+	}
+	if mnt == nil {
+		return fmt.Errorf("no /etc/resolv.conf mount")
+	}
+
+	orig, err := os.ReadFile(mnt.Source)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(orig), "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			switch {
+			case fields[0] == "nameserver" && len(dns.Nameservers) > 0,
+				(fields[0] == "search" || fields[0] == "domain") && len(dns.SearchDomains) > 0,
+				fields[0] == "options" && len(dns.Options) > 0:
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	for _, ns := range dns.Nameservers {
+		lines = append(lines, "nameserver "+ns)
+	}
+	if len(dns.SearchDomains) > 0 {
+		lines = append(lines, "search "+strings.Join(dns.SearchDomains, " "))
+	}
+	if len(dns.Options) > 0 {
+		lines = append(lines, "options "+strings.Join(dns.Options, " "))
+	}
+
+	resolvPath := filepath.Join(bundleDir, "resolv.conf")
+	if err := os.WriteFile(resolvPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil { //nolint:gosec
+		return err
+	}
+
+	mnt.Source = resolvPath
+	return nil
+}
+
 func appendHostAlias(hostsFilePath string, env string) error {
 	alias, target, ok := strings.Cut(strings.TrimPrefix(env, aliasPrefix), "=")
 	if !ok {
@@ -546,6 +889,41 @@ func execRunc() int {
 	panic("congratulations: you've reached unreachable code, please report a bug!")
 }
 
+// setpgid configures the command to run in its own process group, keeping
+// any other attributes already set.
+func setpgid(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// processGroup signals the process group of a command started with setpgid.
+type processGroup struct {
+	started chan struct{}
+	pgid    int
+}
+
+func newProcessGroup() *processGroup {
+	return &processGroup{
+		started: make(chan struct{}),
+	}
+}
+
+// start records the command's process group. It must be called once the
+// command has started.
+func (group *processGroup) start(cmd *exec.Cmd) {
+	group.pgid = cmd.Process.Pid
+	close(group.started)
+}
+
+// signal sends sig to every process in the group, waiting for the command to
+// start first so that signals received early aren't lost.
+func (group *processGroup) signal(sig syscall.Signal) {
+	<-group.started
+	_ = syscall.Kill(-group.pgid, sig)
+}
+
 func internalEnv(name string) (string, bool) {
 	val, found := os.LookupEnv(name)
 	if !found {
@@ -557,12 +935,13 @@ func internalEnv(name string) (string, bool) {
 	return val, true
 }
 
-func runWithNesting(ctx context.Context, cmd *exec.Cmd) error {
+func runWithNesting(ctx context.Context, cmd *exec.Cmd, group *processGroup) error {
 	if _, found := internalEnv("_DAGGER_ENABLE_NESTING"); !found {
 		// no nesting; run as normal
 		if err := cmd.Start(); err != nil {
 			return err
 		}
+		group.start(cmd)
 
 		// Wait for stdout and stderr copy goroutines to finish:
 		pipeWg.Wait()
@@ -610,6 +989,7 @@ func runWithNesting(ctx context.Context, cmd *exec.Cmd) error {
 		if cmdErr != nil {
 			return cmdErr
 		}
+		group.start(cmd)
 		pipeWg.Wait()
 		cmdErr = cmd.Wait()
 		if cmdErr != nil {
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessGroupSignal(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", "sleep 600 & echo $!; wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	setpgid(cmd)
+
+	// existing attributes are kept
+	require.True(t, cmd.SysProcAttr.Setpgid)
+	require.Equal(t, syscall.SIGKILL, cmd.SysProcAttr.Pdeathsig)
+
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+
+	group := newProcessGroup()
+
+	// signals sent before the command starts are delivered once it has
+	signaled := make(chan struct{})
+	go func() {
+		group.signal(syscall.SIGCONT)
+		close(signaled)
+	}()
+
+	require.NoError(t, cmd.Start())
+	group.start(cmd)
+
+	select {
+	case <-signaled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("signal was not delivered after start")
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	child, err := strconv.Atoi(strings.TrimSpace(line))
+	require.NoError(t, err)
+
+	group.signal(syscall.SIGKILL)
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, syscall.SIGKILL, exitErr.Sys().(syscall.WaitStatus).Signal())
+
+	// the background child was killed too, not just the shell
+	require.Eventually(t, func() bool {
+		return !processRunning(child)
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+// processRunning returns whether pid exists and hasn't exited. Orphans may
+// not be reaped right away, so zombies count as exited.
+func processRunning(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	// the state follows the parenthesized command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
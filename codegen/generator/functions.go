@@ -9,9 +9,8 @@ const (
 	QueryStructClientName = "Client"
 )
 
-// CustomScalar registers custom Dagger type.
-// TODO: This may done it dynamically later instead of a static
-// map.
+// CustomScalar maps each ID scalar to the type it identifies. It is extended
+// by SetSchema with the IDs described by the engine.
 var CustomScalar = map[string]string{
 	"ContainerID":      "Container",
 	"FileID":           "File",
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -24,8 +25,15 @@ type Config struct {
 	// Package is the target package that is generated.
 	// Not used for the SDKLangNodeJS.
 	Package string
+	// QueryBuilder is the import path of the querybuilder package used by
+	// the generated code, which defaults to the Go SDK's.
+	// Only used for the SDKLangGo.
+	QueryBuilder string
 }
 
+// DefaultQueryBuilder is the import path of the Go SDK's querybuilder.
+const DefaultQueryBuilder = "dagger.io/dagger/internal/querybuilder"
+
 type Generator interface {
 	Generate(ctx context.Context, schema *introspection.Schema) ([]byte, error)
 }
@@ -42,10 +50,22 @@ func SetSchemaParents(schema *introspection.Schema) {
 // Introspect get the Dagger Schema with the router r.
 func Introspect(ctx context.Context, r *router.Router) (*introspection.Schema, error) {
 	var response introspection.Response
-	_, err := r.Do(ctx, introspection.Query, "", nil, &response)
+	result, err := r.Do(ctx, introspection.Query, "", nil, &response)
 	if err != nil {
 		return nil, fmt.Errorf("error querying the API: %w", err)
 	}
+
+	if hints, found := result.Extensions[router.ScalarHintsExtension]; found {
+		payload, err := json.Marshal(hints)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(payload, &response.Schema.ScalarHints); err != nil {
+			return nil, fmt.Errorf("error decoding scalar hints: %w", err)
+		}
+	}
+
 	return response.Schema, nil
 }
 
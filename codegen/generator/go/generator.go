@@ -19,12 +19,19 @@ type GoGenerator struct {
 func (g *GoGenerator) Generate(_ context.Context, schema *introspection.Schema) ([]byte, error) {
 	generator.SetSchema(schema)
 
+	queryBuilder := g.Config.QueryBuilder
+	if queryBuilder == "" {
+		queryBuilder = generator.DefaultQueryBuilder
+	}
+
 	headerData := struct {
-		Package string
-		Schema  *introspection.Schema
+		Package      string
+		QueryBuilder string
+		Schema       *introspection.Schema
 	}{
-		Package: g.Config.Package,
-		Schema:  schema,
+		Package:      g.Config.Package,
+		QueryBuilder: queryBuilder,
+		Schema:       schema,
 	}
 	var header bytes.Buffer
 	if err := templates.Header.Execute(&header, headerData); err != nil {
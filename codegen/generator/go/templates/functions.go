@@ -2,6 +2,7 @@ package templates
 
 import (
 	"fmt"
+	"go/token"
 	"regexp"
 	"sort"
 	"strings"
@@ -34,6 +35,7 @@ var (
 		"FormatArrayField":        formatArrayField,
 		"FormatArrayToSingleType": formatArrayToSingleType,
 		"ConvertID":               commonFunc.ConvertID,
+		"FormatFieldName":         formatFieldName,
 	}
 )
 
@@ -81,6 +83,16 @@ func formatName(s string) string {
 	return lintName(s)
 }
 
+// formatFieldName formats a GraphQL field name into the unexported struct
+// field that caches its value, escaping Go keywords.
+// Example: `type` -> `type_`
+func formatFieldName(s string) string {
+	if token.IsKeyword(s) {
+		return s + "_"
+	}
+	return s
+}
+
 // formatName formats a GraphQL Enum value into a Go equivalent
 // Example: `fooId` -> `FooID`
 func formatEnum(s string) string {
@@ -134,7 +146,7 @@ func formatArrayField(fields []*introspection.Field) string {
 	result := []string{}
 
 	for _, f := range fields {
-		result = append(result, fmt.Sprintf("%s: &fields[i].%s", f.Name, toUpperCase(f.Name)))
+		result = append(result, fmt.Sprintf("%s: &fields[i].%s", formatFieldName(f.Name), toUpperCase(f.Name)))
 	}
 
 	return strings.Join(result, ", ")
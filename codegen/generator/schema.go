@@ -6,6 +6,12 @@ var _schema *introspection.Schema
 
 func SetSchema(schema *introspection.Schema) {
 	_schema = schema
+
+	// prefer the engine's description of its IDs, falling back to the
+	// built-in ones for engines which don't provide it
+	for scalar, object := range schema.IDs() {
+		CustomScalar[scalar] = object
+	}
 }
 
 func GetSchema() *introspection.Schema {
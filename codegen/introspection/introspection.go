@@ -26,6 +26,30 @@ type Schema struct {
 	} `json:"subscriptionType"`
 
 	Types Types `json:"types"`
+
+	// ScalarHints describes the custom scalars, keyed by name. They are
+	// provided by the engine as an extension to the introspection response.
+	ScalarHints map[string]ScalarHint `json:"-"`
+}
+
+// ScalarHint describes how a custom scalar is serialized.
+type ScalarHint struct {
+	// Either "string" or "id".
+	Kind string `json:"kind"`
+
+	// Name of the object type that an ID identifies.
+	Object string `json:"object,omitempty"`
+}
+
+// IDs returns the object type identified by each ID scalar.
+func (s *Schema) IDs() map[string]string {
+	ids := map[string]string{}
+	for name, hint := range s.ScalarHints {
+		if hint.Kind == "id" && hint.Object != "" {
+			ids[name] = hint.Object
+		}
+	}
+	return ids
 }
 
 func (s *Schema) Query() *Type {
@@ -0,0 +1,489 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/content"
+	"github.com/klauspost/compress/zstd"
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+)
+
+// ArchiveFormat is the format of an archive created from a Directory.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar ArchiveFormat = "TAR"
+	ArchiveFormatZip ArchiveFormat = "ZIP"
+)
+
+// ArchiveCompression is the compression applied to an archive created from a
+// Directory.
+type ArchiveCompression string
+
+const (
+	ArchiveCompressionGzip    ArchiveCompression = "GZIP_COMPRESSION"
+	ArchiveCompressionZstd    ArchiveCompression = "ZSTD_COMPRESSION"
+	ArchiveCompressionDeflate ArchiveCompression = "DEFLATE_COMPRESSION"
+	ArchiveCompressionNone    ArchiveCompression = "NO_COMPRESSION"
+)
+
+// Archive returns the directory's contents as an archive File. Tarballs
+// default to gzip compression, and zip archives to deflate.
+//
+// The archive is written to the OCI store as it is read from the directory,
+// and loaded back into Buildkit from there.
+func (dir *Directory) Archive(
+	ctx context.Context,
+	gw bkgw.Client,
+	store content.Store,
+	format ArchiveFormat,
+	compression ArchiveCompression,
+) (*File, error) {
+	if format == "" {
+		format = ArchiveFormatTar
+	}
+	if compression == "" {
+		compression = ArchiveCompressionGzip
+		if format == ArchiveFormatZip {
+			compression = ArchiveCompressionDeflate
+		}
+	}
+
+	name, err := archiveName(format, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithServices(ctx, gw, dir.Services, func() (*File, error) {
+		contents, err := dir.tarball(ctx, gw)
+		if err != nil {
+			return nil, err
+		}
+		defer contents.Close()
+
+		return storeFile(ctx, store, name, func(w io.Writer) error {
+			return writeArchive(w, contents, format, compression)
+		}, dir.Pipeline, dir.Platform)
+	})
+}
+
+// tarball returns a tar archive of the directory's contents.
+func (dir *Directory) tarball(ctx context.Context, gw bkgw.Client) (io.ReadCloser, error) {
+	if dir.LLB == nil {
+		// scratch: nothing to solve
+		buf := new(bytes.Buffer)
+		if err := tar.NewWriter(buf).Close(); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(buf), nil
+	}
+
+	ref, err := gwRef(ctx, gw, dir.LLB)
+	if err != nil {
+		return nil, err
+	}
+
+	root := dir.Dir
+	if root == "" {
+		root = "/"
+	}
+
+	return archiveRef(ctx, ref, root, ""), nil
+}
+
+// archiveName returns the name of an archive in the given format and
+// compression, or an error if they're unknown or can't be combined.
+func archiveName(format ArchiveFormat, compression ArchiveCompression) (string, error) {
+	switch compression {
+	case ArchiveCompressionGzip,
+		ArchiveCompressionZstd,
+		ArchiveCompressionDeflate,
+		ArchiveCompressionNone:
+	default:
+		return "", fmt.Errorf("unknown archive compression %q", compression)
+	}
+
+	switch format {
+	case ArchiveFormatTar:
+		switch compression {
+		case ArchiveCompressionGzip:
+			return "archive.tar.gz", nil
+		case ArchiveCompressionZstd:
+			return "archive.tar.zst", nil
+		case ArchiveCompressionNone:
+			return "archive.tar", nil
+		}
+	case ArchiveFormatZip:
+		switch compression {
+		case ArchiveCompressionDeflate, ArchiveCompressionNone:
+			return "archive.zip", nil
+		}
+	default:
+		return "", fmt.Errorf("unknown archive format %q", format)
+	}
+
+	return "", fmt.Errorf("%s archives do not support %s", format, compression)
+}
+
+// writeArchive writes the tar stream read from r to w in the given format and
+// compression.
+func writeArchive(w io.Writer, r io.Reader, format ArchiveFormat, compression ArchiveCompression) error {
+	if format == ArchiveFormatZip {
+		method := zip.Deflate
+		if compression == ArchiveCompressionNone {
+			method = zip.Store
+		}
+		return tarToZip(r, w, method)
+	}
+
+	var cw io.WriteCloser
+	switch compression {
+	case ArchiveCompressionGzip:
+		cw = gzip.NewWriter(w)
+	case ArchiveCompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		cw = zw
+	default:
+		_, err := io.Copy(w, r)
+		return err
+	}
+
+	if _, err := io.Copy(cw, r); err != nil {
+		cw.Close()
+		return err
+	}
+
+	return cw.Close()
+}
+
+// tarToZip converts the tar stream read from r to a zip archive. Zip can't
+// represent hardlinks, so they're written as copies of their targets, whose
+// contents are spooled to a temporary file as they're read.
+func tarToZip(r io.Reader, out io.Writer, method uint16) error {
+	tr := tar.NewReader(r)
+	zw := zip.NewWriter(out)
+
+	spool, err := os.CreateTemp("", "dagger-zip-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	// offsets and sizes of regular files' contents in the spool
+	spooled := map[string]*io.SectionReader{}
+	var spoolSize int64
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if name == "" || name == "." {
+			continue
+		}
+
+		fh, err := zip.FileInfoHeader(hdr.FileInfo())
+		if err != nil {
+			return err
+		}
+		fh.Name = name
+		fh.Modified = hdr.ModTime
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fh.Name = strings.TrimSuffix(name, "/") + "/"
+			fh.Method = zip.Store
+			if _, err := zw.CreateHeader(fh); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// by convention, zip stores the link target as the content
+			fh.Method = zip.Store
+			w, err := zw.CreateHeader(fh)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, hdr.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			fh.Method = method
+			w, err := zw.CreateHeader(fh)
+			if err != nil {
+				return err
+			}
+			n, err := io.Copy(w, io.TeeReader(tr, spool)) //nolint:gosec
+			if err != nil {
+				return err
+			}
+			spooled[name] = io.NewSectionReader(spool, spoolSize, n)
+			spoolSize += n
+		case tar.TypeLink:
+			target, ok := spooled[strings.TrimPrefix(hdr.Linkname, "./")]
+			if !ok {
+				return fmt.Errorf("%s: hardlink to %s, which is not in the archive", name, hdr.Linkname)
+			}
+			fh.Method = method
+			w, err := zw.CreateHeader(fh)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(target, 0, target.Size())); err != nil {
+				return err
+			}
+			spooled[name] = target
+		default:
+			// zip can't represent devices, fifos, etc.
+			continue
+		}
+	}
+
+	return zw.Close()
+}
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+
+	// magic numbers of the compression formats buildkit can unpack
+	compressedTarMagics = [][]byte{
+		{0x1f, 0x8b},                     // gzip
+		[]byte("BZh"),                    // bzip2
+		{0xfd, '7', 'z', 'X', 'Z', 0x00}, // xz
+		{0x28, 0xb5, 0x2f, 0xfd},         // zstd
+	}
+)
+
+// tarMagicOffset is where the "ustar" magic is in a tar header.
+const tarMagicOffset = 257
+
+// Unpack extracts the file, which must be a tar or zip archive, into a
+// Directory. Tarballs may be compressed with gzip, bzip2, xz or zstd.
+func (file *File) Unpack(ctx context.Context, gw bkgw.Client, host *Host) (*Directory, error) {
+	header, err := file.readHeader(ctx, gw, tarMagicOffset+len("ustar"))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		return file.unpackZip(ctx, gw, host)
+	case isTarball(header):
+		return file.unpackTarball(ctx)
+	default:
+		return nil, fmt.Errorf("%s: not a tar or zip archive", file.File)
+	}
+}
+
+func isTarball(header []byte) bool {
+	if len(header) >= tarMagicOffset+len("ustar") &&
+		string(header[tarMagicOffset:tarMagicOffset+len("ustar")]) == "ustar" {
+		return true
+	}
+
+	for _, magic := range compressedTarMagics {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (file *File) readHeader(ctx context.Context, gw bkgw.Client, size int) ([]byte, error) {
+	r, err := file.Open(ctx, nil, gw)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	header := make([]byte, size)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return header[:n], nil
+}
+
+// unpackTarball lets buildkit unpack the tarball as part of a copy.
+func (file *File) unpackTarball(ctx context.Context) (*Directory, error) {
+	src, err := file.State()
+	if err != nil {
+		return nil, err
+	}
+
+	st := llb.Scratch().File(llb.Copy(src, file.File, "/", &llb.CopyInfo{
+		AttemptUnpack: true,
+	}))
+
+	return NewDirectorySt(ctx, st, "", file.Pipeline, file.Platform, file.Services)
+}
+
+// unpackZip extracts the zip archive to a temporary directory and syncs it
+// back into the session, since buildkit can't unpack zip archives.
+func (file *File) unpackZip(ctx context.Context, gw bkgw.Client, host *Host) (*Directory, error) {
+	tmpDir, err := os.MkdirTemp("", "dagger-unpack")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, "archive.zip")
+	if err := file.copyTo(ctx, gw, archivePath); err != nil {
+		return nil, err
+	}
+
+	contentsPath := filepath.Join(tmpDir, "contents")
+	if err := extractZip(archivePath, contentsPath); err != nil {
+		return nil, fmt.Errorf("unpack %s: %w", file.File, err)
+	}
+
+	// NB: the host directory is synced eagerly, so the temporary directory can
+	// be removed once this returns
+	return host.Directory(ctx, gw, contentsPath, file.Pipeline, "file.unpack", file.Platform, CopyFilter{})
+}
+
+func (file *File) copyTo(ctx context.Context, gw bkgw.Client, dest string) error {
+	r, err := file.Open(ctx, nil, gw)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+func extractZip(archivePath, dest string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		// clean the name as if it were absolute, so that entries can't escape
+		// the destination
+		target := filepath.Join(dest, filepath.FromSlash(path.Clean("/"+f.Name)))
+		if target == dest {
+			continue
+		}
+
+		if err := checkNoSymlinks(dest, target); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		if err := extractZipEntry(f, target); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkNoSymlinks returns an error if target or any of its parents below dest
+// is a symlink. Archives may contain symlinks pointing anywhere, so following
+// one created by an earlier entry could write outside dest.
+func checkNoSymlinks(dest, target string) error {
+	rel, err := filepath.Rel(dest, target)
+	if err != nil {
+		return err
+	}
+
+	cur := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+
+		info, err := os.Lstat(cur)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("path traverses symlink %s", filepath.ToSlash(rel))
+		}
+	}
+
+	return nil
+}
+
+func extractZipEntry(f *zip.File, target string) error {
+	mode := f.Mode()
+
+	if mode.IsDir() {
+		return os.MkdirAll(target, mode.Perm()|0o700)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		// by convention, zip stores the link target as the content
+		linkname, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(string(linkname), target)
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil { //nolint:gosec
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, f.Modified, f.Modified)
+}
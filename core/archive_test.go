@@ -0,0 +1,345 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/dagger/dagger/core/gatewaytest"
+	"github.com/klauspost/compress/zstd"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveName(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		format      ArchiveFormat
+		compression ArchiveCompression
+		name        string
+		err         string
+	}{
+		{ArchiveFormatTar, ArchiveCompressionGzip, "archive.tar.gz", ""},
+		{ArchiveFormatTar, ArchiveCompressionZstd, "archive.tar.zst", ""},
+		{ArchiveFormatTar, ArchiveCompressionNone, "archive.tar", ""},
+		{ArchiveFormatTar, ArchiveCompressionDeflate, "", "TAR archives do not support DEFLATE_COMPRESSION"},
+		{ArchiveFormatZip, ArchiveCompressionDeflate, "archive.zip", ""},
+		{ArchiveFormatZip, ArchiveCompressionNone, "archive.zip", ""},
+		{ArchiveFormatZip, ArchiveCompressionGzip, "", "ZIP archives do not support GZIP_COMPRESSION"},
+		{ArchiveFormatZip, ArchiveCompressionZstd, "", "ZIP archives do not support ZSTD_COMPRESSION"},
+		{"RAR", ArchiveCompressionNone, "", `unknown archive format "RAR"`},
+		{ArchiveFormatTar, "Bzip2", "", `unknown archive compression "Bzip2"`},
+		{ArchiveFormatZip, "Bzip2", "", `unknown archive compression "Bzip2"`},
+		{"RAR", "Bzip2", "", `unknown archive compression "Bzip2"`},
+	} {
+		name, err := archiveName(tc.format, tc.compression)
+		if tc.err != "" {
+			require.EqualError(t, err, tc.err, "%s/%s", tc.format, tc.compression)
+			continue
+		}
+
+		require.NoError(t, err, "%s/%s", tc.format, tc.compression)
+		require.Equal(t, tc.name, name)
+	}
+}
+
+func TestDirectoryArchive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(nil)
+	gw.Solver = gatewaytest.WithFiles(fstest.MapFS{
+		"src/foo":     {Data: []byte("foo")},
+		"src/sub/bar": {Data: []byte("bar")},
+	})
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	dir, err := NewDirectorySt(ctx, llb.Image("alpine"), "/src", nil, specs.Platform{OS: "linux", Architecture: "amd64"}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		format      ArchiveFormat
+		compression ArchiveCompression
+		file        string
+		read        func(*testing.T, []byte) map[string]string
+	}{
+		{"", "", "/archive.tar.gz", func(t *testing.T, b []byte) map[string]string {
+			zr, err := gzip.NewReader(bytes.NewReader(b))
+			require.NoError(t, err)
+			return readTar(t, zr)
+		}},
+		{ArchiveFormatTar, ArchiveCompressionZstd, "/archive.tar.zst", func(t *testing.T, b []byte) map[string]string {
+			zr, err := zstd.NewReader(bytes.NewReader(b))
+			require.NoError(t, err)
+			defer zr.Close()
+			return readTar(t, zr)
+		}},
+		{ArchiveFormatTar, ArchiveCompressionNone, "/archive.tar", func(t *testing.T, b []byte) map[string]string {
+			return readTar(t, bytes.NewReader(b))
+		}},
+		{ArchiveFormatZip, "", "/archive.zip", func(t *testing.T, b []byte) map[string]string {
+			return readZip(t, b, zip.Deflate)
+		}},
+		{ArchiveFormatZip, ArchiveCompressionNone, "/archive.zip", func(t *testing.T, b []byte) map[string]string {
+			return readZip(t, b, zip.Store)
+		}},
+	} {
+		file, err := dir.Archive(ctx, gw, store, tc.format, tc.compression)
+		require.NoError(t, err)
+		require.Equal(t, tc.file, file.File)
+
+		entries := tc.read(t, storedFile(ctx, t, store, file))
+		require.Equal(t, map[string]string{
+			"foo":     "foo",
+			"sub/":    "",
+			"sub/bar": "bar",
+		}, entries, "%s/%s", tc.format, tc.compression)
+	}
+
+	_, err = dir.Archive(ctx, gw, store, ArchiveFormatZip, ArchiveCompressionGzip)
+	require.EqualError(t, err, "ZIP archives do not support GZIP_COMPRESSION")
+}
+
+func TestDirectoryArchiveScratch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(nil)
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	dir := NewDirectory(ctx, nil, "", nil, specs.Platform{OS: "linux", Architecture: "amd64"}, nil)
+
+	file, err := dir.Archive(ctx, gw, store, ArchiveFormatTar, ArchiveCompressionNone)
+	require.NoError(t, err)
+	require.Empty(t, readTar(t, bytes.NewReader(storedFile(ctx, t, store, file))))
+
+	// nothing to solve
+	require.Empty(t, gw.Solves())
+}
+
+// storedFile returns the contents of a file loaded from the OCI store.
+func storedFile(ctx context.Context, t *testing.T, store content.Store, file *File) []byte {
+	t.Helper()
+
+	ops, err := gatewaytest.Ops(file.LLB)
+	require.NoError(t, err)
+
+	var ref string
+	for _, op := range ops {
+		if src := op.GetSource(); src != nil {
+			ref = src.Identifier
+		}
+	}
+	require.True(t, strings.HasPrefix(ref, "oci-layout://"), ref)
+
+	manifestBlob, err := content.ReadBlob(ctx, store, specs.Descriptor{
+		Digest: digest.Digest(ref[strings.LastIndex(ref, "@")+1:]),
+	})
+	require.NoError(t, err)
+
+	var manifest specs.Manifest
+	require.NoError(t, json.Unmarshal(manifestBlob, &manifest))
+	require.Len(t, manifest.Layers, 1)
+
+	layer, err := content.ReadBlob(ctx, store, manifest.Layers[0])
+	require.NoError(t, err)
+
+	tr := tar.NewReader(bytes.NewReader(layer))
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, strings.TrimPrefix(file.File, "/"), hdr.Name)
+
+	contents, err := io.ReadAll(tr)
+	require.NoError(t, err)
+
+	return contents
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	entries := map[string]string{}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		contents, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[hdr.Name] = string(contents)
+	}
+
+	return entries
+}
+
+func readZip(t *testing.T, b []byte, method uint16) map[string]string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			require.Equal(t, method, f.Method, f.Name)
+		}
+
+		r, err := f.Open()
+		require.NoError(t, err)
+
+		contents, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+
+		entries[f.Name] = string(contents)
+	}
+
+	return entries
+}
+
+func TestTarToZipHardlinks(t *testing.T) {
+	t.Parallel()
+
+	writeTar := func(t *testing.T, hdrs ...*tar.Header) []byte {
+		buf := new(bytes.Buffer)
+		tw := tar.NewWriter(buf)
+		for _, hdr := range hdrs {
+			require.NoError(t, tw.WriteHeader(hdr))
+			if hdr.Typeflag == tar.TypeReg {
+				_, err := tw.Write([]byte(hdr.Name))
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, tw.Close())
+		return buf.Bytes()
+	}
+
+	tarball := writeTar(t,
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./foo", Mode: 0o644, Size: int64(len("./foo"))},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./bar", Mode: 0o644, Size: int64(len("./bar"))},
+		&tar.Header{Typeflag: tar.TypeLink, Name: "./foo-link", Linkname: "./foo", Mode: 0o644},
+		&tar.Header{Typeflag: tar.TypeLink, Name: "./foo-link-link", Linkname: "foo-link", Mode: 0o644},
+	)
+
+	for _, method := range []uint16{zip.Deflate, zip.Store} {
+		out := new(bytes.Buffer)
+		require.NoError(t, tarToZip(bytes.NewReader(tarball), out, method))
+		require.Equal(t, map[string]string{
+			"foo":           "./foo",
+			"bar":           "./bar",
+			"foo-link":      "./foo",
+			"foo-link-link": "./foo",
+		}, readZip(t, out.Bytes(), method))
+	}
+
+	tarball = writeTar(t,
+		&tar.Header{Typeflag: tar.TypeLink, Name: "./link", Linkname: "./missing", Mode: 0o644},
+	)
+	err := tarToZip(bytes.NewReader(tarball), io.Discard, zip.Deflate)
+	require.ErrorContains(t, err, "not in the archive")
+}
+
+func TestExtractZipSymlinks(t *testing.T) {
+	t.Parallel()
+
+	writeZip := func(t *testing.T, entries ...func(*zip.Writer)) string {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		out, err := os.Create(archivePath)
+		require.NoError(t, err)
+		defer out.Close()
+
+		zw := zip.NewWriter(out)
+		for _, entry := range entries {
+			entry(zw)
+		}
+		require.NoError(t, zw.Close())
+		return archivePath
+	}
+
+	symlink := func(name, target string) func(*zip.Writer) {
+		return func(zw *zip.Writer) {
+			hdr := &zip.FileHeader{Name: name}
+			hdr.SetMode(os.ModeSymlink | 0o777)
+			w, err := zw.CreateHeader(hdr)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(target))
+			require.NoError(t, err)
+		}
+	}
+
+	file := func(name, contents string) func(*zip.Writer) {
+		return func(zw *zip.Writer) {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(contents))
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("links are kept", func(t *testing.T) {
+		t.Parallel()
+
+		dest := filepath.Join(t.TempDir(), "contents")
+		archivePath := writeZip(t,
+			file("bin/sh", "#!"),
+			symlink("usr/bin/sh", "/bin/sh"),
+		)
+
+		require.NoError(t, extractZip(archivePath, dest))
+
+		link, err := os.Readlink(filepath.Join(dest, "usr", "bin", "sh"))
+		require.NoError(t, err)
+		require.Equal(t, "/bin/sh", link)
+	})
+
+	for name, linkTarget := range map[string]func(outside string) string{
+		"absolute": func(outside string) string { return outside },
+		"relative": func(outside string) string { return "../outside" },
+	} {
+		linkTarget := linkTarget
+		t.Run("writing through "+name+" links is rejected", func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			outside := filepath.Join(root, "outside")
+			require.NoError(t, os.Mkdir(outside, 0o700))
+
+			dest := filepath.Join(root, "contents")
+			archivePath := writeZip(t,
+				symlink("ssh", linkTarget(outside)),
+				file("ssh/authorized_keys", "ssh-ed25519 AAAA attacker"),
+			)
+
+			err := extractZip(archivePath, dest)
+			require.ErrorContains(t, err, "path traverses symlink ssh")
+
+			_, err = os.Stat(filepath.Join(outside, "authorized_keys"))
+			require.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
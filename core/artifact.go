@@ -0,0 +1,565 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/dagger/dagger/core/pipeline"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/stream"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/identity"
+	"github.com/opencontainers/go-digest"
+	specsgo "github.com/opencontainers/image-spec/specs-go"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DefaultFileArtifactMediaType is the layer media type used when
+	// publishing a file without an explicit media type.
+	DefaultFileArtifactMediaType = "application/vnd.oci.image.layer.v1.tar"
+
+	// DefaultDirectoryArtifactMediaType is the layer media type used when
+	// publishing a directory without an explicit media type.
+	DefaultDirectoryArtifactMediaType = specs.MediaTypeImageLayerGzip
+
+	// artifactConfigMediaType is the config media type of published
+	// artifacts, matching the ORAS default.
+	artifactConfigMediaType = "application/vnd.unknown.config.v1+json"
+
+	// artifactUnpackAnnotation marks a layer as a directory archive that
+	// ORAS-compatible clients unpack on pull.
+	artifactUnpackAnnotation = "io.deis.oras.content.unpack"
+)
+
+// artifactLayer is the single layer of a published artifact.
+type artifactLayer struct {
+	Title  string
+	Layer  v1.Layer
+	Unpack bool
+}
+
+// publishArtifact pushes the layer to the address as an ORAS-style OCI
+// artifact, returning the fully qualified reference to the pushed manifest.
+func publishArtifact(ctx context.Context, address string, layer artifactLayer, keychain authn.Keychain) (string, error) {
+	ref, err := name.ParseReference(address)
+	if err != nil {
+		return "", err
+	}
+
+	annotations := map[string]string{
+		specs.AnnotationTitle: layer.Title,
+	}
+	if layer.Unpack {
+		annotations[artifactUnpackAnnotation] = "true"
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       layer.Layer,
+		Annotations: annotations,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, artifactConfigMediaType)
+
+	if err := remote.Write(ref, img,
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+	); err != nil {
+		return "", fmt.Errorf("push %s: %w", address, err)
+	}
+
+	dig, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Context().Digest(dig.String()).String(), nil
+}
+
+// tarRef writes the tree rooted at dir in the reference to tw as a tar
+// archive, with paths relative to dir under prefix.
+func tarRef(ctx context.Context, ref bkgw.Reference, dir, rel, prefix string, tw *tar.Writer) error {
+	entries, err := ref.ReadDir(ctx, bkgw.ReadDirRequest{
+		Path: path.Join(dir, rel),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryPath := path.Join(rel, entry.GetPath())
+		mode := fs.FileMode(entry.GetMode())
+
+		hdr := &tar.Header{
+			Name:    path.Join(prefix, entryPath),
+			Mode:    int64(mode.Perm()),
+			Uid:     int(entry.GetUid()),
+			Gid:     int(entry.GetGid()),
+			ModTime: time.Unix(0, entry.GetModTime()),
+		}
+
+		switch {
+		case mode.IsDir():
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+		case mode&fs.ModeSymlink != 0:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = entry.GetLinkname()
+		case mode.IsRegular():
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = entry.GetSize_()
+		default:
+			// skip devices, sockets, and pipes
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := tarRef(ctx, ref, dir, entryPath, prefix, tw); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := copyRefFile(ctx, ref, path.Join(dir, entryPath), hdr.Size, tw); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyRefFile writes the contents of the file in the reference to tw in
+// chunks, to stay below the gateway's message size limit.
+func copyRefFile(ctx context.Context, ref bkgw.Reference, filename string, size int64, tw *tar.Writer) error {
+	var offset int64
+	for offset < size {
+		chunk, err := ref.ReadFile(ctx, bkgw.ReadRequest{
+			Filename: filename,
+			Range: &bkgw.FileRange{
+				Offset: int(offset),
+				Length: MaxFileContentsChunkSize,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(chunk) == 0 {
+			return fmt.Errorf("%s: unexpected end of file", filename)
+		}
+
+		if _, err := tw.Write(chunk); err != nil {
+			return err
+		}
+
+		offset += int64(len(chunk))
+	}
+
+	return nil
+}
+
+// archiveRef returns a tar archive of the tree rooted at dir in the reference,
+// with paths under prefix. The archive is written as it is read, so it's never
+// held in memory; closing the reader stops writing it.
+func archiveRef(ctx context.Context, ref bkgw.Reference, dir, prefix string) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tarRef(ctx, ref, dir, "", prefix, tw)
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// Publish pushes the directory to a registry as an OCI artifact with a single
+// gzipped tar layer.
+func (dir *Directory) Publish(ctx context.Context, gw bkgw.Client, address string, mediaType string, keychain authn.Keychain) (string, error) {
+	if mediaType == "" {
+		mediaType = DefaultDirectoryArtifactMediaType
+	}
+
+	// the layer is always gzipped, so it must be labeled as such
+	if !isGzipMediaType(mediaType) {
+		return "", fmt.Errorf("media type %q is not a gzipped layer type; directories are published as gzipped tarballs", mediaType)
+	}
+
+	return WithServices(ctx, gw, dir.Services, func() (string, error) {
+		ref, err := gwRef(ctx, gw, dir.LLB)
+		if err != nil {
+			return "", err
+		}
+
+		root := dir.Dir
+		if root == "" {
+			root = "/"
+		}
+
+		// like ORAS, archive the contents under a directory named by the
+		// layer's title, which is where they're unpacked on pull
+		title := path.Base(root)
+		if title == "/" {
+			title = "rootfs"
+		}
+
+		archive := archiveRef(ctx, ref, root, title)
+		defer archive.Close()
+
+		// the layer is gzipped and digested as it's pushed
+		return publishArtifact(ctx, address, artifactLayer{
+			Title:  title,
+			Layer:  stream.NewLayer(archive, stream.WithMediaType(types.MediaType(mediaType))),
+			Unpack: true,
+		}, keychain)
+	})
+}
+
+// isGzipMediaType returns whether a media type describes gzipped content, like
+// application/vnd.oci.image.layer.v1.tar+gzip or Docker's
+// application/vnd.docker.image.rootfs.diff.tar.gzip.
+func isGzipMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "+gzip") || strings.HasSuffix(mediaType, ".gzip")
+}
+
+// Publish pushes the file to a registry as an OCI artifact with a single
+// layer containing the file's contents.
+func (file *File) Publish(ctx context.Context, gw bkgw.Client, address string, mediaType string, keychain authn.Keychain) (string, error) {
+	if mediaType == "" {
+		mediaType = DefaultFileArtifactMediaType
+	}
+
+	content, err := file.Contents(ctx, gw)
+	if err != nil {
+		return "", err
+	}
+
+	return publishArtifact(ctx, address, artifactLayer{
+		Title: path.Base(file.File),
+		Layer: static.NewLayer(content, types.MediaType(mediaType)),
+	}, keychain)
+}
+
+// LoadArtifact pulls an OCI artifact and materializes it as a directory.
+//
+// Each layer with a title annotation is written to a file named by its title.
+// Layers marked for unpacking (e.g. those published from a Directory) are
+// extracted instead.
+//
+// The layers are written to the OCI store as an image whose layers contain
+// the blobs, so that Buildkit can load it like an imported container.
+func LoadArtifact(
+	ctx context.Context,
+	address string,
+	keychain authn.Keychain,
+	store content.Store,
+	pipeline pipeline.Path,
+	platform specs.Platform,
+) (*Directory, error) {
+	ref, err := name.ParseReference(address)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := remote.Image(ref,
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", address, err)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	diffIDs := []digest.Digest{}
+	layerDescs := []specs.Descriptor{}
+	for i, layer := range layers {
+		title := manifest.Layers[i].Annotations[specs.AnnotationTitle]
+		if title == "" {
+			// like ORAS, skip untitled blobs
+			continue
+		}
+
+		title = path.Clean(title)
+		if path.IsAbs(title) || title == ".." || strings.HasPrefix(title, "../") {
+			return nil, fmt.Errorf("artifact layer %d: invalid title %q", i, title)
+		}
+
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+
+		// the layer is streamed into the store; its digest is verified as
+		// it's read
+		var desc specs.Descriptor
+		var diffID digest.Digest
+		if manifest.Layers[i].Annotations[artifactUnpackAnnotation] == "true" {
+			// already a gzipped tarball of the directory
+			desc, diffID, err = writeUnpackLayer(ctx, store, rc)
+		} else {
+			desc, diffID, err = writeFileLayerFrom(ctx, store, title, rc, manifest.Layers[i].Size)
+		}
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("write layer %s: %w", title, err)
+		}
+
+		layerDescs = append(layerDescs, desc)
+		diffIDs = append(diffIDs, diffID)
+	}
+
+	// NB: the repository portion of this ref doesn't actually matter, but it's
+	// pleasant to see something recognizable.
+	st, err := loadLayers(ctx, store, "dagger/artifact", layerDescs, diffIDs, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDirectorySt(ctx, st, "", pipeline, platform, nil)
+}
+
+// storeFile writes the contents produced by write to the OCI store and loads
+// them as a File named name. This lets contents produced by the engine, like
+// archives, be used in Buildkit without a round-trip through the host.
+func storeFile(
+	ctx context.Context,
+	store content.Store,
+	name string,
+	write func(io.Writer) error,
+	pipeline pipeline.Path,
+	platform specs.Platform,
+) (*File, error) {
+	blob, err := writeStreamBlob(ctx, store, "application/octet-stream", write)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, err := store.ReaderAt(ctx, blob)
+	if err != nil {
+		return nil, err
+	}
+	defer ra.Close()
+
+	layer, diffID, err := writeFileLayerFrom(ctx, store, name, io.NewSectionReader(ra, 0, ra.Size()), ra.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := loadLayers(ctx, store, "dagger/file", []specs.Descriptor{layer}, []digest.Digest{diffID}, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileSt(ctx, st, path.Join("/", name), pipeline, platform, nil)
+}
+
+// loadLayers writes an image made of the given layers to the OCI store, and
+// returns a state which loads it like an imported container.
+func loadLayers(
+	ctx context.Context,
+	store content.Store,
+	repo string,
+	layers []specs.Descriptor,
+	diffIDs []digest.Digest,
+	platform specs.Platform,
+) (llb.State, error) {
+	configDesc, err := writeJSONBlob(ctx, store, specs.MediaTypeImageConfig, specs.Image{
+		Platform: specs.Platform{
+			Architecture: platform.Architecture,
+			OS:           platform.OS,
+		},
+		RootFS: specs.RootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	})
+	if err != nil {
+		return llb.State{}, err
+	}
+
+	manifestDesc, err := writeJSONBlob(ctx, store, specs.MediaTypeImageManifest, specs.Manifest{
+		Versioned: specsgo.Versioned{SchemaVersion: 2},
+		MediaType: specs.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		return llb.State{}, err
+	}
+
+	return llb.OCILayout(
+		fmt.Sprintf("%s@%s", repo, manifestDesc.Digest),
+		llb.OCIStore("", OCIStoreName),
+		llb.Platform(platform),
+	), nil
+}
+
+// writeFileLayer writes an uncompressed layer containing the blob as a file
+// named by title.
+func writeFileLayer(ctx context.Context, store content.Store, title string, blob []byte) (specs.Descriptor, digest.Digest, error) {
+	return writeFileLayerFrom(ctx, store, title, bytes.NewReader(blob), int64(len(blob)))
+}
+
+// writeFileLayerFrom writes an uncompressed layer containing the size bytes
+// read from r as a file named by title.
+func writeFileLayerFrom(ctx context.Context, store content.Store, title string, r io.Reader, size int64) (specs.Descriptor, digest.Digest, error) {
+	desc, err := writeStreamBlob(ctx, store, specs.MediaTypeImageLayer, func(w io.Writer) error {
+		tw := tar.NewWriter(w)
+
+		if dir := path.Dir(title); dir != "." {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     dir + "/",
+				Mode:     0o755,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     title,
+			Mode:     0o644,
+			Size:     size,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(tw, r); err != nil {
+			return err
+		}
+
+		return tw.Close()
+	})
+	if err != nil {
+		return specs.Descriptor{}, "", err
+	}
+
+	// the layer is uncompressed, so its digest is its diff ID
+	return desc, desc.Digest, nil
+}
+
+// writeUnpackLayer writes a gzipped tarball read from r as a layer as-is.
+func writeUnpackLayer(ctx context.Context, store content.Store, r io.Reader) (specs.Descriptor, digest.Digest, error) {
+	desc, err := writeStreamBlob(ctx, store, specs.MediaTypeImageLayerGzip, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+	if err != nil {
+		return specs.Descriptor{}, "", err
+	}
+
+	// read the layer back from the store to compute its diff ID, rather than
+	// holding it in memory
+	ra, err := store.ReaderAt(ctx, desc)
+	if err != nil {
+		return specs.Descriptor{}, "", err
+	}
+	defer ra.Close()
+
+	zr, err := gzip.NewReader(io.NewSectionReader(ra, 0, ra.Size()))
+	if err != nil {
+		return specs.Descriptor{}, "", err
+	}
+	defer zr.Close()
+
+	diffID, err := digest.FromReader(zr)
+	if err != nil {
+		return specs.Descriptor{}, "", err
+	}
+
+	return desc, diffID, nil
+}
+
+func writeJSONBlob(ctx context.Context, store content.Store, mediaType string, val any) (specs.Descriptor, error) {
+	payload, err := json.Marshal(val)
+	if err != nil {
+		return specs.Descriptor{}, err
+	}
+
+	return writeBlob(ctx, store, mediaType, payload)
+}
+
+func writeBlob(ctx context.Context, store content.Store, mediaType string, blob []byte) (specs.Descriptor, error) {
+	desc := specs.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+
+	if err := content.WriteBlob(ctx, store, desc.Digest.String(), bytes.NewReader(blob), desc); err != nil {
+		return specs.Descriptor{}, err
+	}
+
+	return desc, nil
+}
+
+// writeStreamBlob writes the contents produced by write to the store as they
+// are written, so that they're never held in memory.
+func writeStreamBlob(ctx context.Context, store content.Store, mediaType string, write func(io.Writer) error) (specs.Descriptor, error) {
+	w, err := content.OpenWriter(ctx, store, content.WithRef("dagger-"+identity.NewID()))
+	if err != nil {
+		return specs.Descriptor{}, err
+	}
+	defer w.Close()
+
+	if err := write(w); err != nil {
+		return specs.Descriptor{}, err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return specs.Descriptor{}, err
+	}
+
+	desc := specs.Descriptor{
+		MediaType: mediaType,
+		Digest:    w.Digest(),
+		Size:      status.Offset,
+	}
+
+	if err := w.Commit(ctx, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return specs.Descriptor{}, err
+	}
+
+	return desc, nil
+}
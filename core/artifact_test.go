@@ -0,0 +1,280 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/stream"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishArtifact(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pushed, err := publishArtifact(context.Background(), host+"/sbom:v1", artifactLayer{
+		Title: "sbom.spdx.json",
+		Layer: static.NewLayer([]byte(`{"spdxVersion":"SPDX-2.3"}`), "application/spdx+json"),
+	}, authn.DefaultKeychain)
+	require.NoError(t, err)
+	require.Contains(t, pushed, host+"/sbom@sha256:")
+
+	ref, err := name.ParseReference(pushed)
+	require.NoError(t, err)
+
+	img, err := remote.Image(ref)
+	require.NoError(t, err)
+
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+	require.Equal(t, artifactConfigMediaType, string(manifest.Config.MediaType))
+	require.Len(t, manifest.Layers, 1)
+	require.Equal(t, "application/spdx+json", string(manifest.Layers[0].MediaType))
+	require.Equal(t, "sbom.spdx.json", manifest.Layers[0].Annotations[specs.AnnotationTitle])
+	require.NotContains(t, manifest.Layers[0].Annotations, artifactUnpackAnnotation)
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+
+	rc, err := layers[0].Compressed()
+	require.NoError(t, err)
+	defer rc.Close()
+
+	contents, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, `{"spdxVersion":"SPDX-2.3"}`, string(contents))
+}
+
+func TestPublishArtifactStream(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "dist/app.js",
+			Mode:     0o644,
+			Size:     int64(len("console.log(1)")),
+		})
+		if err == nil {
+			_, err = io.WriteString(tw, "console.log(1)")
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	pushed, err := publishArtifact(context.Background(), host+"/dist:v1", artifactLayer{
+		Title:  "dist",
+		Layer:  stream.NewLayer(pr, stream.WithMediaType(DefaultDirectoryArtifactMediaType)),
+		Unpack: true,
+	}, authn.DefaultKeychain)
+	require.NoError(t, err)
+	require.Contains(t, pushed, host+"/dist@sha256:")
+
+	ref, err := name.ParseReference(pushed)
+	require.NoError(t, err)
+
+	img, err := remote.Image(ref)
+	require.NoError(t, err)
+
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Layers, 1)
+	require.Equal(t, DefaultDirectoryArtifactMediaType, string(manifest.Layers[0].MediaType))
+	require.Equal(t, "true", manifest.Layers[0].Annotations[artifactUnpackAnnotation])
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+
+	rc, err := layers[0].Uncompressed()
+	require.NoError(t, err)
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, "dist/app.js", hdr.Name)
+
+	contents, err := io.ReadAll(tr)
+	require.NoError(t, err)
+	require.Equal(t, "console.log(1)", string(contents))
+}
+
+func TestLoadArtifact(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pushed, err := publishArtifact(ctx, host+"/bin:v1", artifactLayer{
+		Title: "bin/tool",
+		Layer: static.NewLayer([]byte("#!/bin/sh\necho hi\n"), DefaultFileArtifactMediaType),
+	}, authn.DefaultKeychain)
+	require.NoError(t, err)
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	dir, err := LoadArtifact(ctx, pushed, authn.DefaultKeychain, store, nil, specs.Platform{
+		OS:           "linux",
+		Architecture: "amd64",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, dir.LLB)
+
+	layers := []content.Info{}
+	require.NoError(t, store.Walk(ctx, func(info content.Info) error {
+		layers = append(layers, info)
+		return nil
+	}))
+
+	// layer, config, and manifest
+	require.Len(t, layers, 3)
+}
+
+func TestLoadArtifactUnpack(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "dist/app.js",
+		Mode:     0o644,
+		Size:     int64(len("console.log(1)")),
+	}))
+	_, err := io.WriteString(tw, "console.log(1)")
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	pushed, err := publishArtifact(ctx, host+"/dist:v1", artifactLayer{
+		Title:  "dist",
+		Layer:  stream.NewLayer(io.NopCloser(&archive), stream.WithMediaType(DefaultDirectoryArtifactMediaType)),
+		Unpack: true,
+	}, authn.DefaultKeychain)
+	require.NoError(t, err)
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = LoadArtifact(ctx, pushed, authn.DefaultKeychain, store, nil, specs.Platform{
+		OS:           "linux",
+		Architecture: "amd64",
+	})
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(pushed)
+	require.NoError(t, err)
+
+	img, err := remote.Image(ref)
+	require.NoError(t, err)
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+
+	layerDigest, err := layers[0].Digest()
+	require.NoError(t, err)
+
+	diffID, err := layers[0].DiffID()
+	require.NoError(t, err)
+
+	var config specs.Image
+	require.NoError(t, store.Walk(ctx, func(info content.Info) error {
+		blob, err := content.ReadBlob(ctx, store, specs.Descriptor{Digest: info.Digest})
+		if err != nil {
+			return err
+		}
+		var img specs.Image
+		if json.Unmarshal(blob, &img) == nil && img.RootFS.Type == "layers" {
+			config = img
+		}
+		return nil
+	}))
+
+	// the gzipped layer is stored as-is, with the diff ID of its contents
+	_, err = store.Info(ctx, digest.Digest(layerDigest.String()))
+	require.NoError(t, err)
+	require.Equal(t, []digest.Digest{digest.Digest(diffID.String())}, config.RootFS.DiffIDs)
+}
+
+func TestDirectoryPublishMediaType(t *testing.T) {
+	t.Parallel()
+
+	// the layer is always gzipped, so other media types are rejected before
+	// anything is solved
+	_, err := (&Directory{}).Publish(context.Background(), nil, "registry/dist:v1", specs.MediaTypeImageLayer, authn.DefaultKeychain)
+	require.ErrorContains(t, err, "not a gzipped layer type")
+}
+
+func TestWriteFileLayer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	desc, diffID, err := writeFileLayer(ctx, store, "bin/tool", []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, desc.Digest, diffID)
+	require.Equal(t, specs.MediaTypeImageLayer, desc.MediaType)
+
+	blob, err := content.ReadBlob(ctx, store, desc)
+	require.NoError(t, err)
+
+	tr := tar.NewReader(bytes.NewReader(blob))
+
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, "bin/", hdr.Name)
+	require.Equal(t, byte(tar.TypeDir), hdr.Typeflag)
+
+	hdr, err = tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, "bin/tool", hdr.Name)
+
+	contents, err := io.ReadAll(tr)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+
+	_, err = tr.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
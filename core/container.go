@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/base32"
 	"encoding/binary"
@@ -9,14 +10,17 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/pkg/transfer/archive"
 	"github.com/containerd/containerd/platforms"
@@ -41,9 +45,16 @@ type Container struct {
 	// The container's root filesystem.
 	FS *pb.Definition `json:"fs"`
 
+	// The root filesystem of the container's base image, if any.
+	BaseFS *pb.Definition `json:"base_fs,omitempty"`
+
 	// Image configuration (env, workdir, etc)
 	Config specs.ImageConfig `json:"cfg"`
 
+	// History of the image, starting from the base image's, with an entry
+	// appended for each operation that changed the rootfs or config
+	History []specs.History `json:"history,omitempty"`
+
 	// Pipeline
 	Pipeline pipeline.Path `json:"pipeline"`
 
@@ -68,12 +79,31 @@ type Container struct {
 	// Hostname is the computed hostname for the container.
 	Hostname string `json:"hostname,omitempty"`
 
+	// CustomHostname, when set, is the hostname seen by the container's execs,
+	// and an alias for the computed hostname in containers bound to it as a
+	// service.
+	CustomHostname string `json:"custom_hostname,omitempty"`
+
 	// Ports to expose from the container.
 	Ports []ContainerPort `json:"ports,omitempty"`
 
+	// How long to wait for the container's ports to accept connections when
+	// it's started as a service, in seconds. Zero means no limit.
+	HealthcheckTimeout int `json:"healthcheck_timeout,omitempty"`
+
 	// Services to start before running the container.
 	Services    ServiceBindings `json:"services,omitempty"`
 	HostAliases []HostAlias     `json:"host_aliases,omitempty"`
+
+	// Extra /etc/hosts entries for the container's execs.
+	ExtraHosts []ExtraHost `json:"extra_hosts,omitempty"`
+
+	// DNS resolver configuration for the container's execs.
+	DNS *DNSConfig `json:"dns,omitempty"`
+
+	// OCI annotations to set on the image manifest (and index) when the
+	// container is published or exported.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 func NewContainer(id ContainerID, pipeline pipeline.Path, platform specs.Platform) (*Container, error) {
@@ -98,12 +128,15 @@ func (container *Container) Clone() *Container {
 	cp.Config.Cmd = cloneSlice(cp.Config.Cmd)
 	cp.Config.Volumes = cloneMap(cp.Config.Volumes)
 	cp.Config.Labels = cloneMap(cp.Config.Labels)
+	cp.History = cloneSlice(cp.History)
 	cp.Mounts = cloneSlice(cp.Mounts)
 	cp.Secrets = cloneSlice(cp.Secrets)
 	cp.Sockets = cloneSlice(cp.Sockets)
 	cp.Ports = cloneSlice(cp.Ports)
 	cp.Services = cloneMap(cp.Services)
 	cp.HostAliases = cloneSlice(cp.HostAliases)
+	cp.ExtraHosts = cloneSlice(cp.ExtraHosts)
+	cp.Annotations = cloneMap(cp.Annotations)
 	cp.Pipeline = cloneSlice(cp.Pipeline)
 	return &cp
 }
@@ -168,6 +201,20 @@ type HostAlias struct {
 	Target string `json:"target"`
 }
 
+// ExtraHost is an /etc/hosts entry mapping a hostname to an IP address.
+type ExtraHost struct {
+	Hostname string `json:"hostname"`
+	IP       string `json:"ip"`
+}
+
+// DNSConfig overrides the resolver configuration (/etc/resolv.conf) of a
+// container's execs. Empty fields keep the worker's defaults.
+type DNSConfig struct {
+	Nameservers   []string `json:"nameservers,omitempty"`
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	Options       []string `json:"options,omitempty"`
+}
+
 // Ownership contains a UID/GID pair resolved from a user/group name or ID pair
 // provided via the API. It primarily exists to distinguish an unspecified
 // ownership from UID/GID 0 (root) ownership.
@@ -202,6 +249,36 @@ type ContainerPort struct {
 	Port        int             `json:"port"`
 	Protocol    NetworkProtocol `json:"protocol"`
 	Description *string         `json:"description,omitempty"`
+
+	// Don't wait for the port to accept connections when the container is
+	// started as a service.
+	SkipHealthcheck bool `json:"skip_healthcheck,omitempty"`
+}
+
+// ExecStats are resource usage statistics of an executed command, written
+// by the shim to the meta mount.
+type ExecStats struct {
+	// Wall-clock duration, in seconds.
+	Duration float64 `json:"duration"`
+
+	// CPU time spent in user mode, in seconds.
+	UserTime float64 `json:"userTime"`
+
+	// CPU time spent in kernel mode, in seconds.
+	SystemTime float64 `json:"systemTime"`
+
+	// Maximum resident set size, in bytes.
+	MaxRSS int64 `json:"maxRSS"`
+
+	// The signal that killed the command, if any (e.g. SIGKILL).
+	Signal string `json:"signal,omitempty"`
+
+	// Whether the command or one of its children was killed for running out
+	// of memory.
+	OOMKilled bool `json:"oomKilled"`
+
+	// Whether the command was killed for exceeding its timeout.
+	TimedOut bool `json:"timedOut"`
 }
 
 // FSState returns the container's root filesystem mount state. If there is
@@ -254,6 +331,39 @@ type ContainerMount struct {
 
 	// Configure the mount as a tmpfs.
 	Tmpfs bool `json:"tmpfs,omitempty"`
+
+	// Limit the size of a tmpfs mount, in bytes.
+	TmpfsSize int64 `json:"tmpfs_size,omitempty"`
+
+	// The source is a single file rather than a directory.
+	File bool `json:"file,omitempty"`
+
+	// Mount the source read-only.
+	Readonly bool `json:"readonly,omitempty"`
+}
+
+// MountType is a string deriving from MountType enum
+type MountType string
+
+const (
+	MountTypeDirectory MountType = "DIRECTORY_MOUNT"
+	MountTypeFile      MountType = "FILE_MOUNT"
+	MountTypeCache     MountType = "CACHE_MOUNT"
+	MountTypeTmpfs     MountType = "TMPFS_MOUNT"
+)
+
+// Type returns the kind of the mount's source.
+func (mnt ContainerMount) Type() MountType {
+	switch {
+	case mnt.Tmpfs:
+		return MountTypeTmpfs
+	case mnt.CacheID != "":
+		return MountTypeCache
+	case mnt.File:
+		return MountTypeFile
+	default:
+		return MountTypeDirectory
+	}
 }
 
 // SourceState returns the state of the source of the mount.
@@ -332,11 +442,80 @@ func (container *Container) From(ctx context.Context, gw bkgw.Client, addr strin
 	}
 
 	container.FS = def.ToPB()
+	container.BaseFS = container.FS
 
 	// associate vertexes to the 'from' sub-pipeline
 	recordVertexes(subRecorder, container.FS)
 
 	container.Config = mergeImageConfig(container.Config, imgSpec.Config)
+	container.History = imgSpec.History
+	container.ImageRef = digested.String()
+
+	return container, nil
+}
+
+// FromLocal is like From, but only uses an image already in the OCI store,
+// e.g. one loaded with Import, and never the network. Since tags could only
+// be resolved against a registry, the address must be pinned by digest.
+func (container *Container) FromLocal(ctx context.Context, store content.Store, addr string) (*Container, error) {
+	refName, err := reference.ParseNormalizedNamed(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	digested, ok := refName.(reference.Digested)
+	if !ok {
+		return nil, fmt.Errorf("%w: image %s must be pinned by digest", ErrOffline, addr)
+	}
+
+	info, err := store.Info(ctx, digested.Digest())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: image %s has not been preloaded", ErrOffline, addr)
+		}
+		return nil, err
+	}
+
+	desc := specs.Descriptor{
+		Digest: info.Digest,
+		Size:   info.Size,
+	}
+
+	blob, err := content.ReadBlob(ctx, store, desc)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", addr, err)
+	}
+
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(blob, &manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", addr, err)
+	}
+
+	desc.MediaType = manifest.MediaType
+
+	container = container.Clone()
+
+	switch desc.MediaType {
+	case specs.MediaTypeImageManifest, // OCI
+		images.MediaTypeDockerSchema2Manifest: // Docker
+	case specs.MediaTypeImageIndex, // OCI
+		images.MediaTypeDockerSchema2ManifestList: // Docker
+		manifestDesc, err := resolveIndex(ctx, store, desc, container.Platform, "")
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", addr, err)
+		}
+		desc = *manifestDesc
+	default:
+		return nil, fmt.Errorf("%s: expected manifest or index, got %q", addr, desc.MediaType)
+	}
+
+	container, err = container.fromOCIStore(ctx, store, desc)
+	if err != nil {
+		return nil, err
+	}
+
 	container.ImageRef = digested.String()
 
 	return container, nil
@@ -466,6 +645,7 @@ func (container *Container) buildUncached(
 
 		container.FS = def.ToPB()
 		container.FS.Source = nil
+		container.BaseFS = container.FS
 
 		cfgBytes, found := res.Metadata[exptypes.ExporterImageConfigKey]
 		if found {
@@ -475,6 +655,7 @@ func (container *Container) buildUncached(
 			}
 
 			container.Config = mergeImageConfig(container.Config, imgSpec.Config)
+			container.History = imgSpec.History
 		}
 
 		return container, nil
@@ -491,7 +672,40 @@ func (container *Container) RootFS(ctx context.Context) (*Directory, error) {
 	}, nil
 }
 
+// Changes returns the changes made to the container's rootfs on top of its
+// base image, or its whole rootfs if it has none.
+func (container *Container) Changes(ctx context.Context) (*Directory, error) {
+	root, err := container.RootFS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if container.BaseFS == nil {
+		return root, nil
+	}
+
+	base := NewDirectory(ctx, container.BaseFS, "/", container.Pipeline, container.Platform, nil)
+	return base.Diff(ctx, root)
+}
+
+// WithRootFS replaces the container's rootfs with the given directory. The
+// image history and base image no longer describe the rootfs, so they are
+// reset, and all of the new rootfs counts as changes.
 func (container *Container) WithRootFS(ctx context.Context, dir *Directory) (*Container, error) {
+	container, err := container.setRootFS(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	container.History = nil
+	container.BaseFS = nil
+
+	return container, nil
+}
+
+// setRootFS sets the container's rootfs to a directory derived from it,
+// keeping its history.
+func (container *Container) setRootFS(ctx context.Context, dir *Directory) (*Container, error) {
 	container = container.Clone()
 
 	dirSt, err := dir.StateWithSourcePath()
@@ -517,7 +731,7 @@ func (container *Container) WithRootFS(ctx context.Context, dir *Directory) (*Co
 func (container *Container) WithDirectory(ctx context.Context, gw bkgw.Client, subdir string, src *Directory, filter CopyFilter, owner string) (*Container, error) {
 	container = container.Clone()
 
-	return container.writeToPath(ctx, gw, subdir, func(dir *Directory) (*Directory, error) {
+	return container.writeToPath(ctx, gw, subdir, "copy directory to "+subdir, func(dir *Directory) (*Directory, error) {
 		ownership, err := container.ownership(ctx, gw, owner)
 		if err != nil {
 			return nil, err
@@ -530,7 +744,7 @@ func (container *Container) WithDirectory(ctx context.Context, gw bkgw.Client, s
 func (container *Container) WithFile(ctx context.Context, gw bkgw.Client, subdir string, src *File, permissions fs.FileMode, owner string) (*Container, error) {
 	container = container.Clone()
 
-	return container.writeToPath(ctx, gw, subdir, func(dir *Directory) (*Directory, error) {
+	return container.writeToPath(ctx, gw, subdir, "copy file to "+subdir, func(dir *Directory) (*Directory, error) {
 		ownership, err := container.ownership(ctx, gw, owner)
 		if err != nil {
 			return nil, err
@@ -544,7 +758,7 @@ func (container *Container) WithNewFile(ctx context.Context, gw bkgw.Client, des
 	container = container.Clone()
 
 	dir, file := filepath.Split(dest)
-	return container.writeToPath(ctx, gw, dir, func(dir *Directory) (*Directory, error) {
+	return container.writeToPath(ctx, gw, dir, "create file "+dest, func(dir *Directory) (*Directory, error) {
 		ownership, err := container.ownership(ctx, gw, owner)
 		if err != nil {
 			return nil, err
@@ -554,22 +768,33 @@ func (container *Container) WithNewFile(ctx context.Context, gw bkgw.Client, des
 	})
 }
 
-func (container *Container) WithMountedDirectory(ctx context.Context, gw bkgw.Client, target string, dir *Directory, owner string) (*Container, error) {
+func (container *Container) WithMountedDirectory(ctx context.Context, gw bkgw.Client, target string, dir *Directory, filter CopyFilter, owner string, readonly bool) (*Container, error) {
 	container = container.Clone()
 
-	return container.withMounted(ctx, gw, target, dir.LLB, dir.Dir, dir.Services, owner)
+	if len(filter.Include) > 0 || len(filter.Exclude) > 0 {
+		// mount a filtered copy, so that changes to excluded paths don't bust
+		// the cache of execs that use the mount
+		var err error
+		dir, err = NewDirectory(ctx, nil, "/", container.Pipeline, container.Platform, nil).
+			WithDirectory(ctx, "", dir, filter, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return container.withMounted(ctx, gw, target, dir.LLB, dir.Dir, false, dir.Services, owner, readonly)
 }
 
-func (container *Container) WithMountedFile(ctx context.Context, gw bkgw.Client, target string, file *File, owner string) (*Container, error) {
+func (container *Container) WithMountedFile(ctx context.Context, gw bkgw.Client, target string, file *File, owner string, readonly bool) (*Container, error) {
 	container = container.Clone()
 
-	return container.withMounted(ctx, gw, target, file.LLB, file.File, file.Services, owner)
+	return container.withMounted(ctx, gw, target, file.LLB, file.File, true, file.Services, owner, readonly)
 }
 
 func (container *Container) WithMountedCache(ctx context.Context, gw bkgw.Client, target string, cache *CacheVolume, source *Directory, concurrency CacheSharingMode, owner string) (*Container, error) {
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	cacheSharingMode := ""
 	switch concurrency {
@@ -615,14 +840,19 @@ func (container *Container) WithMountedCache(ctx context.Context, gw bkgw.Client
 	return container, nil
 }
 
-func (container *Container) WithMountedTemp(ctx context.Context, target string) (*Container, error) {
+func (container *Container) WithMountedTemp(ctx context.Context, target string, size int64) (*Container, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("tmpfs size must not be negative: %d", size)
+	}
+
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	container.Mounts = container.Mounts.With(ContainerMount{
-		Target: target,
-		Tmpfs:  true,
+		Target:    target,
+		Tmpfs:     true,
+		TmpfsSize: size,
 	})
 
 	// set image ref to empty string
@@ -634,7 +864,7 @@ func (container *Container) WithMountedTemp(ctx context.Context, target string)
 func (container *Container) WithMountedSecret(ctx context.Context, gw bkgw.Client, target string, source *Secret, owner string) (*Container, error) {
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	ownership, err := container.ownership(ctx, gw, owner)
 	if err != nil {
@@ -661,7 +891,7 @@ func (container *Container) WithMountedSecret(ctx context.Context, gw bkgw.Clien
 func (container *Container) WithoutMount(ctx context.Context, target string) (*Container, error) {
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	var found bool
 	var foundIdx int
@@ -695,7 +925,7 @@ func (container *Container) MountTargets(ctx context.Context) ([]string, error)
 func (container *Container) WithUnixSocket(ctx context.Context, gw bkgw.Client, target string, source *Socket, owner string) (*Container, error) {
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	ownership, err := container.ownership(ctx, gw, owner)
 	if err != nil {
@@ -735,7 +965,7 @@ func (container *Container) WithUnixSocket(ctx context.Context, gw bkgw.Client,
 func (container *Container) WithoutUnixSocket(ctx context.Context, target string) (*Container, error) {
 	container = container.Clone()
 
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	for i, sock := range container.Sockets {
 		if sock.UnixPath == target {
@@ -815,7 +1045,7 @@ func locatePath[T *File | *Directory](
 	containerPath string,
 	init func(context.Context, *pb.Definition, string, pipeline.Path, specs.Platform, ServiceBindings) T,
 ) (T, *ContainerMount, error) {
-	containerPath = absPath(container.Config.WorkingDir, containerPath)
+	containerPath = AbsPath(container.Config.WorkingDir, containerPath)
 
 	// NB(vito): iterate in reverse order so we'll find deeper mounts first
 	for i := len(container.Mounts) - 1; i >= 0; i-- {
@@ -867,10 +1097,12 @@ func (container *Container) withMounted(
 	target string,
 	srcDef *pb.Definition,
 	srcPath string,
+	isFile bool,
 	svcs ServiceBindings,
 	owner string,
+	readonly bool,
 ) (*Container, error) {
-	target = absPath(container.Config.WorkingDir, target)
+	target = AbsPath(container.Config.WorkingDir, target)
 
 	var err error
 	if owner != "" {
@@ -884,6 +1116,8 @@ func (container *Container) withMounted(
 		Source:     srcDef,
 		SourcePath: srcPath,
 		Target:     target,
+		File:       isFile,
+		Readonly:   readonly,
 	})
 
 	container.Services.Merge(svcs)
@@ -973,7 +1207,7 @@ func (container *Container) chown(
 	return def.ToPB(), srcPath, nil
 }
 
-func (container *Container) writeToPath(ctx context.Context, gw bkgw.Client, subdir string, fn func(dir *Directory) (*Directory, error)) (*Container, error) {
+func (container *Container) writeToPath(ctx context.Context, gw bkgw.Client, subdir string, createdBy string, fn func(dir *Directory) (*Directory, error)) (*Container, error) {
 	dir, mount, err := locatePath(ctx, container, subdir, NewDirectory)
 	if err != nil {
 		return nil, err
@@ -993,10 +1227,27 @@ func (container *Container) writeToPath(ctx context.Context, gw bkgw.Client, sub
 			return nil, err
 		}
 
-		return container.WithRootFS(ctx, root)
+		container, err = container.setRootFS(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+
+		return container.WithHistory(createdBy, false), nil
 	}
 
-	return container.withMounted(ctx, gw, mount.Target, dir.LLB, mount.SourcePath, nil, "")
+	return container.withMounted(ctx, gw, mount.Target, dir.LLB, mount.SourcePath, false, nil, "", mount.Readonly)
+}
+
+// WithHistory returns the container with an entry appended to its image
+// history. The entry has no timestamp, so that it doesn't change the
+// container's ID.
+func (container *Container) WithHistory(createdBy string, emptyLayer bool) *Container {
+	container = container.Clone()
+	container.History = append(container.History, specs.History{
+		CreatedBy:  createdBy,
+		EmptyLayer: emptyLayer,
+	})
+	return container
 }
 
 func (container *Container) ImageConfig(ctx context.Context) (specs.ImageConfig, error) {
@@ -1046,6 +1297,18 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 		return nil, errors.New("no command has been set")
 	}
 
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("exec timeout must not be negative: %d", opts.Timeout)
+	}
+
+	if opts.CPULimit < 0 {
+		return nil, fmt.Errorf("exec CPU limit must not be negative: %g", opts.CPULimit)
+	}
+
+	if opts.MemoryLimit < 0 {
+		return nil, fmt.Errorf("exec memory limit must not be negative: %d", opts.MemoryLimit)
+	}
+
 	runOpts := []llb.RunOption{
 		llb.Args(args),
 		llb.WithCustomNamef("exec %s", strings.Join(args, " ")),
@@ -1090,10 +1353,48 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 		runOpts = append(runOpts, llb.AddEnv("_DAGGER_REDIRECT_STDERR", opts.RedirectStderr))
 	}
 
+	if opts.Timeout > 0 {
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_EXEC_TIMEOUT", strconv.Itoa(opts.Timeout)))
+	}
+
 	for _, alias := range container.HostAliases {
 		runOpts = append(runOpts, llb.AddEnv("_DAGGER_HOSTNAME_ALIAS_"+alias.Alias, alias.Target))
 	}
 
+	if container.CustomHostname != "" {
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_HOSTNAME", container.CustomHostname))
+	}
+
+	if opts.AllowFailure {
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_ALLOW_FAILURE", ""))
+	}
+
+	if opts.CPULimit > 0 {
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_CPU_LIMIT", strconv.FormatFloat(opts.CPULimit, 'f', -1, 64)))
+	}
+
+	if opts.MemoryLimit > 0 {
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_MEMORY_LIMIT", strconv.Itoa(opts.MemoryLimit)))
+	}
+
+	if container.DNS != nil {
+		dns, err := json.Marshal(container.DNS)
+		if err != nil {
+			return nil, fmt.Errorf("marshal dns config: %w", err)
+		}
+
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_DNS", string(dns)))
+	}
+
+	if opts.GPU != nil {
+		gpus, err := opts.GPU.shimValue()
+		if err != nil {
+			return nil, err
+		}
+
+		runOpts = append(runOpts, llb.AddEnv("_DAGGER_GPUS", gpus))
+	}
+
 	if cfg.User != "" {
 		runOpts = append(runOpts, llb.User(cfg.User))
 	}
@@ -1214,7 +1515,15 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 		}
 
 		if mnt.Tmpfs {
-			mountOpts = append(mountOpts, llb.Tmpfs())
+			var tmpfsOpts []llb.TmpfsOption
+			if mnt.TmpfsSize > 0 {
+				tmpfsOpts = append(tmpfsOpts, llb.TmpfsSize(mnt.TmpfsSize))
+			}
+			mountOpts = append(mountOpts, llb.Tmpfs(tmpfsOpts...))
+		}
+
+		if mnt.Readonly {
+			mountOpts = append(mountOpts, llb.Readonly)
 		}
 
 		runOpts = append(runOpts, llb.AddMount(mnt.Target, srcSt, mountOpts...))
@@ -1224,6 +1533,14 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 		runOpts = append(runOpts, llb.Security(llb.SecurityModeInsecure))
 	}
 
+	if opts.Offline && len(container.Services) == 0 && len(container.Ports) == 0 {
+		runOpts = append(runOpts, llb.Network(llb.NetModeNone))
+	}
+
+	for _, host := range container.ExtraHosts {
+		runOpts = append(runOpts, llb.AddExtraHost(host.Hostname, net.ParseIP(host.IP)))
+	}
+
 	fsSt, err := container.FSState()
 	if err != nil {
 		return nil, fmt.Errorf("fs state: %w", err)
@@ -1232,7 +1549,8 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 	// first, build without a hostname
 	execStNoHostname := fsSt.Run(runOpts...)
 
-	// next, marshal it to compute a deterministic hostname
+	// next, marshal it to compute a deterministic hostname, which services are
+	// registered in DNS and tracked under, even with a custom hostname
 	constraints := llb.NewConstraints(llb.Platform(platform))
 	rootVtx := execStNoHostname.Root().Output().Vertex(ctx, constraints)
 	digest, _, _, _, err := rootVtx.Marshal(ctx, constraints) //nolint:dogsled
@@ -1252,6 +1570,9 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 	}
 
 	container.FS = execDef.ToPB()
+	container.History = append(container.History, specs.History{
+		CreatedBy: "exec " + strings.Join(args, " "),
+	})
 
 	metaDef, err := execSt.GetMount(metaMountDestPath).Marshal(ctx, llb.Platform(platform))
 	if err != nil {
@@ -1261,7 +1582,7 @@ func (container *Container) WithExec(ctx context.Context, gw bkgw.Client, progSo
 	container.Meta = metaDef.ToPB()
 
 	for i, mnt := range mounts {
-		if mnt.Tmpfs || mnt.CacheID != "" {
+		if mnt.Tmpfs || mnt.CacheID != "" || mnt.Readonly {
 			continue
 		}
 
@@ -1317,12 +1638,33 @@ func (container *Container) ExitCode(ctx context.Context, gw bkgw.Client, progSo
 	return strconv.Atoi(content)
 }
 
+func (container *Container) ExecStats(ctx context.Context, gw bkgw.Client, progSock *Socket) (*ExecStats, error) {
+	content, err := container.MetaFileContents(ctx, gw, progSock, "stats")
+	if err != nil {
+		return nil, err
+	}
+
+	var stats ExecStats
+	if err := json.Unmarshal([]byte(content), &stats); err != nil {
+		return nil, fmt.Errorf("decode exec stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
 func (container *Container) Start(ctx context.Context, gw bkgw.Client) (*Service, error) {
 	if container.Hostname == "" {
 		return nil, ErrContainerNoExec
 	}
 
-	health := newHealth(gw, container.Hostname, container.Ports)
+	checkPorts := []ContainerPort{}
+	for _, port := range container.Ports {
+		if !port.SkipHealthcheck {
+			checkPorts = append(checkPorts, port)
+		}
+	}
+
+	health := newHealth(gw, container.Hostname, checkPorts)
 
 	// annotate the container as a service so they can be treated differently
 	// in the UI
@@ -1337,12 +1679,32 @@ func (container *Container) Start(ctx context.Context, gw bkgw.Client) (*Service
 
 	checked := make(chan error, 1)
 	go func() {
-		checked <- health.Check(svcCtx)
+		if len(checkPorts) == 0 {
+			// nothing to wait for
+			checked <- nil
+			return
+		}
+
+		checkCtx := svcCtx
+		timeout := time.Duration(container.HealthcheckTimeout) * time.Second
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			checkCtx, cancel = context.WithTimeout(svcCtx, timeout)
+			defer cancel()
+		}
+
+		err := health.Check(checkCtx)
+		if err != nil && errors.Is(checkCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("ports not ready after %s", timeout)
+		}
+		checked <- err
 	}()
 
-	exited := make(chan error, 1)
+	var exitErr error
+	exited := make(chan struct{})
 	go func() {
-		exited <- container.Evaluate(svcCtx, gw)
+		exitErr = container.Evaluate(svcCtx, gw)
+		close(exited)
 	}()
 
 	select {
@@ -1357,12 +1719,13 @@ func (container *Container) Start(ctx context.Context, gw bkgw.Client) (*Service
 		return &Service{
 			Container: container,
 			Detach:    stop,
+			Exited:    exited,
 		}, nil
-	case err := <-exited:
+	case <-exited:
 		stop() // interrupt healthcheck
 
-		if err != nil {
-			return nil, fmt.Errorf("exited: %w", err)
+		if exitErr != nil {
+			return nil, fmt.Errorf("exited: %w", exitErr)
 		}
 
 		return nil, fmt.Errorf("service exited before healthcheck")
@@ -1400,17 +1763,43 @@ func (container *Container) Publish(
 	ref string,
 	platformVariants []ContainerID,
 	forcedCompression ImageLayerCompression,
+	compressionLevel int,
+	mediaTypes ImageMediaTypes,
+	provenance bool,
 	bkClient *bkclient.Client,
 	solveOpts bkclient.SolveOpt,
 	solveCh chan<- *bkclient.SolveStatus,
 ) (string, error) {
-	exportOpts := container.baseExportOpts(platformVariants, forcedCompression)
+	if provenance {
+		// attestations are stored alongside the image in an OCI index
+		switch mediaTypes {
+		case "":
+			mediaTypes = OCIMediaTypes
+		case DockerMediaTypes:
+			return "", fmt.Errorf("provenance attestations require %s", OCIMediaTypes)
+		}
+	}
+
+	exportOpts, err := container.baseExportOpts(platformVariants, forcedCompression, compressionLevel, mediaTypes)
+	if err != nil {
+		return "", err
+	}
 	exportOpts.Type = bkclient.ExporterImage // always use image for publishing to registry
 	exportOpts.Attrs["name"] = ref
 	exportOpts.Attrs["push"] = strconv.FormatBool(true)
 	// NOTE: be careful to not overwrite any values from original solveOpts (i.e. with append).
 	solveOpts.Exports = []bkclient.ExportEntry{exportOpts}
 
+	if provenance {
+		// NOTE: copy rather than mutate the original solveOpts' attrs.
+		frontendAttrs := make(map[string]string, len(solveOpts.FrontendAttrs)+1)
+		for k, v := range solveOpts.FrontendAttrs {
+			frontendAttrs[k] = v
+		}
+		frontendAttrs["attest:provenance"] = "mode=max"
+		solveOpts.FrontendAttrs = frontendAttrs
+	}
+
 	ch, wg := mirrorCh(solveCh)
 	defer wg.Wait()
 
@@ -1450,6 +1839,8 @@ func (container *Container) Export(
 	dest string,
 	platformVariants []ContainerID,
 	forcedCompression ImageLayerCompression,
+	compressionLevel int,
+	mediaTypes ImageMediaTypes,
 	bkClient *bkclient.Client,
 	solveOpts bkclient.SolveOpt,
 	solveCh chan<- *bkclient.SolveStatus,
@@ -1463,6 +1854,21 @@ func (container *Container) Export(
 		return err
 	}
 
+	return container.exportTarball(ctx, host, dest, platformVariants, forcedCompression, compressionLevel, mediaTypes, bkClient, solveOpts, solveCh)
+}
+
+func (container *Container) exportTarball(
+	ctx context.Context,
+	host *Host,
+	dest string,
+	platformVariants []ContainerID,
+	forcedCompression ImageLayerCompression,
+	compressionLevel int,
+	mediaTypes ImageMediaTypes,
+	bkClient *bkclient.Client,
+	solveOpts bkclient.SolveOpt,
+	solveCh chan<- *bkclient.SolveStatus,
+) error {
 	out, err := os.Create(dest)
 	if err != nil {
 		return err
@@ -1470,7 +1876,10 @@ func (container *Container) Export(
 
 	defer out.Close()
 
-	exportOpts := container.baseExportOpts(platformVariants, forcedCompression)
+	exportOpts, err := container.baseExportOpts(platformVariants, forcedCompression, compressionLevel, mediaTypes)
+	if err != nil {
+		return err
+	}
 	exportOpts.Output = func(map[string]string) (io.WriteCloser, error) {
 		return out, nil
 	}
@@ -1479,10 +1888,50 @@ func (container *Container) Export(
 	})
 }
 
+// AsTarball returns the container as an image tarball File, in the same
+// format as Export. The tarball is exported into the OCI store rather than
+// to the host, so it works when host access is disabled.
+func (container *Container) AsTarball(
+	ctx context.Context,
+	store content.Store,
+	platformVariants []ContainerID,
+	forcedCompression ImageLayerCompression,
+	compressionLevel int,
+	mediaTypes ImageMediaTypes,
+	bkClient *bkclient.Client,
+	solveOpts bkclient.SolveOpt,
+	solveCh chan<- *bkclient.SolveStatus,
+) (*File, error) {
+	exportOpts, err := container.baseExportOpts(platformVariants, forcedCompression, compressionLevel, mediaTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return storeFile(ctx, store, "container.tar", func(w io.Writer) error {
+		// the store's writer is committed by storeFile, not closed by the
+		// exporter
+		exportOpts.Output = func(map[string]string) (io.WriteCloser, error) {
+			return nopCloser{w}, nil
+		}
+
+		ch, wg := mirrorCh(solveCh)
+		defer wg.Wait()
+
+		solveOpts.Exports = []bkclient.ExportEntry{exportOpts}
+
+		_, err := bkClient.Build(ctx, solveOpts, "", func(ctx context.Context, gw bkgw.Client) (*bkgw.Result, error) {
+			return container.export(ctx, gw, platformVariants)
+		}, ch)
+		return err
+	}, container.Pipeline, container.Platform)
+}
+
 func (container *Container) baseExportOpts(
 	platformVariants []ContainerID,
 	forcedCompression ImageLayerCompression,
-) bkclient.ExportEntry {
+	compressionLevel int,
+	mediaTypes ImageMediaTypes,
+) (bkclient.ExportEntry, error) {
 	exportOpts := bkclient.ExportEntry{
 		Attrs: make(map[string]string),
 	}
@@ -1493,11 +1942,21 @@ func (container *Container) baseExportOpts(
 	}
 	// The behavior here is enforcing the default behavior present before
 	// a change in containerd: https://github.com/dagger/dagger/pull/5223#issuecomment-1569286964
-	if platformCount > 1 || forcedCompression == CompressionEStarGZ {
+	switch {
+	case mediaTypes == OCIMediaTypes:
+		exportOpts.Type = bkclient.ExporterOCI
+		exportOpts.Attrs["oci-mediatypes"] = strconv.FormatBool(true)
+	case mediaTypes == DockerMediaTypes:
+		if forcedCompression == CompressionEStarGZ {
+			return bkclient.ExportEntry{}, fmt.Errorf("%s compression requires %s", CompressionEStarGZ, OCIMediaTypes)
+		}
+		exportOpts.Type = bkclient.ExporterDocker
+		exportOpts.Attrs["oci-mediatypes"] = strconv.FormatBool(false)
+	case platformCount > 1 || forcedCompression == CompressionEStarGZ:
 		// multiplatform images must use OCI mediatypes
 		exportOpts.Type = bkclient.ExporterOCI
 		exportOpts.Attrs["oci-mediatypes"] = strconv.FormatBool(true)
-	} else {
+	default:
 		// single platform images currently default to Docker types, though tarballs
 		// still include an OCI index.json
 		exportOpts.Type = bkclient.ExporterDocker
@@ -1508,7 +1967,24 @@ func (container *Container) baseExportOpts(
 		exportOpts.Attrs["force-compression"] = strconv.FormatBool(true)
 	}
 
-	return exportOpts
+	for key, value := range container.Annotations {
+		exportOpts.Attrs["annotation-manifest."+key] = value
+		if platformCount > 1 {
+			exportOpts.Attrs["annotation-index."+key] = value
+		}
+	}
+
+	if compressionLevel != 0 {
+		switch forcedCompression {
+		case "":
+			return bkclient.ExportEntry{}, fmt.Errorf("compression level requires forced compression")
+		case CompressionUncompressed:
+			return bkclient.ExportEntry{}, fmt.Errorf("compression level is not supported with %s", CompressionUncompressed)
+		}
+		exportOpts.Attrs["compression-level"] = strconv.Itoa(compressionLevel)
+	}
+
+	return exportOpts, nil
 }
 
 const OCIStoreName = "dagger-oci"
@@ -1565,6 +2041,12 @@ func (container *Container) importUncached(
 		return nil, fmt.Errorf("image archive resolve index: %w", err)
 	}
 
+	return container.fromOCIStore(ctx, store, *manifestDesc)
+}
+
+// fromOCIStore sets the container's rootfs and config to those of the image
+// manifest in the OCI store.
+func (container *Container) fromOCIStore(ctx context.Context, store content.Store, manifestDesc specs.Descriptor) (*Container, error) {
 	// NB: the repository portion of this ref doesn't actually matter, but it's
 	// pleasant to see something recognizable.
 	dummyRepo := "dagger/import"
@@ -1581,8 +2063,9 @@ func (container *Container) importUncached(
 	}
 
 	container.FS = execDef.ToPB()
+	container.BaseFS = container.FS
 
-	manifestBlob, err := content.ReadBlob(ctx, store, *manifestDesc)
+	manifestBlob, err := content.ReadBlob(ctx, store, manifestDesc)
 	if err != nil {
 		return nil, fmt.Errorf("image archive read manifest blob: %w", err)
 	}
@@ -1593,6 +2076,14 @@ func (container *Container) importUncached(
 		return nil, fmt.Errorf("image archive unmarshal manifest: %w", err)
 	}
 
+	// make sure the image is self-contained, so that nothing is fetched from
+	// elsewhere (e.g. the URLs of foreign layers) once it's pulled
+	for _, layer := range man.Layers {
+		if _, err := store.Info(ctx, layer.Digest); err != nil {
+			return nil, fmt.Errorf("image archive layer %s: %w", layer.Digest, err)
+		}
+	}
+
 	configBlob, err := content.ReadBlob(ctx, store, man.Config)
 	if err != nil {
 		return nil, fmt.Errorf("image archive read image config blob %s: %w", man.Config.Digest, err)
@@ -1605,10 +2096,135 @@ func (container *Container) importUncached(
 	}
 
 	container.Config = imgSpec.Config
+	container.History = imgSpec.History
 
 	return container, nil
 }
 
+// ImportLayout reads the container from an OCI image layout directory on the
+// host, copying its blobs into the OCI store.
+func (container *Container) ImportLayout(
+	ctx context.Context,
+	host *Host,
+	src string,
+	tag string,
+	store content.Store,
+) (*Container, error) {
+	if host.DisableRW {
+		return nil, ErrHostRWDisabled
+	}
+
+	src, err := host.NormalizeDest(src)
+	if err != nil {
+		return nil, err
+	}
+
+	container = container.Clone()
+
+	indexBlob, err := os.ReadFile(filepath.Join(src, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read OCI layout index: %w", err)
+	}
+
+	blobsDir := filepath.Join(src, "blobs")
+	err = filepath.WalkDir(blobsDir, func(blobPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(blobsDir, blobPath)
+		if err != nil {
+			return err
+		}
+
+		// blobs/<algorithm>/<encoded>
+		dgst, err := digest.Parse(strings.Replace(filepath.ToSlash(rel), "/", ":", 1))
+		if err != nil {
+			// not a blob
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		blob, err := os.Open(blobPath)
+		if err != nil {
+			return err
+		}
+		defer blob.Close()
+
+		return content.WriteBlob(ctx, store, dgst.String(), blob, specs.Descriptor{
+			Digest: dgst,
+			Size:   info.Size(),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copy OCI layout blobs: %w", err)
+	}
+
+	// index.json isn't itself a blob; write it to the store so it can be
+	// resolved like any other index
+	indexDesc := specs.Descriptor{
+		MediaType: specs.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(indexBlob),
+		Size:      int64(len(indexBlob)),
+	}
+
+	err = content.WriteBlob(ctx, store, indexDesc.Digest.String(), bytes.NewReader(indexBlob), indexDesc)
+	if err != nil {
+		return nil, fmt.Errorf("write OCI layout index: %w", err)
+	}
+
+	manifestDesc, err := resolveIndex(ctx, store, indexDesc, container.Platform, tag)
+	if err != nil {
+		return nil, fmt.Errorf("OCI layout resolve index: %w", err)
+	}
+
+	return container.fromOCIStore(ctx, store, *manifestDesc)
+}
+
+// ExportLayout writes the container to an OCI image layout directory on the
+// host, for use with tools like skopeo and crane.
+func (container *Container) ExportLayout(
+	ctx context.Context,
+	host *Host,
+	dest string,
+	platformVariants []ContainerID,
+	forcedCompression ImageLayerCompression,
+	bkClient *bkclient.Client,
+	solveOpts bkclient.SolveOpt,
+	solveCh chan<- *bkclient.SolveStatus,
+) error {
+	dest, err := host.NormalizeDest(dest)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dest, 0o700); err != nil {
+		return err
+	}
+
+	exportOpts, err := container.baseExportOpts(platformVariants, forcedCompression, 0, OCIMediaTypes)
+	if err != nil {
+		return err
+	}
+	// layouts are an OCI format, so always use OCI mediatypes
+	exportOpts.Type = bkclient.ExporterOCI
+	exportOpts.Attrs["oci-mediatypes"] = strconv.FormatBool(true)
+	exportOpts.Attrs["tar"] = strconv.FormatBool(false)
+	exportOpts.OutputDir = dest
+
+	return host.Export(ctx, exportOpts, bkClient, solveOpts, solveCh, func(ctx context.Context, gw bkgw.Client) (*bkgw.Result, error) {
+		return container.export(ctx, gw, platformVariants)
+	})
+}
+
 func (container *Container) HostnameOrErr() (string, error) {
 	if container.Hostname == "" {
 		return "", ErrContainerNoExec
@@ -1667,6 +2283,77 @@ func (container *Container) WithExposedPort(port ContainerPort) (*Container, err
 	return container, nil
 }
 
+// WithDNS sets the resolver configuration for the container's execs.
+func (container *Container) WithDNS(cfg DNSConfig) (*Container, error) {
+	for _, ns := range cfg.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return nil, fmt.Errorf("invalid nameserver IP address: %q", ns)
+		}
+	}
+
+	container = container.Clone()
+	container.DNS = &cfg
+	return container, nil
+}
+
+// WithHostname sets the hostname seen by the container's execs. Its services
+// are still registered under the computed hostname, with the custom one only
+// added as an alias in the containers they're bound to, so services with the
+// same custom hostname don't collide.
+func (container *Container) WithHostname(hostname string) (*Container, error) {
+	if hostname == "" {
+		return nil, fmt.Errorf("hostname must not be empty")
+	}
+
+	container = container.Clone()
+	container.CustomHostname = hostname
+	return container, nil
+}
+
+// WithExtraHost adds an /etc/hosts entry for the container's execs.
+func (container *Container) WithExtraHost(hostname, ip string) (*Container, error) {
+	if hostname == "" {
+		return nil, fmt.Errorf("extra host name must not be empty")
+	}
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid IP address for extra host %q: %q", hostname, ip)
+	}
+
+	container = container.Clone()
+	container.ExtraHosts = append(container.ExtraHosts, ExtraHost{
+		Hostname: hostname,
+		IP:       ip,
+	})
+	return container, nil
+}
+
+// WithAnnotation sets an OCI annotation on the manifest of the image, and on
+// its index when it has one.
+func (container *Container) WithAnnotation(key, value string) (*Container, error) {
+	if key == "" {
+		return nil, fmt.Errorf("annotation key must not be empty")
+	}
+
+	container = container.Clone()
+	if container.Annotations == nil {
+		container.Annotations = map[string]string{}
+	}
+	container.Annotations[key] = value
+	return container, nil
+}
+
+// WithHealthcheckTimeout limits how long to wait for the container's ports
+// when it's started as a service.
+func (container *Container) WithHealthcheckTimeout(seconds int) (*Container, error) {
+	if seconds < 0 {
+		return nil, fmt.Errorf("healthcheck timeout must not be negative: %d", seconds)
+	}
+
+	container = container.Clone()
+	container.HealthcheckTimeout = seconds
+	return container, nil
+}
+
 func (container *Container) WithoutExposedPort(port int, protocol NetworkProtocol) (*Container, error) {
 	container = container.Clone()
 
@@ -1698,14 +2385,23 @@ func (container *Container) WithServiceBinding(svc *Container, alias string) (*C
 		svcID: AliasSet{alias},
 	})
 
-	if alias != "" {
+	names := []string{alias}
+	if svc.CustomHostname != alias {
+		names = append(names, svc.CustomHostname)
+	}
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
 		hn, err := svc.HostnameOrErr()
 		if err != nil {
 			return nil, fmt.Errorf("get hostname: %w", err)
 		}
 
 		container.HostAliases = append(container.HostAliases, HostAlias{
-			Alias:  alias,
+			Alias:  name,
 			Target: hn,
 		})
 	}
@@ -1769,7 +2465,8 @@ func (container *Container) export(
 					OSVersion:    exportContainer.Platform.OSVersion,
 					OSFeatures:   exportContainer.Platform.OSFeatures,
 				},
-				Config: exportContainer.Config,
+				Config:  exportContainer.Config,
+				History: exportContainer.History,
 			})
 			if err != nil {
 				return nil, err
@@ -1821,7 +2518,8 @@ func (container *Container) export(
 					OSVersion:    exportContainer.Platform.OSVersion,
 					OSFeatures:   exportContainer.Platform.OSFeatures,
 				},
-				Config: exportContainer.Config,
+				Config:  exportContainer.Config,
+				History: exportContainer.History,
 			})
 			if err != nil {
 				return nil, err
@@ -1886,6 +2584,60 @@ type ContainerExecOpts struct {
 
 	// Grant the process all root capabilities
 	InsecureRootCapabilities bool
+
+	// GPUs to make available to the process
+	GPU *GPURequest
+
+	// Kill the command if it's still running after this many seconds
+	Timeout int
+
+	// Maximum number of CPUs the process may use, e.g. 1.5
+	CPULimit float64
+
+	// Maximum amount of memory the process may use, in MiB
+	MemoryLimit int
+
+	// Record a non-zero exit code instead of failing
+	AllowFailure bool
+
+	// Run the command without network access, unless the container is bound
+	// to services or exposes ports, which are reached over the network. Set
+	// by the engine in offline mode; clients can't set it.
+	Offline bool `json:"-"`
+}
+
+// GPURequest requests GPU devices for an exec.
+type GPURequest struct {
+	// Number of GPUs to request; all GPUs if zero.
+	Count int `json:"count"`
+
+	// GPU vendor; only nvidia is supported.
+	Vendor string `json:"vendor"`
+}
+
+const gpuVendorNvidia = "nvidia"
+
+// shimValue encodes the request for the shim, which wires up the vendor's
+// runtime hook when setting up the container.
+func (req GPURequest) shimValue() (string, error) {
+	vendor := strings.ToLower(req.Vendor)
+	if vendor == "" {
+		vendor = gpuVendorNvidia
+	}
+
+	if vendor != gpuVendorNvidia {
+		return "", fmt.Errorf("unsupported GPU vendor %q", req.Vendor)
+	}
+
+	if req.Count < 0 {
+		return "", fmt.Errorf("invalid GPU count %d", req.Count)
+	}
+
+	if req.Count == 0 {
+		return vendor + "=all", nil
+	}
+
+	return vendor + "=" + strconv.Itoa(req.Count), nil
 }
 
 type BuildArg struct {
@@ -1974,3 +2726,13 @@ const (
 	CompressionEStarGZ      ImageLayerCompression = "EStarGZ"
 	CompressionUncompressed ImageLayerCompression = "Uncompressed"
 )
+
+// ImageMediaTypes selects the media types used for image manifests and
+// configs. If unset, Docker media types are used unless the image requires
+// OCI ones.
+type ImageMediaTypes string
+
+const (
+	OCIMediaTypes    ImageMediaTypes = "OCIMediaTypes"
+	DockerMediaTypes ImageMediaTypes = "DockerMediaTypes"
+)
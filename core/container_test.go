@@ -0,0 +1,296 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/dagger/dagger/core/gatewayrecord"
+	"github.com/dagger/dagger/core/gatewaytest"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerFrom(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {
+			Config: specs.ImageConfig{
+				Env:        []string{"PATH=/usr/bin:/bin"},
+				Cmd:        []string{"/bin/sh"},
+				WorkingDir: "/root",
+			},
+		},
+	})
+
+	ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+
+	ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+	require.NoError(t, err)
+	require.Contains(t, ctr.ImageRef, "docker.io/library/alpine:3.16@sha256:")
+	require.Equal(t, []string{"/bin/sh"}, ctr.Config.Cmd)
+	require.Equal(t, "/root", ctr.Config.WorkingDir)
+
+	ops, err := gatewaytest.Ops(ctr.FS)
+	require.NoError(t, err)
+	require.Equal(t, "docker-image://"+ctr.ImageRef, ops[0].GetSource().GetIdentifier())
+
+	_, err = ctr.From(ctx, gw, "alpine:3.17")
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestContainerWithExecOffline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {},
+	})
+
+	ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+
+	ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+	require.NoError(t, err)
+
+	network := func(ctr *Container, offline bool) pb.NetMode {
+		execCtr, err := ctr.WithExec(ctx, gw, &Socket{}, ctr.Platform, ContainerExecOpts{
+			Args:    []string{"wget", "https://dagger.io"},
+			Offline: offline,
+		})
+		require.NoError(t, err)
+
+		op, err := rootExec(execCtr.FS)
+		require.NoError(t, err)
+
+		return op.GetExec().Network
+	}
+
+	require.Equal(t, pb.NetMode_UNSET, network(ctr, false))
+	require.Equal(t, pb.NetMode_NONE, network(ctr, true))
+
+	// services are reached over the network
+	svc, err := ctr.WithExposedPort(ContainerPort{Port: 8080, Protocol: NetworkProtocolTCP})
+	require.NoError(t, err)
+	require.Equal(t, pb.NetMode_UNSET, network(svc, true))
+}
+
+func TestContainerWithHostnameServices(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {},
+	})
+
+	ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+
+	ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithHostname("db")
+	require.NoError(t, err)
+
+	service := func(args ...string) *Container {
+		svc, err := ctr.WithExec(ctx, gw, &Socket{}, ctr.Platform, ContainerExecOpts{Args: args})
+		require.NoError(t, err)
+
+		op, err := rootExec(svc.FS)
+		require.NoError(t, err)
+
+		// the exec is registered under the computed hostname, and sees the
+		// custom one
+		require.Equal(t, svc.Hostname, op.GetExec().GetMeta().GetHostname())
+		require.Contains(t, op.GetExec().GetMeta().GetEnv(), "_DAGGER_HOSTNAME=db")
+
+		return svc
+	}
+
+	primary := service("postgres")
+	replica := service("postgres", "--replica")
+	require.NotEqual(t, primary.Hostname, replica.Hostname)
+
+	// each client resolves the custom hostname to the service it's bound to
+	for _, svc := range []*Container{primary, replica} {
+		client, err := ctr.WithServiceBinding(svc, "")
+		require.NoError(t, err)
+		require.Equal(t, []HostAlias{{Alias: "db", Target: svc.Hostname}}, client.HostAliases)
+
+		client, err = ctr.WithServiceBinding(svc, "db")
+		require.NoError(t, err)
+		require.Len(t, client.HostAliases, 1)
+	}
+}
+
+func TestContainerStdoutReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {},
+	})
+	gw.Solver = gatewaytest.WithFiles(fstest.MapFS{
+		"meta/stdout": {Data: []byte("hello\n")},
+		"meta/secret": {Data: []byte("hunter2")},
+	})
+
+	exercise := func(gw bkgw.Client) (string, error) {
+		ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+		require.NoError(t, err)
+
+		ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+		require.NoError(t, err)
+
+		ctr, err = ctr.WithExec(ctx, gw, &Socket{}, ctr.Platform, ContainerExecOpts{
+			Args: []string{"echo", "hello"},
+		})
+		require.NoError(t, err)
+
+		stdout, err := ctr.MetaFileContents(ctx, gw, &Socket{}, "stdout")
+		require.NoError(t, err)
+
+		_, err = ctr.MetaFileContents(ctx, gw, &Socket{}, "secret")
+		return stdout, err
+	}
+
+	rec := gatewayrecord.NewRecorder(gw)
+	rec.RecordContents(gatewayrecord.ExecOutput)
+
+	stdout, err := exercise(rec)
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", stdout)
+
+	recording := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, rec.Save(recording))
+
+	payload, err := os.ReadFile(recording)
+	require.NoError(t, err)
+	require.NotContains(t, string(payload), "hunter2")
+
+	rep, err := gatewaytest.LoadReplayer(recording)
+	require.NoError(t, err)
+
+	stdout, err = exercise(rep)
+	require.ErrorIs(t, err, gatewaytest.ErrContentsNotRecorded)
+	require.Equal(t, "hello\n", stdout)
+}
+
+func TestContainerFromLocal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	writeBlob := func(mediaType string, v any) specs.Descriptor {
+		blob, ok := v.([]byte)
+		if !ok {
+			blob, err = json.Marshal(v)
+			require.NoError(t, err)
+		}
+		desc := specs.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		require.NoError(t, content.WriteBlob(ctx, store, desc.Digest.String(), bytes.NewReader(blob), desc))
+		return desc
+	}
+
+	config := writeBlob(specs.MediaTypeImageConfig, specs.Image{
+		Config: specs.ImageConfig{Cmd: []string{"/bin/sh"}},
+	})
+	layer := writeBlob(specs.MediaTypeImageLayerGzip, []byte("not really a layer"))
+	manifest := writeBlob(specs.MediaTypeImageManifest, specs.Manifest{
+		MediaType: specs.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []specs.Descriptor{layer},
+	})
+
+	// a layer that was never written to the store
+	missing := specs.Descriptor{
+		MediaType: specs.MediaTypeImageLayerGzip,
+		Digest:    digest.FromString("missing"),
+		Size:      7,
+	}
+	incomplete := writeBlob(specs.MediaTypeImageManifest, specs.Manifest{
+		MediaType: specs.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []specs.Descriptor{missing},
+	})
+
+	ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+
+	t.Run("preloaded", func(t *testing.T) {
+		t.Parallel()
+
+		ref := "alpine@" + manifest.Digest.String()
+		loaded, err := ctr.FromLocal(ctx, store, ref)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/bin/sh"}, loaded.Config.Cmd)
+		require.Equal(t, "docker.io/library/"+ref, loaded.ImageRef)
+
+		ops, err := gatewaytest.Ops(loaded.FS)
+		require.NoError(t, err)
+		require.Contains(t, ops[0].GetSource().GetIdentifier(), "oci-layout://")
+		require.Contains(t, ops[0].GetSource().GetIdentifier(), manifest.Digest.String())
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ctr.FromLocal(ctx, store, "alpine:3.16")
+		require.ErrorIs(t, err, ErrOffline)
+		require.ErrorContains(t, err, "pinned by digest")
+	})
+
+	t.Run("not preloaded", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ctr.FromLocal(ctx, store, "alpine@"+digest.FromString("uncached").String())
+		require.ErrorIs(t, err, ErrOffline)
+		require.ErrorContains(t, err, "has not been preloaded")
+	})
+
+	t.Run("missing layer", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ctr.FromLocal(ctx, store, "alpine@"+incomplete.Digest.String())
+		require.ErrorContains(t, err, missing.Digest.String())
+	})
+}
+
+func TestAbsPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		workdir, path, expected string
+	}{
+		{"", "foo", "/foo"},
+		{"/app", "/usr", "/usr"},
+		{"/app", "src", "/app/src"},
+		{"/app", "./src/../bin", "/app/bin"},
+		{"/app", "..", "/"},
+	} {
+		require.Equal(t, tc.expected, AbsPath(tc.workdir, tc.path), "%s in %s", tc.path, tc.workdir)
+	}
+}
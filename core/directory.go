@@ -16,6 +16,7 @@ import (
 	"github.com/moby/buildkit/client/llb"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/patternmatcher"
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
@@ -234,6 +235,165 @@ func (dir *Directory) Entries(ctx context.Context, gw bkgw.Client, src string) (
 	})
 }
 
+// Evaluate solves the directory, surfacing any errors building it, such as
+// missing paths.
+func (dir *Directory) Evaluate(ctx context.Context, gw bkgw.Client) error {
+	if dir.LLB == nil {
+		return nil
+	}
+
+	_, err := WithServices(ctx, gw, dir.Services, func() (*bkgw.Result, error) {
+		return gw.Solve(ctx, bkgw.SolveRequest{
+			Evaluate:   true,
+			Definition: dir.LLB,
+		})
+	})
+	return err
+}
+
+// Glob returns the paths in the directory that match the given pattern,
+// recursively. Patterns follow .dockerignore syntax, so ** matches any number
+// of directories (e.g. "dist/**/*.dll").
+func (dir *Directory) Glob(ctx context.Context, gw bkgw.Client, pattern string) ([]string, error) {
+	pm, err := patternmatcher.New([]string{pattern})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return WithServices(ctx, gw, dir.Services, func() ([]string, error) {
+		res, err := gw.Solve(ctx, bkgw.SolveRequest{
+			Definition: dir.LLB,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+		// empty directory, i.e. llb.Scratch()
+		if ref == nil {
+			return []string{}, nil
+		}
+
+		matches := []string{}
+		err = walkRef(ctx, ref, dir.Dir, func(entryPath string, _ *fstypes.Stat) error {
+			// match the path itself, not paths under a matching directory
+			match, err := pm.MatchesUsingParentResult(entryPath, false) //nolint:staticcheck // SA1019 exact for a single pattern
+			if err != nil {
+				return err
+			}
+			if match {
+				matches = append(matches, entryPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return matches, nil
+	})
+}
+
+// ContentDigest returns a digest of the directory's contents: the paths,
+// modes, ownership and contents of every file in it. Unlike Digest, it only
+// changes when the contents do, regardless of how the directory was built.
+// Timestamps are not included.
+func (dir *Directory) ContentDigest(ctx context.Context, gw bkgw.Client) (digest.Digest, error) {
+	return WithServices(ctx, gw, dir.Services, func() (digest.Digest, error) {
+		res, err := gw.Solve(ctx, bkgw.SolveRequest{
+			Definition: dir.LLB,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		ref, err := res.SingleRef()
+		if err != nil {
+			return "", err
+		}
+
+		digester := digest.Canonical.Digester()
+		h := digester.Hash()
+
+		// empty directory, i.e. llb.Scratch()
+		if ref == nil {
+			return digester.Digest(), nil
+		}
+
+		err = walkRef(ctx, ref, dir.Dir, func(entryPath string, stat *fstypes.Stat) error {
+			mode := fs.FileMode(stat.GetMode())
+			fmt.Fprintf(h, "%s\x00%s\x00%d:%d\x00", entryPath, mode, stat.GetUid(), stat.GetGid())
+
+			switch {
+			case mode&fs.ModeSymlink != 0:
+				fmt.Fprintf(h, "%s\x00", stat.GetLinkname())
+			case mode.IsRegular():
+				// read in chunks, so as not to hold large files in memory
+				for offset := 0; offset < int(stat.GetSize_()); {
+					chunk, err := ref.ReadFile(ctx, bkgw.ReadRequest{
+						Filename: path.Join(dir.Dir, entryPath),
+						Range: &bkgw.FileRange{
+							Offset: offset,
+							Length: MaxFileContentsChunkSize,
+						},
+					})
+					if err != nil {
+						return err
+					}
+					if len(chunk) == 0 {
+						break
+					}
+
+					h.Write(chunk)
+					offset += len(chunk)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return digester.Digest(), nil
+	})
+}
+
+// walkRef calls fn for every entry beneath root in the reference, recursively,
+// with paths relative to root.
+func walkRef(ctx context.Context, ref bkgw.Reference, root string, fn func(string, *fstypes.Stat) error) error {
+	var walk func(sub string) error
+	walk = func(sub string) error {
+		entries, err := ref.ReadDir(ctx, bkgw.ReadDirRequest{
+			Path: path.Join(root, sub),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			entryPath := path.Join(sub, entry.GetPath())
+
+			if err := fn(entryPath, entry); err != nil {
+				return err
+			}
+
+			if fs.FileMode(entry.GetMode()).IsDir() {
+				if err := walk(entryPath); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return walk("")
+}
+
 func (dir *Directory) WithNewFile(ctx context.Context, dest string, content []byte, permissions fs.FileMode, ownership *Ownership) (*Directory, error) {
 	dir = dir.Clone()
 
@@ -516,33 +676,37 @@ func (dir *Directory) Export(
 	return host.Export(ctx, bkclient.ExportEntry{
 		Type:      bkclient.ExporterLocal,
 		OutputDir: dest,
-	}, bkClient, solveOpts, solveCh, func(ctx context.Context, gw bkgw.Client) (*bkgw.Result, error) {
-		return WithServices(ctx, gw, dir.Services, func() (*bkgw.Result, error) {
-			src, err := dir.State()
-			if err != nil {
-				return nil, err
-			}
+	}, bkClient, solveOpts, solveCh, dir.solveContents)
+}
 
-			var defPB *pb.Definition
-			if dir.Dir != "" {
-				src = llb.Scratch().File(llb.Copy(src, dir.Dir, ".", &llb.CopyInfo{
-					CopyDirContentsOnly: true,
-				}))
+// solveContents solves the directory for exporting, with its contents at the
+// root of the result.
+func (dir *Directory) solveContents(ctx context.Context, gw bkgw.Client) (*bkgw.Result, error) {
+	return WithServices(ctx, gw, dir.Services, func() (*bkgw.Result, error) {
+		src, err := dir.State()
+		if err != nil {
+			return nil, err
+		}
 
-				def, err := src.Marshal(ctx, llb.Platform(dir.Platform))
-				if err != nil {
-					return nil, err
-				}
+		var defPB *pb.Definition
+		if dir.Dir != "" {
+			src = llb.Scratch().File(llb.Copy(src, dir.Dir, ".", &llb.CopyInfo{
+				CopyDirContentsOnly: true,
+			}))
 
-				defPB = def.ToPB()
-			} else {
-				defPB = dir.LLB
+			def, err := src.Marshal(ctx, llb.Platform(dir.Platform))
+			if err != nil {
+				return nil, err
 			}
 
-			return gw.Solve(ctx, bkgw.SolveRequest{
-				Evaluate:   true,
-				Definition: defPB,
-			})
+			defPB = def.ToPB()
+		} else {
+			defPB = dir.LLB
+		}
+
+		return gw.Solve(ctx, bkgw.SolveRequest{
+			Evaluate:   true,
+			Definition: defPB,
 		})
 	})
 }
@@ -6,6 +6,8 @@ import (
 
 var ErrHostRWDisabled = errors.New("host read/write is disabled")
 
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
 var ErrContainerNoExec = errors.New("no command has been executed")
 
 // ExecError is an error that occurred while executing an `Op_Exec`.
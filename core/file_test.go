@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dagger/dagger/core/gatewaytest"
+	"github.com/moby/buildkit/client/llb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileContents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(nil)
+	gw.Solver = gatewaytest.WithFiles(fstest.MapFS{
+		"src/hello.txt": {Data: []byte("hello, world!")},
+	})
+
+	file, err := NewFileSt(ctx, llb.Image("alpine"), "/src/hello.txt", nil, specs.Platform{OS: "linux", Architecture: "amd64"}, nil)
+	require.NoError(t, err)
+
+	contents, err := file.Contents(ctx, gw)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world!", string(contents))
+	require.NotEmpty(t, gw.Solves())
+
+	dir, err := NewDirectorySt(ctx, llb.Image("alpine"), "/src", nil, specs.Platform{OS: "linux", Architecture: "amd64"}, nil)
+	require.NoError(t, err)
+
+	entries, err := dir.Entries(ctx, gw, ".")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello.txt"}, entries)
+}
@@ -96,6 +96,21 @@ func (g *GatewayClient) Solve(ctx context.Context, req bkgw.SolveRequest) (_ *bk
 	return res, nil
 }
 
+// NewContainer unwraps the refs returned by Solve, since the inner client only
+// accepts its own.
+func (g *GatewayClient) NewContainer(ctx context.Context, req bkgw.NewContainerRequest) (bkgw.Container, error) {
+	mounts := make([]bkgw.Mount, len(req.Mounts))
+	for i, mnt := range req.Mounts {
+		if r, ok := mnt.Ref.(*ref); ok {
+			mnt.Ref = r.Reference
+		}
+		mounts[i] = mnt
+	}
+	req.Mounts = mounts
+
+	return g.Client.NewContainer(ctx, req)
+}
+
 // CombinedResult returns a buildkit result with all the refs solved by this client so far.
 // This is useful for constructing a result for remote caching.
 func (g *GatewayClient) CombinedResult(ctx context.Context) (*bkgw.Result, error) {
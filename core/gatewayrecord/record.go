@@ -0,0 +1,261 @@
+// Package gatewayrecord records a session's interactions with the buildkit
+// gateway, so that they can be replayed in unit tests by core/gatewaytest.
+//
+// Recordings only capture metadata: the contents of files read through the
+// gateway, which may include secrets, are recorded as a digest and size,
+// unless they're explicitly allowed with Recorder.RecordContents.
+package gatewayrecord
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// Interaction is a single request to a gateway client and its response.
+type Interaction struct {
+	Method string `json:"method"`
+
+	// Digest of the solved definition, for Solve and reference methods.
+	Definition digest.Digest `json:"definition,omitempty"`
+
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+const (
+	MethodSolve              = "Solve"
+	MethodResolveImageConfig = "ResolveImageConfig"
+	MethodReadFile           = "ReadFile"
+	MethodStatFile           = "StatFile"
+	MethodReadDir            = "ReadDir"
+	MethodEvaluate           = "Evaluate"
+)
+
+type ResolveImageConfigRequest struct {
+	Ref         string `json:"ref"`
+	Platform    string `json:"platform,omitempty"`
+	ResolveMode string `json:"resolveMode,omitempty"`
+}
+
+type ResolveImageConfigResponse struct {
+	Digest digest.Digest   `json:"digest"`
+	Config json.RawMessage `json:"config"`
+}
+
+type SolveResponse struct {
+	HasRef   bool              `json:"hasRef,omitempty"`
+	Refs     []string          `json:"refs,omitempty"`
+	Metadata map[string][]byte `json:"metadata,omitempty"`
+}
+
+// ReadFileResponse identifies the contents that were read. The contents
+// themselves are only included for files allowed by Recorder.RecordContents.
+type ReadFileResponse struct {
+	Digest digest.Digest `json:"digest"`
+	Size   int           `json:"size"`
+
+	HasContents bool   `json:"hasContents,omitempty"`
+	Contents    []byte `json:"contents,omitempty"`
+}
+
+// ExecOutput matches the files that an exec's stdout, stderr and exit code
+// are read from, relative to its metadata mount. Secrets are scrubbed from
+// exec output by the shim, so it's safe to record.
+func ExecOutput(filename string) bool {
+	switch filename {
+	case "meta/stdout", "meta/stderr", "meta/exitCode":
+		return true
+	default:
+		return false
+	}
+}
+
+// DefinitionDigest identifies a definition across recordings.
+func DefinitionDigest(def *pb.Definition) digest.Digest {
+	if def == nil || len(def.Def) == 0 {
+		return ""
+	}
+
+	// the last op is the definition's output, and its digest covers all of
+	// its inputs
+	return digest.FromBytes(def.Def[len(def.Def)-1])
+}
+
+// ImageConfigRequest returns the recorded form of a ResolveImageConfig
+// request.
+func ImageConfigRequest(ref string, opt llb.ResolveImageConfigOpt) ResolveImageConfigRequest {
+	req := ResolveImageConfigRequest{
+		Ref:         ref,
+		ResolveMode: opt.ResolveMode,
+	}
+
+	if opt.Platform != nil {
+		req.Platform = opt.Platform.OS + "/" + opt.Platform.Architecture
+		if opt.Platform.Variant != "" {
+			req.Platform += "/" + opt.Platform.Variant
+		}
+	}
+
+	return req
+}
+
+// Recorder is a bkgw.Client which records every interaction with the client
+// it wraps, along with the references it returns.
+type Recorder struct {
+	bkgw.Client
+
+	mu           sync.Mutex
+	interactions []Interaction
+	contents     func(filename string) bool
+}
+
+var _ bkgw.Client = &Recorder{}
+
+func NewRecorder(client bkgw.Client) *Recorder {
+	return &Recorder{Client: client}
+}
+
+// RecordContents records the contents of files read through the gateway
+// whose names are matched by allow, e.g. ExecOutput, so that they can be
+// replayed too.
+func (r *Recorder) RecordContents(allow func(filename string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.contents = allow
+}
+
+// Interactions returns every interaction recorded so far, in order.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction{}, r.interactions...)
+}
+
+// Save writes the recorded interactions to the given path as JSON.
+func (r *Recorder) Save(path string) error {
+	payload, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, payload, 0o600)
+}
+
+func (r *Recorder) record(method string, def digest.Digest, req, res any, err error) {
+	i := Interaction{
+		Method:     method,
+		Definition: def,
+	}
+
+	if req != nil {
+		i.Request, _ = json.Marshal(req)
+	}
+
+	if err != nil {
+		i.Error = err.Error()
+	} else if res != nil {
+		i.Response, _ = json.Marshal(res)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, i)
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Solve(ctx context.Context, req bkgw.SolveRequest) (*bkgw.Result, error) {
+	res, err := r.Client.Solve(ctx, req)
+
+	dgst := DefinitionDigest(req.Definition)
+	if dgst == "" {
+		// frontend solves, exports, etc. can't be replayed
+		return res, err
+	}
+
+	if err != nil {
+		r.record(MethodSolve, dgst, nil, nil, err)
+		return nil, err
+	}
+
+	recorded := SolveResponse{Metadata: res.Metadata}
+
+	if res.Ref != nil {
+		recorded.HasRef = true
+		res.Ref = &recordingRef{Reference: res.Ref, def: dgst, rec: r}
+	}
+
+	for k, ref := range res.Refs {
+		recorded.Refs = append(recorded.Refs, k)
+		if ref != nil {
+			res.Refs[k] = &recordingRef{Reference: ref, def: dgst, rec: r}
+		}
+	}
+
+	r.record(MethodSolve, dgst, nil, recorded, nil)
+
+	return res, nil
+}
+
+func (r *Recorder) ResolveImageConfig(ctx context.Context, ref string, opt llb.ResolveImageConfigOpt) (digest.Digest, []byte, error) {
+	dgst, cfg, err := r.Client.ResolveImageConfig(ctx, ref, opt)
+	r.record(MethodResolveImageConfig, "", ImageConfigRequest(ref, opt), ResolveImageConfigResponse{
+		Digest: dgst,
+		Config: cfg,
+	}, err)
+	return dgst, cfg, err
+}
+
+type recordingRef struct {
+	bkgw.Reference
+
+	def digest.Digest
+	rec *Recorder
+}
+
+func (r *recordingRef) ReadFile(ctx context.Context, req bkgw.ReadRequest) ([]byte, error) {
+	content, err := r.Reference.ReadFile(ctx, req)
+
+	res := ReadFileResponse{
+		Digest: digest.FromBytes(content),
+		Size:   len(content),
+	}
+
+	r.rec.mu.Lock()
+	allow := r.rec.contents
+	r.rec.mu.Unlock()
+
+	if allow != nil && allow(req.Filename) {
+		res.HasContents = true
+		res.Contents = content
+	}
+
+	r.rec.record(MethodReadFile, r.def, req, res, err)
+	return content, err
+}
+
+func (r *recordingRef) StatFile(ctx context.Context, req bkgw.StatRequest) (*fstypes.Stat, error) {
+	stat, err := r.Reference.StatFile(ctx, req)
+	r.rec.record(MethodStatFile, r.def, req, stat, err)
+	return stat, err
+}
+
+func (r *recordingRef) ReadDir(ctx context.Context, req bkgw.ReadDirRequest) ([]*fstypes.Stat, error) {
+	stats, err := r.Reference.ReadDir(ctx, req)
+	r.rec.record(MethodReadDir, r.def, req, stats, err)
+	return stats, err
+}
+
+func (r *recordingRef) Evaluate(ctx context.Context) error {
+	err := r.Reference.Evaluate(ctx)
+	r.rec.record(MethodEvaluate, r.def, nil, nil, err)
+	return err
+}
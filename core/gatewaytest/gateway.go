@@ -0,0 +1,357 @@
+// Package gatewaytest provides an in-memory buildkit gateway client, so that
+// core APIs can be unit tested without a running engine.
+//
+// Definitions are never actually executed. Instead, each Solve is recorded
+// and answered with a filesystem returned by the client's Solver, which
+// defaults to an empty directory. Likewise, containers are recorded, and the
+// processes started in them exit right away.
+package gatewaytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing/fstest"
+
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// SessionID is the session ID reported by the client's BuildOpts.
+const SessionID = "gatewaytest"
+
+// ErrNotSupported is returned by operations which require a real engine.
+var ErrNotSupported = errors.New("not supported by gatewaytest")
+
+// Solver returns the filesystem that results from solving a definition.
+type Solver func(ctx context.Context, def *pb.Definition) (fs.FS, error)
+
+// Client is a fake bkgw.Client.
+type Client struct {
+	// Images maps image references (e.g. docker.io/library/alpine:latest) to
+	// their configs, for ResolveImageConfig.
+	Images map[string]specs.Image
+
+	// Solver is called for each Solve with a definition. If nil, every
+	// definition solves to an empty directory.
+	Solver Solver
+
+	mu         sync.Mutex
+	solves     []*pb.Definition
+	containers []*Container
+	warnings   []string
+}
+
+var _ bkgw.Client = &Client{}
+
+// New returns a client which resolves the given images.
+func New(images map[string]specs.Image) *Client {
+	return &Client{Images: images}
+}
+
+// WithFiles returns a Solver which solves every definition to the given
+// files.
+func WithFiles(files fstest.MapFS) Solver {
+	return func(context.Context, *pb.Definition) (fs.FS, error) {
+		return files, nil
+	}
+}
+
+func (c *Client) Solve(ctx context.Context, req bkgw.SolveRequest) (*bkgw.Result, error) {
+	res := bkgw.NewResult()
+
+	if req.Definition == nil || req.Definition.Def == nil {
+		// nothing to solve, i.e. llb.Scratch()
+		return res, nil
+	}
+
+	c.mu.Lock()
+	c.solves = append(c.solves, req.Definition)
+	c.mu.Unlock()
+
+	var fsys fs.FS = fstest.MapFS{}
+	if c.Solver != nil {
+		var err error
+		fsys, err = c.Solver(ctx, req.Definition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res.SetRef(&Ref{FS: fsys, Def: req.Definition})
+
+	return res, nil
+}
+
+func (c *Client) ResolveImageConfig(ctx context.Context, ref string, opt llb.ResolveImageConfigOpt) (digest.Digest, []byte, error) {
+	img, found := c.Images[ref]
+	if !found {
+		return "", nil, fmt.Errorf("%s: not found", ref)
+	}
+
+	cfg, err := json.Marshal(img)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return digest.FromBytes(cfg), cfg, nil
+}
+
+func (c *Client) BuildOpts() bkgw.BuildOpts {
+	return bkgw.BuildOpts{
+		SessionID: SessionID,
+		Product:   "gatewaytest",
+	}
+}
+
+func (c *Client) Inputs(ctx context.Context) (map[string]llb.State, error) {
+	return map[string]llb.State{}, nil
+}
+
+func (c *Client) NewContainer(ctx context.Context, req bkgw.NewContainerRequest) (bkgw.Container, error) {
+	ctr := &Container{Request: req}
+
+	c.mu.Lock()
+	c.containers = append(c.containers, ctr)
+	c.mu.Unlock()
+
+	return ctr, nil
+}
+
+func (c *Client) Warn(ctx context.Context, dgst digest.Digest, msg string, opts bkgw.WarnOpts) error {
+	c.mu.Lock()
+	c.warnings = append(c.warnings, msg)
+	c.mu.Unlock()
+	return nil
+}
+
+// Solves returns every definition solved so far, in order.
+func (c *Client) Solves() []*pb.Definition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*pb.Definition{}, c.solves...)
+}
+
+// Containers returns every container created so far, in order.
+func (c *Client) Containers() []*Container {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Container{}, c.containers...)
+}
+
+// Warnings returns every warning emitted so far, in order.
+func (c *Client) Warnings() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.warnings...)
+}
+
+// Execs returns every exec op in the definitions solved so far.
+func (c *Client) Execs() ([]*pb.ExecOp, error) {
+	execs := []*pb.ExecOp{}
+	for _, def := range c.Solves() {
+		ops, err := Ops(def)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, op := range ops {
+			if exec := op.GetExec(); exec != nil {
+				execs = append(execs, exec)
+			}
+		}
+	}
+
+	return execs, nil
+}
+
+// Ops decodes each op in a definition.
+func Ops(def *pb.Definition) ([]*pb.Op, error) {
+	ops := make([]*pb.Op, 0, len(def.Def))
+	for _, dt := range def.Def {
+		var op pb.Op
+		if err := (&op).Unmarshal(dt); err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, &op)
+	}
+
+	return ops, nil
+}
+
+// Container is a bkgw.Container whose processes exit as soon as they start.
+type Container struct {
+	Request bkgw.NewContainerRequest
+
+	mu       sync.Mutex
+	starts   []bkgw.StartRequest
+	released bool
+}
+
+var _ bkgw.Container = &Container{}
+
+func (c *Container) Start(ctx context.Context, req bkgw.StartRequest) (bkgw.ContainerProcess, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.released {
+		return nil, errors.New("container released")
+	}
+
+	c.starts = append(c.starts, req)
+
+	return process{}, nil
+}
+
+func (c *Container) Release(ctx context.Context) error {
+	c.mu.Lock()
+	c.released = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Starts returns every process started in the container so far, in order.
+func (c *Container) Starts() []bkgw.StartRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]bkgw.StartRequest{}, c.starts...)
+}
+
+// Released returns whether the container has been released.
+func (c *Container) Released() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.released
+}
+
+type process struct{}
+
+func (process) Wait() error {
+	return nil
+}
+
+func (process) Resize(ctx context.Context, size bkgw.WinSize) error {
+	return nil
+}
+
+func (process) Signal(ctx context.Context, sig syscall.Signal) error {
+	return nil
+}
+
+// Ref is a bkgw.Reference backed by a filesystem.
+type Ref struct {
+	FS  fs.FS
+	Def *pb.Definition
+}
+
+var _ bkgw.Reference = &Ref{}
+
+func (r *Ref) ToState() (llb.State, error) {
+	op, err := llb.NewDefinitionOp(r.Def)
+	if err != nil {
+		return llb.State{}, err
+	}
+
+	return llb.NewState(op), nil
+}
+
+func (r *Ref) Evaluate(ctx context.Context) error {
+	return nil
+}
+
+func (r *Ref) ReadFile(ctx context.Context, req bkgw.ReadRequest) ([]byte, error) {
+	content, err := fs.ReadFile(r.FS, fsPath(req.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Range != nil {
+		start := req.Range.Offset
+		if start > len(content) {
+			start = len(content)
+		}
+
+		end := start + req.Range.Length
+		if end > len(content) {
+			end = len(content)
+		}
+
+		content = content[start:end]
+	}
+
+	return content, nil
+}
+
+func (r *Ref) StatFile(ctx context.Context, req bkgw.StatRequest) (*fstypes.Stat, error) {
+	info, err := fs.Stat(r.FS, fsPath(req.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	return toStat(req.Path, info), nil
+}
+
+func (r *Ref) ReadDir(ctx context.Context, req bkgw.ReadDirRequest) ([]*fstypes.Stat, error) {
+	entries, err := fs.ReadDir(r.FS, fsPath(req.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []*fstypes.Stat{}
+	for _, entry := range entries {
+		if req.IncludePattern != "" {
+			match, err := filepath.Match(req.IncludePattern, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+
+			if !match {
+				continue
+			}
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, toStat(entry.Name(), info))
+	}
+
+	return stats, nil
+}
+
+// fsPath converts a path in a reference to one accepted by io/fs.
+func fsPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+func toStat(p string, info fs.FileInfo) *fstypes.Stat {
+	mode := uint32(info.Mode().Perm())
+	if info.IsDir() {
+		mode |= uint32(fs.ModeDir)
+	}
+
+	return &fstypes.Stat{
+		Path:    p,
+		Mode:    mode,
+		Size_:   info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+	}
+}
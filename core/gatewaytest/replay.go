@@ -0,0 +1,190 @@
+package gatewaytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dagger/dagger/core/gatewayrecord"
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// ErrNotRecorded is returned by a Replayer for requests that were not
+// recorded.
+var ErrNotRecorded = errors.New("interaction was not recorded")
+
+// ErrContentsNotRecorded is returned by a Replayer when reading a file whose
+// contents weren't recorded; see gatewayrecord.Recorder.RecordContents.
+var ErrContentsNotRecorded = errors.New("file contents are not recorded")
+
+// Replayer is a bkgw.Client which responds to requests with the interactions
+// captured by a gatewayrecord.Recorder, without a running engine.
+//
+// Requests are matched by their method, definition, and parameters; the order
+// in which they are made doesn't matter.
+type Replayer struct {
+	interactions map[string]gatewayrecord.Interaction
+}
+
+var _ bkgw.Client = &Replayer{}
+
+func NewReplayer(interactions []gatewayrecord.Interaction) *Replayer {
+	r := &Replayer{
+		interactions: map[string]gatewayrecord.Interaction{},
+	}
+
+	for _, i := range interactions {
+		r.interactions[interactionKey(i.Method, i.Definition, i.Request)] = i
+	}
+
+	return r
+}
+
+// LoadReplayer reads interactions saved by a gatewayrecord.Recorder.
+func LoadReplayer(path string) (*Replayer, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []gatewayrecord.Interaction
+	if err := json.Unmarshal(payload, &interactions); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return NewReplayer(interactions), nil
+}
+
+func interactionKey(method string, def digest.Digest, req json.RawMessage) string {
+	// saved recordings are indented, so compare requests in compact form
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, req); err != nil {
+		compact.Write(req)
+	}
+
+	return method + "\x00" + def.String() + "\x00" + compact.String()
+}
+
+func (r *Replayer) replay(method string, def digest.Digest, req, res any) error {
+	var reqPayload json.RawMessage
+	if req != nil {
+		var err error
+		reqPayload, err = json.Marshal(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	i, found := r.interactions[interactionKey(method, def, reqPayload)]
+	if !found {
+		return fmt.Errorf("%s %s %s: %w", method, def, reqPayload, ErrNotRecorded)
+	}
+
+	if i.Error != "" {
+		return errors.New(i.Error)
+	}
+
+	if res != nil && i.Response != nil {
+		return json.Unmarshal(i.Response, res)
+	}
+
+	return nil
+}
+
+func (r *Replayer) Solve(ctx context.Context, req bkgw.SolveRequest) (*bkgw.Result, error) {
+	dgst := gatewayrecord.DefinitionDigest(req.Definition)
+	if dgst == "" {
+		return nil, fmt.Errorf("only solving definitions can be replayed: %w", ErrNotSupported)
+	}
+
+	var recorded gatewayrecord.SolveResponse
+	if err := r.replay(gatewayrecord.MethodSolve, dgst, nil, &recorded); err != nil {
+		return nil, err
+	}
+
+	res := bkgw.NewResult()
+	res.Metadata = recorded.Metadata
+
+	if recorded.HasRef {
+		res.SetRef(&replayRef{def: req.Definition, rep: r})
+	}
+
+	for _, k := range recorded.Refs {
+		res.AddRef(k, &replayRef{def: req.Definition, rep: r})
+	}
+
+	return res, nil
+}
+
+func (r *Replayer) ResolveImageConfig(ctx context.Context, ref string, opt llb.ResolveImageConfigOpt) (digest.Digest, []byte, error) {
+	var recorded gatewayrecord.ResolveImageConfigResponse
+	if err := r.replay(gatewayrecord.MethodResolveImageConfig, "", gatewayrecord.ImageConfigRequest(ref, opt), &recorded); err != nil {
+		return "", nil, err
+	}
+
+	return recorded.Digest, recorded.Config, nil
+}
+
+func (r *Replayer) BuildOpts() bkgw.BuildOpts {
+	return bkgw.BuildOpts{
+		SessionID: SessionID,
+		Product:   "gatewaytest",
+	}
+}
+
+func (r *Replayer) Inputs(ctx context.Context) (map[string]llb.State, error) {
+	return map[string]llb.State{}, nil
+}
+
+func (r *Replayer) NewContainer(ctx context.Context, req bkgw.NewContainerRequest) (bkgw.Container, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Replayer) Warn(ctx context.Context, dgst digest.Digest, msg string, opts bkgw.WarnOpts) error {
+	return nil
+}
+
+type replayRef struct {
+	def *pb.Definition
+	rep *Replayer
+}
+
+func (r *replayRef) ToState() (llb.State, error) {
+	return (&Ref{Def: r.def}).ToState()
+}
+
+func (r *replayRef) ReadFile(ctx context.Context, req bkgw.ReadRequest) ([]byte, error) {
+	var recorded gatewayrecord.ReadFileResponse
+	if err := r.rep.replay(gatewayrecord.MethodReadFile, gatewayrecord.DefinitionDigest(r.def), req, &recorded); err != nil {
+		return nil, err
+	}
+
+	if recorded.HasContents {
+		return recorded.Contents, nil
+	}
+
+	return nil, fmt.Errorf("%s (%s): %w", req.Filename, recorded.Digest, ErrContentsNotRecorded)
+}
+
+func (r *replayRef) StatFile(ctx context.Context, req bkgw.StatRequest) (*fstypes.Stat, error) {
+	var stat *fstypes.Stat
+	err := r.rep.replay(gatewayrecord.MethodStatFile, gatewayrecord.DefinitionDigest(r.def), req, &stat)
+	return stat, err
+}
+
+func (r *replayRef) ReadDir(ctx context.Context, req bkgw.ReadDirRequest) ([]*fstypes.Stat, error) {
+	var stats []*fstypes.Stat
+	err := r.rep.replay(gatewayrecord.MethodReadDir, gatewayrecord.DefinitionDigest(r.def), req, &stats)
+	return stats, err
+}
+
+func (r *replayRef) Evaluate(ctx context.Context) error {
+	return r.rep.replay(gatewayrecord.MethodEvaluate, gatewayrecord.DefinitionDigest(r.def), nil, nil)
+}
@@ -0,0 +1,90 @@
+package gatewaytest
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dagger/dagger/core/gatewayrecord"
+	"github.com/moby/buildkit/client/llb"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	fake := New(map[string]specs.Image{
+		"docker.io/library/alpine:latest": {
+			Config: specs.ImageConfig{Cmd: []string{"/bin/sh"}},
+		},
+	})
+	fake.Solver = WithFiles(fstest.MapFS{
+		"etc/motd": {Data: []byte("welcome!")},
+	})
+
+	def, err := llb.Image("alpine").Marshal(ctx)
+	require.NoError(t, err)
+
+	platform := specs.Platform{OS: "linux", Architecture: "amd64"}
+
+	exercise := func(gw bkgw.Client) (string, []byte, []string, error) {
+		dgst, cfg, err := gw.ResolveImageConfig(ctx, "docker.io/library/alpine:latest", llb.ResolveImageConfigOpt{
+			Platform: &platform,
+		})
+		require.NoError(t, err)
+
+		res, err := gw.Solve(ctx, bkgw.SolveRequest{Definition: def.ToPB()})
+		require.NoError(t, err)
+
+		ref, err := res.SingleRef()
+		require.NoError(t, err)
+
+		stats, err := ref.ReadDir(ctx, bkgw.ReadDirRequest{Path: "/etc"})
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, st := range stats {
+			names = append(names, st.Path)
+		}
+
+		_, err = ref.StatFile(ctx, bkgw.StatRequest{Path: "/etc/passwd"})
+		require.Error(t, err)
+
+		_, readErr := ref.ReadFile(ctx, bkgw.ReadRequest{Filename: "/etc/motd"})
+
+		return dgst.String(), cfg, names, readErr
+	}
+
+	rec := gatewayrecord.NewRecorder(fake)
+	dgst, cfg, names, err := exercise(rec)
+	require.NoError(t, err)
+	require.Equal(t, []string{"motd"}, names)
+
+	recording := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, rec.Save(recording))
+
+	// file contents may be secret, so they aren't recorded by default
+	payload, err := os.ReadFile(recording)
+	require.NoError(t, err)
+	require.NotContains(t, string(payload), "welcome!")
+	require.NotContains(t, string(payload), base64.StdEncoding.EncodeToString([]byte("welcome!")))
+
+	rep, err := LoadReplayer(recording)
+	require.NoError(t, err)
+
+	replayedDgst, replayedCfg, replayedNames, err := exercise(rep)
+	require.ErrorIs(t, err, ErrContentsNotRecorded)
+	require.Equal(t, dgst, replayedDgst)
+	require.JSONEq(t, string(cfg), string(replayedCfg))
+	require.Equal(t, names, replayedNames)
+
+	_, _, err = rep.ResolveImageConfig(ctx, "docker.io/library/busybox:latest", llb.ResolveImageConfigOpt{})
+	require.ErrorIs(t, err, ErrNotRecorded)
+}
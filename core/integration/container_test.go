@@ -3,9 +3,15 @@ package core
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -84,6 +90,27 @@ func TestContainerFrom(t *testing.T) {
 	require.Equal(t, res.Container.From.Fs.File.Contents, "3.16.2\n")
 }
 
+func TestContainerPreloadImages(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		PreloadImages bool
+	}{}
+
+	err := testutil.Query(
+		`{
+			preloadImages(addresses: ["alpine:3.16.2", "busybox:1.35"])
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.True(t, res.PreloadImages)
+
+	err = testutil.Query(
+		`{
+			preloadImages(addresses: ["this-image-does-not-exist.invalid/nope:latest"])
+		}`, &res, nil)
+	require.Error(t, err)
+}
+
 func TestContainerWith(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -424,33 +451,36 @@ func TestContainerExecExitCode(t *testing.T) {
 	require.NotNil(t, res.Container.From.WithExec.ExitCode)
 	require.Equal(t, 0, *res.Container.From.WithExec.ExitCode)
 
-	/*
-		It's not currently possible to get a nonzero exit code back because
-		Buildkit raises an error.
-
-		We could perhaps have the shim mask the exit status and always exit 0, but
-		we would have to be careful not to let that happen in a big chained LLB
-		since it would prevent short-circuiting.
-
-		We could only do it when the user requests the exitCode, but then we would
-		actually need to run the command _again_ since we'd need some way to tell
-		the shim what to do.
-
-		Hmm...
+	// failures are fatal unless explicitly allowed, in which case the exit
+	// code and output of the command can be inspected
+	allowRes := struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					ExitCode int
+					Stdout   string
+					Stderr   string
+				}
+			}
+		}
+	}{}
 
-		err = testutil.Query(
-			`{
-				container {
-					from(address: "alpine:3.16.2") {
-						withExec(args: ["false"]) {
-							exitCode
-						}
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sh", "-c", "echo -n out; echo -n err >&2; exit 3"], allowFailure: true) {
+						exitCode
+						stdout
+						stderr
 					}
 				}
-			}`, &res, nil)
-		require.NoError(t, err)
-		require.Equal(t, res.Container.From.WithExec.ExitCode, 1)
-	*/
+			}
+		}`, &allowRes, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, allowRes.Container.From.WithExec.ExitCode)
+	require.Equal(t, "out", allowRes.Container.From.WithExec.Stdout)
+	require.Equal(t, "err", allowRes.Container.From.WithExec.Stderr)
 }
 
 func TestContainerExecStdoutStderr(t *testing.T) {
@@ -510,6 +540,424 @@ func TestContainerExecStdin(t *testing.T) {
 	require.Equal(t, res.Container.From.WithExec.Stdout, "hello")
 }
 
+func TestContainerExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					Stdout string
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sh", "-c", "echo -n hello; sleep 1"], timeout: 60) {
+						stdout
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, "hello", res.Container.From.WithExec.Stdout)
+
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sh", "-c", "sleep 600 & sleep 600"], timeout: 1) {
+						stdout
+					}
+				}
+			}
+		}`, nil, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "did not complete successfully: exit code: 124")
+
+	var allowed struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					ExitCode  int
+					Stderr    string
+					ExecStats struct {
+						TimedOut bool
+					}
+				}
+			}
+		}
+	}
+
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sleep", "600"], timeout: 1, allowFailure: true) {
+						exitCode
+						stderr
+						execStats {
+							timedOut
+						}
+					}
+				}
+			}
+		}`, &allowed, nil)
+	require.NoError(t, err)
+
+	exec := allowed.Container.From.WithExec
+	require.Equal(t, 124, exec.ExitCode)
+	require.Contains(t, exec.Stderr, "exec timed out after 1s")
+	require.True(t, exec.ExecStats.TimedOut)
+}
+
+func TestContainerExecStats(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					ExecStats struct {
+						Duration   float64
+						UserTime   float64
+						SystemTime float64
+						MaxRSS     int64
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sleep", "1"]) {
+						execStats {
+							duration
+							userTime
+							systemTime
+							maxRSS
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	stats := res.Container.From.WithExec.ExecStats
+	require.GreaterOrEqual(t, stats.Duration, 1.0)
+	require.Less(t, stats.UserTime+stats.SystemTime, stats.Duration)
+	require.Positive(t, stats.MaxRSS)
+}
+
+func TestContainerExecSignaled(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					ExitCode  int
+					ExecStats struct {
+						Signal    *string
+						OOMKilled bool
+					}
+				}
+			}
+		}
+	}
+
+	t.Run("signal", func(t *testing.T) {
+		var res result
+		err := testutil.Query(
+			`{
+				container {
+					from(address: "alpine:3.16.2") {
+						withExec(args: ["sh", "-c", "kill -TERM $$"], allowFailure: true) {
+							exitCode
+							execStats {
+								signal
+								oomKilled
+							}
+						}
+					}
+				}
+			}`, &res, nil)
+		require.NoError(t, err)
+
+		exec := res.Container.From.WithExec
+		require.Equal(t, 143, exec.ExitCode)
+		require.NotNil(t, exec.ExecStats.Signal)
+		require.Equal(t, "SIGTERM", *exec.ExecStats.Signal)
+		require.False(t, exec.ExecStats.OOMKilled)
+	})
+
+	t.Run("out of memory", func(t *testing.T) {
+		var res result
+		err := testutil.Query(
+			`{
+				container {
+					from(address: "alpine:3.16.2") {
+						withExec(args: ["tail", "/dev/zero"], memoryLimit: 16, allowFailure: true) {
+							exitCode
+							execStats {
+								signal
+								oomKilled
+							}
+						}
+					}
+				}
+			}`, &res, nil)
+		require.NoError(t, err)
+
+		exec := res.Container.From.WithExec
+		require.Equal(t, 137, exec.ExitCode)
+		require.NotNil(t, exec.ExecStats.Signal)
+		require.Equal(t, "SIGKILL", *exec.ExecStats.Signal)
+		require.True(t, exec.ExecStats.OOMKilled)
+	})
+}
+
+func TestContainerWithHostname(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithHostname struct {
+					WithExec struct {
+						Stdout   string
+						Hostname string
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withHostname(hostname: "license-server") {
+						withExec(args: ["hostname"]) {
+							stdout
+							hostname
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, "license-server\n", res.Container.From.WithHostname.WithExec.Stdout)
+
+	// services are still reached via the computed hostname
+	require.NotEqual(t, "license-server", res.Container.From.WithHostname.WithExec.Hostname)
+}
+
+func TestContainerWithDNS(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithDNS struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withDNS(nameservers: ["10.0.0.2"], searchDomains: ["corp.internal", "example.com"], options: ["ndots:2"]) {
+						withExec(args: ["cat", "/etc/resolv.conf"]) {
+							stdout
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	var nameservers []string
+	for _, line := range strings.Split(res.Container.From.WithDNS.WithExec.Stdout, "\n") {
+		if ns, ok := strings.CutPrefix(line, "nameserver "); ok {
+			nameservers = append(nameservers, ns)
+		}
+	}
+	require.Equal(t, []string{"10.0.0.2"}, nameservers)
+	require.Contains(t, res.Container.From.WithDNS.WithExec.Stdout, "search corp.internal example.com\n")
+	require.Contains(t, res.Container.From.WithDNS.WithExec.Stdout, "options ndots:2\n")
+
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withDNS(nameservers: ["dns.example.com"]) {
+						id
+					}
+				}
+			}
+		}`, nil, nil)
+	require.ErrorContains(t, err, "invalid nameserver IP address")
+}
+
+func TestContainerWithExtraHost(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithExtraHost struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExtraHost(hostname: "registry.internal", ip: "10.0.0.5") {
+						withExec(args: ["getent", "hosts", "registry.internal"]) {
+							stdout
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Contains(t, res.Container.From.WithExtraHost.WithExec.Stdout, "10.0.0.5")
+
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExtraHost(hostname: "registry.internal", ip: "not-an-ip") {
+						id
+					}
+				}
+			}
+		}`, nil, nil)
+	require.ErrorContains(t, err, "invalid IP address")
+}
+
+func TestContainerExecResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithExec struct {
+					Stdout string
+				}
+			}
+		}
+	}{}
+
+	// support both cgroup v2 and v1
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sh", "-c", "cat /sys/fs/cgroup/memory.max /sys/fs/cgroup/cpu.max 2>/dev/null || cat /sys/fs/cgroup/memory/memory.limit_in_bytes /sys/fs/cgroup/cpu/cpu.cfs_quota_us"], cpuLimit: 1.5, memoryLimit: 512) {
+						stdout
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Contains(t, res.Container.From.WithExec.Stdout, "536870912\n")
+	require.Contains(t, res.Container.From.WithExec.Stdout, "150000")
+}
+
+func TestContainerChanges(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithNewFile struct {
+					WithExec struct {
+						Changes struct {
+							Entries []string
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withNewFile(path: "/hello", contents: "hello") {
+						withExec(args: ["sh", "-c", "mkdir /app && touch /app/x"]) {
+							changes {
+								entries
+							}
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	entries := res.Container.From.WithNewFile.WithExec.Changes.Entries
+	require.Contains(t, entries, "hello")
+	require.Contains(t, entries, "app")
+	require.NotContains(t, entries, "bin")
+}
+
+func TestContainerChangesBuildAndRootfs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, err := dagger.Connect(ctx)
+	require.NoError(t, err)
+	defer c.Close()
+
+	t.Run("built image is the base", func(t *testing.T) {
+		src := c.Directory().
+			WithNewFile("Dockerfile", "FROM alpine:3.16.2\nRUN touch /built\n")
+
+		entries, err := c.Container().
+			Build(src).
+			WithNewFile("/hello", dagger.ContainerWithNewFileOpts{Contents: "hello"}).
+			Changes().
+			Entries(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"hello"}, entries)
+	})
+
+	t.Run("replaced rootfs is all changes", func(t *testing.T) {
+		rootfs := c.Directory().WithNewFile("app", "app")
+
+		entries, err := c.Container().
+			From("alpine:3.16.2").
+			WithRootfs(rootfs).
+			WithNewFile("/hello", dagger.ContainerWithNewFileOpts{Contents: "hello"}).
+			Changes().
+			Entries(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"app", "hello"}, entries)
+	})
+}
+
 func TestContainerExecRedirectStdoutStderr(t *testing.T) {
 	t.Parallel()
 
@@ -744,6 +1192,95 @@ func TestContainerExecWithEntrypoint(t *testing.T) {
 	require.Empty(t, removed)
 }
 
+func TestContainerWithoutEntrypoint(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithEntrypoint struct {
+					WithoutEntrypoint struct {
+						Entrypoint []string
+						WithExec   struct {
+							Stdout string
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withEntrypoint(args: ["sh", "-c"]) {
+						withoutEntrypoint {
+							entrypoint
+							withExec(args: ["echo", "hello"]) {
+								stdout
+							}
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Empty(t, res.Container.From.WithEntrypoint.WithoutEntrypoint.Entrypoint)
+	require.Equal(t, "hello\n", res.Container.From.WithEntrypoint.WithoutEntrypoint.WithExec.Stdout)
+}
+
+func TestContainerStopSignalAndVolumes(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithStopSignal struct {
+					WithWorkdir struct {
+						WithVolume struct {
+							WithVolume struct {
+								StopSignal    string
+								Volumes       []string
+								WithoutVolume struct {
+									Volumes []string
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withStopSignal(signal: "SIGQUIT") {
+						withWorkdir(path: "/app") {
+							withVolume(path: "/var/lib/data") {
+								withVolume(path: "cache") {
+									stopSignal
+									volumes
+									withoutVolume(path: "/var/lib/data") {
+										volumes
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	ctr := res.Container.From.WithStopSignal.WithWorkdir.WithVolume.WithVolume
+	require.Equal(t, "SIGQUIT", ctr.StopSignal)
+	require.Equal(t, []string{"/app/cache", "/var/lib/data"}, ctr.Volumes)
+	require.Equal(t, []string{"/app/cache"}, ctr.WithoutVolume.Volumes)
+}
+
 func TestContainerWithDefaultArgs(t *testing.T) {
 	t.Parallel()
 
@@ -1060,6 +1597,45 @@ func TestContainerWithEnvVariableExpand(t *testing.T) {
 	})
 }
 
+func TestContainerPathExpand(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithEnvVariable struct {
+					WithWorkdir struct {
+						WithNewFile struct {
+							WithExec struct {
+								Stdout string
+							}
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withEnvVariable(name: "APP_DIR", value: "/opt/app") {
+						withWorkdir(path: "$APP_DIR", expand: true) {
+							withNewFile(path: "${APP_DIR}/hello", contents: "hello", expand: true) {
+								withExec(args: ["sh", "-c", "pwd && cat /opt/app/hello"]) {
+									stdout
+								}
+							}
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, "/opt/app\nhello", res.Container.From.WithEnvVariable.WithWorkdir.WithNewFile.WithExec.Stdout)
+}
+
 func TestContainerLabel(t *testing.T) {
 	ctx := context.Background()
 	c, err := dagger.Connect(ctx)
@@ -1225,6 +1801,48 @@ func TestContainerWithWorkdir(t *testing.T) {
 	require.Equal(t, res.Container.From.WithWorkdir.WithExec.Stdout, "/usr\n")
 }
 
+func TestContainerWithWorkdirRelative(t *testing.T) {
+	t.Parallel()
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithWorkdir struct {
+					WithWorkdir struct {
+						Workdir         string
+						WithMountedTemp struct {
+							WithExec struct {
+								Stdout string
+							}
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withWorkdir(path: "/usr") {
+						withWorkdir(path: "local/../lib") {
+							workdir
+							withMountedTemp(path: "tmp") {
+								withExec(args: ["sh", "-c", "pwd; grep -c ' /usr/lib/tmp ' /proc/mounts"]) {
+									stdout
+								}
+							}
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, "/usr/lib", res.Container.From.WithWorkdir.WithWorkdir.Workdir)
+	require.Equal(t, "/usr/lib\n1\n", res.Container.From.WithWorkdir.WithWorkdir.WithMountedTemp.WithExec.Stdout)
+}
+
 func TestContainerWithMountedDirectory(t *testing.T) {
 	t.Parallel()
 
@@ -1508,6 +2126,143 @@ func TestContainerWithMountedFile(t *testing.T) {
 	require.Equal(t, "sub-content", execRes.Container.From.WithMountedFile.WithExec.Stdout)
 }
 
+func TestContainerWithMountedDirectoryIncludeExclude(t *testing.T) {
+	t.Parallel()
+
+	dirRes := struct {
+		Directory struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					WithNewFile struct {
+						ID core.DirectoryID
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "main.go", contents: "package main") {
+					withNewFile(path: "README.md", contents: "# readme") {
+						withNewFile(path: "node_modules/dep/index.js", contents: "") {
+							id
+						}
+					}
+				}
+			}
+		}`, &dirRes, nil)
+	require.NoError(t, err)
+
+	id := dirRes.Directory.WithNewFile.WithNewFile.WithNewFile.ID
+
+	execRes := struct {
+		Container struct {
+			From struct {
+				Included struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+				Excluded struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+			}
+		}
+	}{}
+	err = testutil.Query(
+		`query Test($id: DirectoryID!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					included: withMountedDirectory(path: "/src", source: $id, include: ["*.go"]) {
+						withExec(args: ["ls", "/src"]) {
+							stdout
+						}
+					}
+					excluded: withMountedDirectory(path: "/src", source: $id, exclude: ["node_modules"]) {
+						withExec(args: ["ls", "/src"]) {
+							stdout
+						}
+					}
+				}
+			}
+		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
+			"id": id,
+		}})
+	require.NoError(t, err)
+	require.Equal(t, "main.go\n", execRes.Container.From.Included.WithExec.Stdout)
+	require.Equal(t, "README.md\nmain.go\n", execRes.Container.From.Excluded.WithExec.Stdout)
+}
+
+func TestContainerWithMountedReadonly(t *testing.T) {
+	t.Parallel()
+
+	dirRes := struct {
+		Directory struct {
+			WithNewFile struct {
+				ID   core.DirectoryID
+				File struct {
+					ID core.FileID
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "some-file", contents: "some-content") {
+					id
+					file(path: "some-file") {
+						id
+					}
+				}
+			}
+		}`, &dirRes, nil)
+	require.NoError(t, err)
+
+	dirID := dirRes.Directory.WithNewFile.ID
+	fileID := dirRes.Directory.WithNewFile.File.ID
+
+	execRes := struct {
+		Container struct {
+			From struct {
+				WithMountedDirectory struct {
+					WithMountedFile struct {
+						WithExec struct {
+							Stdout string
+						}
+					}
+				}
+			}
+		}
+	}{}
+	err = testutil.Query(
+		`query Test($dir: DirectoryID!, $file: FileID!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					withMountedDirectory(path: "/mnt/dir", source: $dir, readonly: true) {
+						withMountedFile(path: "/mnt/file", source: $file, readonly: true) {
+							withExec(args: ["sh", "-c", "cat /mnt/dir/some-file /mnt/file; touch /mnt/dir/new || echo dir-ro; echo x > /mnt/file || echo file-ro"]) {
+								stdout
+							}
+						}
+					}
+				}
+			}
+		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
+			"dir":  dirID,
+			"file": fileID,
+		}})
+	require.NoError(t, err)
+	require.Equal(t,
+		"some-contentsome-contentdir-ro\nfile-ro\n",
+		execRes.Container.From.WithMountedDirectory.WithMountedFile.WithExec.Stdout)
+}
+
 func TestContainerWithMountedCache(t *testing.T) {
 	t.Parallel()
 
@@ -1664,6 +2419,36 @@ func TestContainerWithMountedTemp(t *testing.T) {
 	require.Contains(t, execRes.Container.From.WithMountedTemp.WithExec.Stdout, "tmpfs /mnt/tmp tmpfs")
 }
 
+func TestContainerWithMountedTempSize(t *testing.T) {
+	t.Parallel()
+
+	execRes := struct {
+		Container struct {
+			From struct {
+				WithMountedTemp struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+			}
+		}
+	}{}
+
+	err := testutil.Query(`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withMountedTemp(path: "/mnt/tmp", size: 1048576) {
+						withExec(args: ["grep", "/mnt/tmp", "/proc/mounts"]) {
+							stdout
+						}
+					}
+				}
+			}
+		}`, &execRes, nil)
+	require.NoError(t, err)
+	require.Contains(t, execRes.Container.From.WithMountedTemp.WithExec.Stdout, "size=1024k")
+}
+
 func TestContainerWithDirectory(t *testing.T) {
 	t.Parallel()
 
@@ -1847,15 +2632,115 @@ func TestContainerMountsWithoutMount(t *testing.T) {
 					}
 				}
 			}
-		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
-			"id": id,
+		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
+			"id": id,
+		}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/mnt/tmp"}, execRes.Container.From.WithDirectory.WithMountedTemp.Mounts)
+	require.Equal(t, []string{"/mnt/tmp", "/mnt/dir"}, execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.Mounts)
+	require.Equal(t, "some-dir\nsome-file\n", execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.Stdout)
+	require.Equal(t, "", execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.WithoutMount.WithExec.Stdout)
+	require.Equal(t, []string{"/mnt/tmp"}, execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.WithoutMount.Mounts)
+}
+
+func TestContainerMountPoints(t *testing.T) {
+	t.Parallel()
+
+	dirRes := struct {
+		Directory struct {
+			WithNewFile struct {
+				ID   core.DirectoryID
+				File struct {
+					ID core.FileID
+				}
+			}
+		}
+		CacheVolume struct {
+			ID core.CacheID
+		}
+	}{}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "sub/some-file", contents: "some-content") {
+					id
+					file(path: "sub/some-file") {
+						id
+					}
+				}
+			}
+			cacheVolume(key: "mount-points") {
+				id
+			}
+		}`, &dirRes, nil)
+	require.NoError(t, err)
+
+	type mountPoint struct {
+		Path       string
+		Type       core.MountType
+		SourcePath *string
+	}
+
+	res := struct {
+		Container struct {
+			From struct {
+				WithMountedDirectory struct {
+					WithMountedFile struct {
+						WithMountedCache struct {
+							WithMountedTemp struct {
+								MountPoints []mountPoint
+							}
+						}
+					}
+				}
+			}
+		}
+	}{}
+
+	err = testutil.Query(
+		`query Test($dir: DirectoryID!, $file: FileID!, $cache: CacheID!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					withMountedDirectory(path: "/mnt/dir", source: $dir) {
+						withMountedFile(path: "/mnt/file", source: $file) {
+							withMountedCache(path: "/mnt/cache", cache: $cache) {
+								withMountedTemp(path: "/mnt/tmp") {
+									mountPoints {
+										path
+										type
+										sourcePath
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+			"dir":   dirRes.Directory.WithNewFile.ID,
+			"file":  dirRes.Directory.WithNewFile.File.ID,
+			"cache": dirRes.CacheVolume.ID,
 		}})
 	require.NoError(t, err)
-	require.Equal(t, []string{"/mnt/tmp"}, execRes.Container.From.WithDirectory.WithMountedTemp.Mounts)
-	require.Equal(t, []string{"/mnt/tmp", "/mnt/dir"}, execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.Mounts)
-	require.Equal(t, "some-dir\nsome-file\n", execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.Stdout)
-	require.Equal(t, "", execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.WithoutMount.WithExec.Stdout)
-	require.Equal(t, []string{"/mnt/tmp"}, execRes.Container.From.WithDirectory.WithMountedTemp.WithMountedDirectory.WithExec.WithoutMount.Mounts)
+
+	mounts := res.Container.From.WithMountedDirectory.WithMountedFile.WithMountedCache.WithMountedTemp.MountPoints
+	require.Len(t, mounts, 4)
+
+	require.Equal(t, "/mnt/dir", mounts[0].Path)
+	require.Equal(t, core.MountTypeDirectory, mounts[0].Type)
+
+	require.Equal(t, "/mnt/file", mounts[1].Path)
+	require.Equal(t, core.MountTypeFile, mounts[1].Type)
+	require.NotNil(t, mounts[1].SourcePath)
+	require.Contains(t, *mounts[1].SourcePath, "sub/some-file")
+
+	require.Equal(t, "/mnt/cache", mounts[2].Path)
+	require.Equal(t, core.MountTypeCache, mounts[2].Type)
+
+	require.Equal(t, "/mnt/tmp", mounts[3].Path)
+	require.Equal(t, core.MountTypeTmpfs, mounts[3].Type)
+	require.Nil(t, mounts[3].SourcePath)
 }
 
 func TestContainerReplacedMounts(t *testing.T) {
@@ -2783,6 +3668,126 @@ func TestContainerImport(t *testing.T) {
 	})
 }
 
+func TestContainerAsTarball(t *testing.T) {
+	t.Parallel()
+
+	var tarballRes struct {
+		Container struct {
+			From struct {
+				WithEnvVariable struct {
+					AsTarball struct {
+						ID   core.FileID
+						Size int
+					}
+				}
+			}
+		}
+	}
+
+	err := testutil.Query(`{
+		container {
+			from(address: "alpine:3.16.2") {
+				withEnvVariable(name: "FOO", value: "bar") {
+					asTarball {
+						id
+						size
+					}
+				}
+			}
+		}
+	}`, &tarballRes, nil)
+	require.NoError(t, err)
+
+	tarball := tarballRes.Container.From.WithEnvVariable.AsTarball
+	require.NotZero(t, tarball.Size)
+
+	var importRes struct {
+		Container struct {
+			Import struct {
+				WithExec struct {
+					Stdout string
+				}
+			}
+		}
+	}
+
+	err = testutil.Query(`query Test($tarball: FileID!) {
+		container {
+			import(source: $tarball) {
+				withExec(args: ["sh", "-c", "echo $FOO"]) {
+					stdout
+				}
+			}
+		}
+	}`, &importRes, &testutil.QueryOptions{Variables: map[string]any{
+		"tarball": tarball.ID,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "bar\n", importRes.Container.Import.WithExec.Stdout)
+}
+
+func TestContainerLayout(t *testing.T) {
+	t.Parallel()
+
+	dest := filepath.Join(t.TempDir(), "layout")
+
+	var exportRes struct {
+		Container struct {
+			From struct {
+				WithEnvVariable struct {
+					ExportLayout bool
+				}
+			}
+		}
+	}
+
+	err := testutil.Query(
+		`query Test($path: String!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					withEnvVariable(name: "FOO", value: "bar") {
+						exportLayout(path: $path)
+					}
+				}
+			}
+		}`, &exportRes, &testutil.QueryOptions{
+			Variables: map[string]any{"path": dest},
+		})
+	require.NoError(t, err)
+	require.True(t, exportRes.Container.From.WithEnvVariable.ExportLayout)
+
+	require.FileExists(t, filepath.Join(dest, "index.json"))
+	require.FileExists(t, filepath.Join(dest, ocispecs.ImageLayoutFile))
+
+	var importRes struct {
+		Container struct {
+			ImportLayout struct {
+				EnvVariable string
+				WithExec    struct {
+					Stdout string
+				}
+			}
+		}
+	}
+
+	err = testutil.Query(
+		`query Test($path: String!) {
+			container {
+				importLayout(path: $path) {
+					envVariable(name: "FOO")
+					withExec(args: ["cat", "/etc/alpine-release"]) {
+						stdout
+					}
+				}
+			}
+		}`, &importRes, &testutil.QueryOptions{
+			Variables: map[string]any{"path": dest},
+		})
+	require.NoError(t, err)
+	require.Equal(t, "bar", importRes.Container.ImportLayout.EnvVariable)
+	require.Equal(t, "3.16.2\n", importRes.Container.ImportLayout.WithExec.Stdout)
+}
+
 func TestContainerMultiPlatformExport(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -3788,6 +4793,332 @@ func TestContainerForceCompression(t *testing.T) {
 	}
 }
 
+func TestContainerPublishProvenance(t *testing.T) {
+	t.Parallel()
+
+	var res struct {
+		Container struct {
+			From struct {
+				Publish string
+			}
+		}
+	}
+
+	ref := registryRef("testcontainerpublishprovenance")
+	err := testutil.Query(`query Test($ref: String!) {
+		container {
+			from(address: "alpine:3.16.2") {
+				publish(address: $ref, provenance: true)
+			}
+		}
+	}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+		"ref": ref,
+	}})
+	require.NoError(t, err)
+
+	parsedRef, err := name.ParseReference(ref, name.Insecure)
+	require.NoError(t, err)
+
+	idx, err := remote.Index(parsedRef, remote.WithTransport(http.DefaultTransport))
+	require.NoError(t, err)
+	idxManifest, err := idx.IndexManifest()
+	require.NoError(t, err)
+
+	var attestations int
+	for _, desc := range idxManifest.Manifests {
+		if desc.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+			attestations++
+		}
+	}
+	require.Equal(t, 1, attestations)
+
+	t.Run("docker media types", func(t *testing.T) {
+		t.Parallel()
+
+		err := testutil.Query(`query Test($ref: String!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					publish(address: $ref, provenance: true, mediaTypes: DockerMediaTypes)
+				}
+			}
+		}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+			"ref": registryRef("testcontainerpublishprovenancedocker"),
+		}})
+		require.ErrorContains(t, err, "provenance attestations require OCIMediaTypes")
+	})
+}
+
+func TestContainerPublishSigned(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	signingKey := c.SetSecret("cosign-key", string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	})))
+
+	pushedRef, err := c.Container().
+		From("alpine:3.16.2").
+		Publish(ctx, registryRef("testcontainerpublishsigned"), dagger.ContainerPublishOpts{
+			SigningKey: signingKey,
+		})
+	require.NoError(t, err)
+
+	parsedRef, err := name.NewDigest(pushedRef, name.Insecure)
+	require.NoError(t, err)
+
+	sigRef := parsedRef.Context().Tag(strings.Replace(parsedRef.DigestStr(), ":", "-", 1) + ".sig")
+	sigImg, err := remote.Image(sigRef, remote.WithTransport(http.DefaultTransport))
+	require.NoError(t, err)
+
+	manifest, err := sigImg.Manifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Layers, 1)
+
+	layers, err := sigImg.Layers()
+	require.NoError(t, err)
+	rc, err := layers[0].Compressed()
+	require.NoError(t, err)
+	defer rc.Close()
+	payload, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Contains(t, string(payload), parsedRef.DigestStr())
+
+	sig, err := base64.StdEncoding.DecodeString(manifest.Layers[0].Annotations["dev.cosignproject.cosign/signature"])
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(payload)
+	require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig))
+}
+
+func TestContainerWithAnnotation(t *testing.T) {
+	t.Parallel()
+
+	tarPath := filepath.Join(t.TempDir(), "export.tar")
+	err := testutil.Query(`query Test($path: String!) {
+		container {
+			from(address: "alpine:3.16.2") {
+				withAnnotation(name: "org.opencontainers.image.source", value: "https://github.com/dagger/dagger") {
+					withLabel(name: "org.opencontainers.image.title", value: "alpine") {
+						export(path: $path)
+					}
+				}
+			}
+		}
+	}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+		"path": tarPath,
+	}})
+	require.NoError(t, err)
+
+	indexBytes := readTarFile(t, tarPath, "index.json")
+	var index ocispecs.Index
+	require.NoError(t, json.Unmarshal(indexBytes, &index))
+	manifestBytes := readTarFile(t, tarPath, "blobs/sha256/"+index.Manifests[0].Digest.Encoded())
+	var manifest ocispecs.Manifest
+	require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+	require.Equal(t, "https://github.com/dagger/dagger", manifest.Annotations["org.opencontainers.image.source"])
+	require.NotContains(t, manifest.Annotations, "org.opencontainers.image.title")
+}
+
+func TestContainerExportHistory(t *testing.T) {
+	t.Parallel()
+
+	tarPath := filepath.Join(t.TempDir(), "export.tar")
+	err := testutil.Query(`query Test($path: String!) {
+		container {
+			from(address: "alpine:3.16.2") {
+				withEnvVariable(name: "FOO", value: "bar") {
+					withNewFile(path: "/hello", contents: "hello") {
+						withExec(args: ["cat", "/hello"]) {
+							export(path: $path)
+						}
+					}
+				}
+			}
+		}
+	}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+		"path": tarPath,
+	}})
+	require.NoError(t, err)
+
+	indexBytes := readTarFile(t, tarPath, "index.json")
+	var index ocispecs.Index
+	require.NoError(t, json.Unmarshal(indexBytes, &index))
+	manifestBytes := readTarFile(t, tarPath, "blobs/sha256/"+index.Manifests[0].Digest.Encoded())
+	var manifest ocispecs.Manifest
+	require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+	configBytes := readTarFile(t, tarPath, "blobs/sha256/"+manifest.Config.Digest.Encoded())
+	var config ocispecs.Image
+	require.NoError(t, json.Unmarshal(configBytes, &config))
+
+	// the base image's history comes first
+	require.Greater(t, len(config.History), 3)
+	require.Contains(t, config.History[0].CreatedBy, "ADD file:")
+
+	history := config.History[len(config.History)-3:]
+	require.Equal(t, "env FOO=bar", history[0].CreatedBy)
+	require.True(t, history[0].EmptyLayer)
+	require.Equal(t, "create file /hello", history[1].CreatedBy)
+	require.False(t, history[1].EmptyLayer)
+	require.Equal(t, "exec cat /hello", history[2].CreatedBy)
+	require.False(t, history[2].EmptyLayer)
+}
+
+func TestContainerExportHistoryWithRootfs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, err := dagger.Connect(ctx)
+	require.NoError(t, err)
+	defer c.Close()
+
+	rootfs := c.Container().From("alpine:3.15.6").Rootfs()
+
+	tarPath := filepath.Join(t.TempDir(), "export.tar")
+	_, err = c.Container().
+		From("alpine:3.16.2").
+		WithEnvVariable("FOO", "bar").
+		WithRootfs(rootfs).
+		WithNewFile("/hello", dagger.ContainerWithNewFileOpts{Contents: "hello"}).
+		Export(ctx, tarPath)
+	require.NoError(t, err)
+
+	indexBytes := readTarFile(t, tarPath, "index.json")
+	var index ocispecs.Index
+	require.NoError(t, json.Unmarshal(indexBytes, &index))
+	manifestBytes := readTarFile(t, tarPath, "blobs/sha256/"+index.Manifests[0].Digest.Encoded())
+	var manifest ocispecs.Manifest
+	require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+	configBytes := readTarFile(t, tarPath, "blobs/sha256/"+manifest.Config.Digest.Encoded())
+	var config ocispecs.Image
+	require.NoError(t, json.Unmarshal(configBytes, &config))
+
+	// the replaced image's history is dropped
+	require.NotEmpty(t, config.History)
+	for _, h := range config.History {
+		require.NotContains(t, h.CreatedBy, "ADD file:")
+		require.NotEqual(t, "env FOO=bar", h.CreatedBy)
+	}
+	require.Equal(t, "create file /hello", config.History[len(config.History)-1].CreatedBy)
+}
+
+func TestContainerExportMediaTypes(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		mediaTypes        core.ImageMediaTypes
+		expectedManifest  string
+		expectedLayerType string
+	}{
+		{
+			core.OCIMediaTypes,
+			ocispecs.MediaTypeImageManifest,
+			"application/vnd.oci.image.layer.v1.tar+gzip",
+		},
+		{
+			core.DockerMediaTypes,
+			"application/vnd.docker.distribution.manifest.v2+json",
+			"application/vnd.docker.image.rootfs.diff.tar.gzip",
+		},
+	} {
+		tc := tc
+		t.Run(string(tc.mediaTypes), func(t *testing.T) {
+			t.Parallel()
+
+			tarPath := filepath.Join(t.TempDir(), "export.tar")
+			err := testutil.Query(`query Test($path: String!, $mediaTypes: ImageMediaTypes!) {
+				container {
+					from(address: "alpine:3.16.2") {
+						export(path: $path, forcedCompression: Gzip, mediaTypes: $mediaTypes)
+					}
+				}
+			}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+				"path":       tarPath,
+				"mediaTypes": tc.mediaTypes,
+			}})
+			require.NoError(t, err)
+
+			indexBytes := readTarFile(t, tarPath, "index.json")
+			var index ocispecs.Index
+			require.NoError(t, json.Unmarshal(indexBytes, &index))
+			manifestDigest := index.Manifests[0].Digest
+			manifestBytes := readTarFile(t, tarPath, "blobs/sha256/"+manifestDigest.Encoded())
+			var manifest ocispecs.Manifest
+			require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+			require.Equal(t, tc.expectedManifest, manifest.MediaType)
+			for _, layer := range manifest.Layers {
+				require.Equal(t, tc.expectedLayerType, layer.MediaType)
+			}
+		})
+	}
+
+	t.Run("docker with estargz", func(t *testing.T) {
+		t.Parallel()
+
+		err := testutil.Query(`query Test($path: String!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					export(path: $path, forcedCompression: EStarGZ, mediaTypes: DockerMediaTypes)
+				}
+			}
+		}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+			"path": filepath.Join(t.TempDir(), "export.tar"),
+		}})
+		require.ErrorContains(t, err, "requires OCIMediaTypes")
+	})
+}
+
+func TestContainerExportCompressionLevel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("with forced compression", func(t *testing.T) {
+		t.Parallel()
+
+		tarPath := filepath.Join(t.TempDir(), "export.tar")
+		err := testutil.Query(`query Test($path: String!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					export(path: $path, forcedCompression: Zstd, compressionLevel: 19)
+				}
+			}
+		}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+			"path": tarPath,
+		}})
+		require.NoError(t, err)
+
+		indexBytes := readTarFile(t, tarPath, "index.json")
+		var index ocispecs.Index
+		require.NoError(t, json.Unmarshal(indexBytes, &index))
+		manifestBytes := readTarFile(t, tarPath, "blobs/sha256/"+index.Manifests[0].Digest.Encoded())
+		var manifest ocispecs.Manifest
+		require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+		for _, layer := range manifest.Layers {
+			require.Equal(t, "application/vnd.docker.image.rootfs.diff.tar.zstd", layer.MediaType)
+		}
+	})
+
+	t.Run("without forced compression", func(t *testing.T) {
+		t.Parallel()
+
+		err := testutil.Query(`query Test($path: String!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					export(path: $path, compressionLevel: 9)
+				}
+			}
+		}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+			"path": filepath.Join(t.TempDir(), "export.tar"),
+		}})
+		require.ErrorContains(t, err, "compression level requires forced compression")
+	})
+}
+
 func TestContainerBuildMergesWithParent(t *testing.T) {
 	t.Parallel()
 
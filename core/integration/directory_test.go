@@ -123,6 +123,234 @@ func TestDirectoryEntriesOfPath(t *testing.T) {
 	require.Equal(t, []string{"sub-file"}, res.Directory.WithNewFile.WithNewFile.Entries)
 }
 
+func TestDirectoryGlob(t *testing.T) {
+	t.Parallel()
+
+	var res struct {
+		Directory struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					WithNewFile struct {
+						All     []string
+						TopOnly []string
+						Nested  []string
+					}
+				}
+			}
+		}
+	}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "a.dll", contents: "a") {
+					withNewFile(path: "dist/b.dll", contents: "b") {
+						withNewFile(path: "dist/sub/c.dll", contents: "c") {
+							all: glob(pattern: "**/*.dll")
+							topOnly: glob(pattern: "*.dll")
+							nested: glob(pattern: "dist/**/*.dll")
+						}
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	dir := res.Directory.WithNewFile.WithNewFile.WithNewFile
+	require.ElementsMatch(t, []string{"a.dll", "dist/b.dll", "dist/sub/c.dll"}, dir.All)
+	require.Equal(t, []string{"a.dll"}, dir.TopOnly)
+	require.ElementsMatch(t, []string{"dist/b.dll", "dist/sub/c.dll"}, dir.Nested)
+}
+
+func TestDirectoryDigest(t *testing.T) {
+	t.Parallel()
+
+	var res struct {
+		A struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					Digest string
+				}
+			}
+		}
+		B struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					Digest string
+				}
+			}
+		}
+		C struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					Digest string
+				}
+			}
+		}
+	}
+
+	err := testutil.Query(
+		`{
+			a: directory {
+				withNewFile(path: "foo", contents: "foo") {
+					withNewFile(path: "sub/bar", contents: "bar") {
+						digest
+					}
+				}
+			}
+			b: directory {
+				withNewFile(path: "sub/bar", contents: "bar") {
+					withNewFile(path: "foo", contents: "foo") {
+						digest
+					}
+				}
+			}
+			c: directory {
+				withNewFile(path: "foo", contents: "foo") {
+					withNewFile(path: "sub/bar", contents: "baz") {
+						digest
+					}
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+
+	a := res.A.WithNewFile.WithNewFile.Digest
+	b := res.B.WithNewFile.WithNewFile.Digest
+	c := res.C.WithNewFile.WithNewFile.Digest
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, a)
+	require.Equal(t, a, b)
+	require.NotEqual(t, a, c)
+}
+
+func TestDirectoryArchive(t *testing.T) {
+	t.Parallel()
+
+	var dirRes struct {
+		Directory struct {
+			WithNewFile struct {
+				WithNewFile struct {
+					Tarball struct {
+						ID core.FileID
+					}
+					Zip struct {
+						ID core.FileID
+					}
+				}
+			}
+		}
+	}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "foo", contents: "foo") {
+					withNewFile(path: "sub/bar", contents: "bar") {
+						tarball: archive {
+							id
+						}
+						zip: archive(format: ZIP) {
+							id
+						}
+					}
+				}
+			}
+		}`, &dirRes, nil)
+	require.NoError(t, err)
+
+	dir := dirRes.Directory.WithNewFile.WithNewFile
+
+	var execRes struct {
+		Container struct {
+			From struct {
+				WithMountedFile struct {
+					WithMountedFile struct {
+						WithExec struct {
+							Stdout string
+						}
+					}
+				}
+			}
+		}
+	}
+
+	err = testutil.Query(
+		`query Test($tarball: FileID!, $zip: FileID!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					withMountedFile(path: "/archive.tar.gz", source: $tarball) {
+						withMountedFile(path: "/archive.zip", source: $zip) {
+							withExec(args: ["sh", "-c", "tar -xzOf /archive.tar.gz sub/bar && unzip -p /archive.zip foo"]) {
+								stdout
+							}
+						}
+					}
+				}
+			}
+		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
+			"tarball": dir.Tarball.ID,
+			"zip":     dir.Zip.ID,
+		}})
+	require.NoError(t, err)
+	require.Equal(t, "barfoo", execRes.Container.From.WithMountedFile.WithMountedFile.WithExec.Stdout)
+}
+
+func TestDirectorySync(t *testing.T) {
+	t.Parallel()
+
+	var res struct {
+		Directory struct {
+			WithNewFile struct {
+				ID   core.DirectoryID
+				Sync core.DirectoryID
+			}
+		}
+	}
+
+	err := testutil.Query(
+		`{
+			directory {
+				withNewFile(path: "foo", contents: "foo") {
+					id
+					sync
+				}
+			}
+		}`, &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, res.Directory.WithNewFile.ID, res.Directory.WithNewFile.Sync)
+
+	var scratchRes struct {
+		Directory struct {
+			ID   core.DirectoryID
+			Sync core.DirectoryID
+		}
+	}
+
+	err = testutil.Query(
+		`{
+			directory {
+				id
+				sync
+			}
+		}`, &scratchRes, nil)
+	require.NoError(t, err)
+	require.Equal(t, scratchRes.Directory.ID, scratchRes.Directory.Sync)
+
+	err = testutil.Query(
+		`{
+			container {
+				from(address: "alpine:3.16.2") {
+					withExec(args: ["sh", "-c", "exit 1"]) {
+						directory(path: "/") {
+							sync
+						}
+					}
+				}
+			}
+		}`, nil, nil)
+	require.ErrorContains(t, err, "exit code: 1")
+}
+
 func TestDirectoryDirectory(t *testing.T) {
 	t.Parallel()
 
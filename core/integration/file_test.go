@@ -248,3 +248,74 @@ func TestFileContents(t *testing.T) {
 		require.Equal(t, testFile.hash, contentsHash)
 	}
 }
+
+func TestFileUnpack(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []string{"TAR", "ZIP"} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			var res struct {
+				Directory struct {
+					WithNewFile struct {
+						WithNewFile struct {
+							Archive struct {
+								Unpack struct {
+									Entries []string
+									File    struct {
+										Contents string
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+
+			err := testutil.Query(
+				`query Test($format: ArchiveFormat!) {
+					directory {
+						withNewFile(path: "foo", contents: "foo") {
+							withNewFile(path: "sub/bar", contents: "bar") {
+								archive(format: $format) {
+									unpack {
+										entries
+										file(path: "sub/bar") {
+											contents
+										}
+									}
+								}
+							}
+						}
+					}
+				}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+					"format": format,
+				}})
+			require.NoError(t, err)
+
+			unpacked := res.Directory.WithNewFile.WithNewFile.Archive.Unpack
+			require.ElementsMatch(t, []string{"foo", "sub"}, unpacked.Entries)
+			require.Equal(t, "bar", unpacked.File.Contents)
+		})
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		t.Parallel()
+
+		err := testutil.Query(
+			`{
+				directory {
+					withNewFile(path: "foo", contents: "foo") {
+						file(path: "foo") {
+							unpack {
+								entries
+							}
+						}
+					}
+				}
+			}`, nil, nil)
+		require.ErrorContains(t, err, "not a tar or zip archive")
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"dagger.io/dagger"
+	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/internal/engine"
 	"github.com/dagger/dagger/internal/testutil"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
@@ -1122,6 +1123,248 @@ func TestFileServiceSecret(t *testing.T) {
 	})
 }
 
+func TestContainerWithStack(t *testing.T) {
+	t.Parallel()
+
+	checkNotDisabled(t, engine.ServicesDNSEnvName)
+
+	c, ctx := connect(t)
+	defer c.Close()
+
+	dbID, err := c.Container().
+		From("python").
+		WithMountedDirectory("/srv/www", c.Directory().WithNewFile("index.html", "Hello from db!")).
+		WithWorkdir("/srv/www").
+		WithExposedPort(8000).
+		WithDefaultArgs(dagger.ContainerWithDefaultArgsOpts{
+			Args: []string{"python", "-m", "http.server"},
+		}).
+		ID(ctx)
+	require.NoError(t, err)
+
+	// web can only come up once db is reachable by its name in the stack
+	webID, err := c.Container().
+		From("python").
+		WithWorkdir("/srv/www").
+		WithExposedPort(8000).
+		WithDefaultArgs(dagger.ContainerWithDefaultArgsOpts{
+			Args: []string{"sh", "-c", `python -c "import urllib.request; open('index.html', 'wb').write(urllib.request.urlopen('http://db:8000').read())" && python -m http.server`},
+		}).
+		ID(ctx)
+	require.NoError(t, err)
+
+	var res struct {
+		Stack struct {
+			WithService struct {
+				WithService struct {
+					Services []string
+					ID       core.StackID
+				}
+			}
+		}
+	}
+	err = testutil.Query(
+		`query Test($db: ContainerID!, $web: ContainerID!) {
+			stack {
+				withService(name: "db", container: $db) {
+					withService(name: "web", container: $web, dependsOn: ["db"]) {
+						services
+						id
+					}
+				}
+			}
+		}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+			"db":  dbID,
+			"web": webID,
+		}})
+	require.NoError(t, err)
+
+	stack := res.Stack.WithService.WithService
+	require.Equal(t, []string{"db", "web"}, stack.Services)
+
+	var execRes struct {
+		Container struct {
+			From struct {
+				WithStack struct {
+					WithExec struct {
+						Stdout string
+					}
+				}
+			}
+		}
+	}
+	err = testutil.Query(
+		`query Test($stack: StackID!) {
+			container {
+				from(address: "alpine:3.16.2") {
+					withStack(stack: $stack) {
+						withExec(args: ["wget", "-O-", "http://web:8000"]) {
+							stdout
+						}
+					}
+				}
+			}
+		}`, &execRes, &testutil.QueryOptions{Variables: map[string]any{
+			"stack": stack.ID,
+		}})
+	require.NoError(t, err)
+	require.Equal(t, "Hello from db!", execRes.Container.From.WithStack.WithExec.Stdout)
+
+	t.Run("circular dependencies", func(t *testing.T) {
+		err := testutil.Query(
+			`query Test($db: ContainerID!) {
+				stack {
+					withService(name: "a", container: $db, dependsOn: ["b"]) {
+						withService(name: "b", container: $db, dependsOn: ["a"]) {
+							service(name: "a") {
+								id
+							}
+						}
+					}
+				}
+			}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+				"db": dbID,
+			}})
+		require.ErrorContains(t, err, "circular dependency")
+	})
+}
+
+func TestServiceStartStop(t *testing.T) {
+	t.Parallel()
+
+	checkNotDisabled(t, engine.ServicesDNSEnvName)
+
+	c, ctx := connect(t)
+	defer c.Close()
+
+	srvID, err := c.Container().
+		From("python").
+		WithMountedDirectory("/srv/www", c.Directory().WithNewFile("index.html", "Hello, world!")).
+		WithWorkdir("/srv/www").
+		WithExposedPort(8000).
+		WithDefaultArgs(dagger.ContainerWithDefaultArgsOpts{
+			Args: []string{"python", "-m", "http.server"},
+		}).
+		ID(ctx)
+	require.NoError(t, err)
+
+	var res struct {
+		Container struct {
+			AsService struct {
+				Start    core.ServiceID
+				Endpoint string
+			}
+		}
+	}
+	err = testutil.Query(
+		`query Test($srv: ContainerID!) {
+			container(id: $srv) {
+				asService {
+					start
+					endpoint(scheme: "http")
+				}
+			}
+		}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+			"srv": srvID,
+		}})
+	require.NoError(t, err)
+
+	svc := res.Container.AsService
+
+	// the service is reachable without binding it, since it was started
+	// explicitly
+	out, err := c.Container().
+		From("alpine:3.16.2").
+		WithEnvVariable("BUST", identity.NewID()).
+		WithExec([]string{"wget", "-O-", svc.Endpoint}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Hello, world!", out)
+
+	err = testutil.Query(
+		`query Test($svc: ServiceID!) {
+			service(id: $svc) {
+				stop
+			}
+		}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+			"svc": svc.Start,
+		}})
+	require.NoError(t, err)
+}
+
+func TestServiceHealthcheckOptions(t *testing.T) {
+	t.Parallel()
+
+	checkNotDisabled(t, engine.ServicesDNSEnvName)
+
+	c, ctx := connect(t)
+	defer c.Close()
+
+	// never listens on the exposed port
+	sleeperID, err := c.Container().
+		From("alpine:3.16.2").
+		WithEnvVariable("BUST", identity.NewID()).
+		WithDefaultArgs(dagger.ContainerWithDefaultArgsOpts{
+			Args: []string{"sleep", "300"},
+		}).
+		ID(ctx)
+	require.NoError(t, err)
+
+	t.Run("timeout", func(t *testing.T) {
+		err := testutil.Query(
+			`query Test($sleeper: ContainerID!) {
+				container(id: $sleeper) {
+					withExposedPort(port: 8000) {
+						withStartTimeout(timeout: 1) {
+							asService {
+								start
+							}
+						}
+					}
+				}
+			}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+				"sleeper": sleeperID,
+			}})
+		require.ErrorContains(t, err, "ports not ready after 1s")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		var res struct {
+			Container struct {
+				WithExposedPort struct {
+					AsService struct {
+						ID core.ServiceID
+					}
+				}
+			}
+		}
+		err := testutil.Query(
+			`query Test($sleeper: ContainerID!) {
+				container(id: $sleeper) {
+					withExposedPort(port: 8000, skipHealthcheck: true) {
+						asService {
+							id
+							start
+						}
+					}
+				}
+			}`, &res, &testutil.QueryOptions{Variables: map[string]any{
+				"sleeper": sleeperID,
+			}})
+		require.NoError(t, err)
+
+		err = testutil.Query(
+			`query Test($svc: ServiceID!) {
+				service(id: $svc) {
+					stop
+				}
+			}`, nil, &testutil.QueryOptions{Variables: map[string]any{
+				"svc": res.Container.WithExposedPort.AsService.ID,
+			}})
+		require.NoError(t, err)
+	})
+}
+
 func httpService(ctx context.Context, t *testing.T, c *dagger.Client, content string) (*dagger.Container, string) {
 	t.Helper()
 
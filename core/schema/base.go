@@ -1,7 +1,10 @@
 package schema
 
 import (
+	"context"
+
 	"github.com/containerd/containerd/content"
+	"github.com/dagger/dagger/admission"
 	"github.com/dagger/dagger/auth"
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/router"
@@ -24,13 +27,43 @@ type InitializeArgs struct {
 	Auth           *auth.RegistryAuthProvider
 	Secrets        *secret.Store
 	ProgrockSocket string
+	Admission      *admission.Controller
+
+	// Reject operations that require external network access.
+	Offline bool
+
+	// Services started with Service.start are stopped once the session
+	// context is done.
+	SessionContext context.Context
 
 	// TODO(vito): remove when stable
 	EnableServices bool
 }
 
 func New(params InitializeArgs) (router.ExecutableSchema, error) {
-	base := &baseSchema{
+	base := newBaseSchema(params)
+	host := core.NewHost(params.Workdir, params.DisableHostRW)
+	containers := &containerSchema{base, host, params.OCIStore}
+	return router.MergeExecutableSchemas("core",
+		&querySchema{base},
+		&directorySchema{base, host, params.OCIStore},
+		&fileSchema{base, host},
+		&gitSchema{base},
+		containers,
+		&cacheSchema{base},
+		&secretSchema{base},
+		&hostSchema{base, host},
+		&projectSchema{base},
+		&httpSchema{base},
+		&platformSchema{base},
+		&socketSchema{base, host},
+		&stackSchema{base, containers},
+		&serviceSchema{base, core.NewServices(params.SessionContext)},
+	)
+}
+
+func newBaseSchema(params InitializeArgs) *baseSchema {
+	return &baseSchema{
 		router:    params.Router,
 		gw:        params.Gateway,
 		bkClient:  params.BKClient,
@@ -44,22 +77,10 @@ func New(params InitializeArgs) (router.ExecutableSchema, error) {
 		servicesEnabled: params.EnableServices,
 
 		progSock: params.ProgrockSocket,
+
+		admission: params.Admission,
+		offline:   params.Offline,
 	}
-	host := core.NewHost(params.Workdir, params.DisableHostRW)
-	return router.MergeExecutableSchemas("core",
-		&querySchema{base},
-		&directorySchema{base, host},
-		&fileSchema{base, host},
-		&gitSchema{base},
-		&containerSchema{base, host, params.OCIStore},
-		&cacheSchema{base},
-		&secretSchema{base},
-		&hostSchema{base, host},
-		&projectSchema{base},
-		&httpSchema{base},
-		&platformSchema{base},
-		&socketSchema{base, host},
-	)
 }
 
 type baseSchema struct {
@@ -77,4 +98,48 @@ type baseSchema struct {
 
 	// path to Progrock forwarding socket
 	progSock string
+
+	// admission controller consulted before pulling, publishing, and running
+	// commands; may be nil
+	admission *admission.Controller
+
+	// reject operations that require external network access
+	offline bool
+}
+
+// checkPublish returns an error if pushing to the address is not allowed.
+func (s *baseSchema) checkPublish(ctx context.Context, address string) error {
+	if err := s.admission.Admit(ctx, admission.Request{
+		Operation: admission.OperationPublish,
+		ImageRef:  address,
+	}); err != nil {
+		return err
+	}
+
+	if s.offline {
+		return core.ErrOffline
+	}
+
+	return nil
+}
+
+// checkDockerBuild returns an error if Dockerfile builds are not allowed.
+//
+// The images a Dockerfile pulls aren't known until buildkit runs it, so
+// they can't be admitted; builds are denied outright when an admission
+// policy is configured.
+func (s *baseSchema) checkDockerBuild() error {
+	if s.admission != nil {
+		return &admission.DeniedError{
+			Request: admission.Request{Operation: admission.OperationFrom},
+			Reason:  "Dockerfile builds are not allowed by the admission policy",
+		}
+	}
+
+	if s.offline {
+		// the Dockerfile may pull images and fetch URLs or git repositories
+		return core.ErrOffline
+	}
+
+	return nil
 }
@@ -3,16 +3,19 @@ package schema
 import (
 	"fmt"
 	"os"
-	"path"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/containerd/containerd/content"
+	"github.com/dagger/dagger/admission"
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/core/pipeline"
+	"github.com/dagger/dagger/events"
 	"github.com/dagger/dagger/router"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 )
 
 type containerSchema struct {
@@ -36,7 +39,8 @@ func (s *containerSchema) Resolvers() router.Resolvers {
 	return router.Resolvers{
 		"ContainerID": stringResolver(core.ContainerID("")),
 		"Query": router.ObjectResolver{
-			"container": router.ToResolver(s.container),
+			"container":     router.ToResolver(s.container),
+			"preloadImages": router.ToResolver(s.preloadImages),
 		},
 		"Container": router.ObjectResolver{
 			"id":                   router.ToResolver(s.id),
@@ -44,6 +48,7 @@ func (s *containerSchema) Resolvers() router.Resolvers {
 			"from":                 router.ToResolver(s.from),
 			"build":                router.ToResolver(s.build),
 			"rootfs":               router.ToResolver(s.rootfs),
+			"changes":              router.ToResolver(s.changes),
 			"pipeline":             router.ToResolver(s.pipeline),
 			"fs":                   router.ToResolver(s.rootfs), // deprecated
 			"withRootfs":           router.ToResolver(s.withRootfs),
@@ -53,6 +58,11 @@ func (s *containerSchema) Resolvers() router.Resolvers {
 			"user":                 router.ToResolver(s.user),
 			"withUser":             router.ToResolver(s.withUser),
 			"workdir":              router.ToResolver(s.workdir),
+			"stopSignal":           router.ToResolver(s.stopSignal),
+			"withStopSignal":       router.ToResolver(s.withStopSignal),
+			"volumes":              router.ToResolver(s.volumes),
+			"withVolume":           router.ToResolver(s.withVolume),
+			"withoutVolume":        router.ToResolver(s.withoutVolume),
 			"withWorkdir":          router.ToResolver(s.withWorkdir),
 			"envVariables":         router.ToResolver(s.envVariables),
 			"envVariable":          router.ToResolver(s.envVariable),
@@ -63,11 +73,14 @@ func (s *containerSchema) Resolvers() router.Resolvers {
 			"label":                router.ToResolver(s.label),
 			"labels":               router.ToResolver(s.labels),
 			"withoutLabel":         router.ToResolver(s.withoutLabel),
+			"withAnnotation":       router.ToResolver(s.withAnnotation),
 			"entrypoint":           router.ToResolver(s.entrypoint),
 			"withEntrypoint":       router.ToResolver(s.withEntrypoint),
+			"withoutEntrypoint":    router.ToResolver(s.withoutEntrypoint),
 			"defaultArgs":          router.ToResolver(s.defaultArgs),
 			"withDefaultArgs":      router.ToResolver(s.withDefaultArgs),
 			"mounts":               router.ToResolver(s.mounts),
+			"mountPoints":          router.ToResolver(s.mountPoints),
 			"withMountedDirectory": router.ToResolver(s.withMountedDirectory),
 			"withMountedFile":      router.ToResolver(s.withMountedFile),
 			"withMountedTemp":      router.ToResolver(s.withMountedTemp),
@@ -82,21 +95,31 @@ func (s *containerSchema) Resolvers() router.Resolvers {
 			"withExec":             router.ToResolver(s.withExec),
 			"exec":                 router.ToResolver(s.withExec), // deprecated
 			"exitCode":             router.ToResolver(s.exitCode),
+			"execStats":            router.ToResolver(s.execStats),
 			"stdout":               router.ToResolver(s.stdout),
 			"stderr":               router.ToResolver(s.stderr),
 			"publish":              router.ToResolver(s.publish),
 			"platform":             router.ToResolver(s.platform),
 			"export":               router.ToResolver(s.export),
+			"asTarball":            router.ToResolver(s.asTarball),
 			"import":               router.ToResolver(s.import_),
+			"exportLayout":         router.ToResolver(s.exportLayout),
+			"importLayout":         router.ToResolver(s.importLayout),
 			"withRegistryAuth":     router.ToResolver(s.withRegistryAuth),
 			"withoutRegistryAuth":  router.ToResolver(s.withoutRegistryAuth),
 			"imageRef":             router.ToResolver(s.imageRef),
 			"withExposedPort":      router.ToResolver(s.withExposedPort),
+			"withStartTimeout":     router.ToResolver(s.withStartTimeout),
 			"withoutExposedPort":   router.ToResolver(s.withoutExposedPort),
 			"exposedPorts":         router.ToResolver(s.exposedPorts),
 			"hostname":             router.ToResolver(s.hostname),
 			"endpoint":             router.ToResolver(s.endpoint),
+			"asService":            router.ToResolver(s.asService),
 			"withServiceBinding":   router.ToResolver(s.withServiceBinding),
+			"withExtraHost":        router.ToResolver(s.withExtraHost),
+			"withHostname":         router.ToResolver(s.withHostname),
+			"withDNS":              router.ToResolver(s.withDNS),
+			"withStack":            router.ToResolver(s.withStack),
 		},
 	}
 }
@@ -126,6 +149,76 @@ func (s *containerSchema) container(ctx *router.Context, parent *core.Query, arg
 	return ctr, err
 }
 
+type preloadImagesArgs struct {
+	Addresses []string
+	Archive   core.FileID
+	Platform  *specs.Platform
+}
+
+func (s *containerSchema) preloadImages(ctx *router.Context, parent *core.Query, args preloadImagesArgs) (bool, error) {
+	platform := s.baseSchema.platform
+	if args.Platform != nil {
+		platform = *args.Platform
+	}
+
+	if len(args.Addresses) > 0 && s.offline {
+		return false, core.ErrOffline
+	}
+
+	// check every address before pulling any of them, so that a rejected
+	// address doesn't leave earlier pulls running
+	for _, addr := range args.Addresses {
+		if err := s.admission.Admit(ctx, admission.Request{
+			Operation: admission.OperationFrom,
+			ImageRef:  addr,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	eg, egctx := errgroup.WithContext(ctx)
+
+	for _, addr := range args.Addresses {
+		addr := addr
+
+		eg.Go(func() error {
+			ctr, err := core.NewContainer("", parent.PipelinePath(), platform)
+			if err != nil {
+				return err
+			}
+
+			ctr, err = ctr.From(egctx, s.gw, addr)
+			if err != nil {
+				return fmt.Errorf("pull %s: %w", addr, err)
+			}
+
+			return ctr.Evaluate(egctx, s.gw)
+		})
+	}
+
+	if args.Archive != "" {
+		eg.Go(func() error {
+			ctr, err := core.NewContainer("", parent.PipelinePath(), platform)
+			if err != nil {
+				return err
+			}
+
+			ctr, err = ctr.Import(egctx, s.gw, s.host, args.Archive, "", s.ociStore)
+			if err != nil {
+				return fmt.Errorf("import archive: %w", err)
+			}
+
+			return ctr.Evaluate(egctx, s.gw)
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (s *containerSchema) sync(ctx *router.Context, parent *core.Container, _ any) (core.ContainerID, error) {
 	err := parent.Evaluate(ctx.Context, s.gw)
 	if err != nil {
@@ -143,6 +236,15 @@ type containerFromArgs struct {
 }
 
 func (s *containerSchema) from(ctx *router.Context, parent *core.Container, args containerFromArgs) (*core.Container, error) {
+	if err := s.admission.Admit(ctx, admission.Request{
+		Operation: admission.OperationFrom,
+		ImageRef:  args.Address,
+	}); err != nil {
+		return nil, err
+	}
+	if s.offline {
+		return parent.FromLocal(ctx, s.ociStore, args.Address)
+	}
 	return parent.From(ctx, s.gw, args.Address)
 }
 
@@ -155,6 +257,9 @@ type containerBuildArgs struct {
 }
 
 func (s *containerSchema) build(ctx *router.Context, parent *core.Container, args containerBuildArgs) (*core.Container, error) {
+	if err := s.checkDockerBuild(); err != nil {
+		return nil, err
+	}
 	dir, err := args.Context.ToDirectory()
 	if err != nil {
 		return nil, err
@@ -188,11 +293,23 @@ func (s *containerSchema) rootfs(ctx *router.Context, parent *core.Container, ar
 	return parent.RootFS(ctx)
 }
 
+func (s *containerSchema) changes(ctx *router.Context, parent *core.Container, args any) (*core.Directory, error) {
+	return parent.Changes(ctx)
+}
+
 type containerExecArgs struct {
 	core.ContainerExecOpts
 }
 
 func (s *containerSchema) withExec(ctx *router.Context, parent *core.Container, args containerExecArgs) (*core.Container, error) {
+	if err := s.admission.Admit(ctx, admission.Request{
+		Operation:  admission.OperationExec,
+		Args:       args.Args,
+		Privileged: args.InsecureRootCapabilities || args.ExperimentalPrivilegedNesting,
+	}); err != nil {
+		return nil, err
+	}
+	args.Offline = s.offline
 	progSock := &core.Socket{HostPath: s.progSock}
 	return parent.WithExec(ctx, s.gw, progSock, s.baseSchema.platform, args.ContainerExecOpts)
 }
@@ -209,6 +326,11 @@ func (s *containerSchema) exitCode(ctx *router.Context, parent *core.Container,
 	return parent.ExitCode(ctx, s.gw, progSock)
 }
 
+func (s *containerSchema) execStats(ctx *router.Context, parent *core.Container, args any) (*core.ExecStats, error) {
+	progSock := &core.Socket{HostPath: s.progSock}
+	return parent.ExecStats(ctx, s.gw, progSock)
+}
+
 func (s *containerSchema) stdout(ctx *router.Context, parent *core.Container, args any) (string, error) {
 	progSock := &core.Socket{HostPath: s.progSock}
 	return parent.MetaFileContents(ctx, s.gw, progSock, "stdout")
@@ -230,6 +352,13 @@ func (s *containerSchema) withEntrypoint(ctx *router.Context, parent *core.Conta
 	})
 }
 
+func (s *containerSchema) withoutEntrypoint(ctx *router.Context, parent *core.Container, args any) (*core.Container, error) {
+	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		cfg.Entrypoint = nil
+		return cfg
+	})
+}
+
 func (s *containerSchema) entrypoint(ctx *router.Context, parent *core.Container, args containerWithVariableArgs) ([]string, error) {
 	cfg, err := parent.ImageConfig(ctx)
 	if err != nil {
@@ -285,12 +414,17 @@ func (s *containerSchema) user(ctx *router.Context, parent *core.Container, args
 }
 
 type containerWithWorkdirArgs struct {
-	Path string
+	Path   string
+	Expand bool
 }
 
 func (s *containerSchema) withWorkdir(ctx *router.Context, parent *core.Container, args containerWithWorkdirArgs) (*core.Container, error) {
 	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
-		cfg.WorkingDir = absPath(cfg.WorkingDir, args.Path)
+		if args.Expand {
+			args.Path = expandEnv(cfg.Env, args.Path)
+		}
+
+		cfg.WorkingDir = core.AbsPath(cfg.WorkingDir, args.Path)
 		return cfg
 	})
 }
@@ -304,6 +438,62 @@ func (s *containerSchema) workdir(ctx *router.Context, parent *core.Container, a
 	return cfg.WorkingDir, nil
 }
 
+type containerWithStopSignalArgs struct {
+	Signal string
+}
+
+func (s *containerSchema) withStopSignal(ctx *router.Context, parent *core.Container, args containerWithStopSignalArgs) (*core.Container, error) {
+	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		cfg.StopSignal = args.Signal
+		return cfg
+	})
+}
+
+func (s *containerSchema) stopSignal(ctx *router.Context, parent *core.Container, args any) (string, error) {
+	cfg, err := parent.ImageConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.StopSignal, nil
+}
+
+type containerWithVolumeArgs struct {
+	Path string
+}
+
+func (s *containerSchema) withVolume(ctx *router.Context, parent *core.Container, args containerWithVolumeArgs) (*core.Container, error) {
+	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		if cfg.Volumes == nil {
+			cfg.Volumes = make(map[string]struct{})
+		}
+		cfg.Volumes[core.AbsPath(cfg.WorkingDir, args.Path)] = struct{}{}
+		return cfg
+	})
+}
+
+func (s *containerSchema) withoutVolume(ctx *router.Context, parent *core.Container, args containerWithVolumeArgs) (*core.Container, error) {
+	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		delete(cfg.Volumes, core.AbsPath(cfg.WorkingDir, args.Path))
+		return cfg
+	})
+}
+
+func (s *containerSchema) volumes(ctx *router.Context, parent *core.Container, args any) ([]string, error) {
+	cfg, err := parent.ImageConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := make([]string, 0, len(cfg.Volumes))
+	for vol := range cfg.Volumes {
+		volumes = append(volumes, vol)
+	}
+	sort.Strings(volumes)
+
+	return volumes, nil
+}
+
 type containerWithVariableArgs struct {
 	Name   string
 	Value  string
@@ -311,20 +501,30 @@ type containerWithVariableArgs struct {
 }
 
 func (s *containerSchema) withEnvVariable(ctx *router.Context, parent *core.Container, args containerWithVariableArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
-		value := args.Value
-
+	value := args.Value
+	ctr, err := parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
 		if args.Expand {
-			value = os.Expand(value, func(k string) string {
-				v, _ := core.LookupEnv(cfg.Env, k)
-				return v
-			})
+			value = expandEnv(cfg.Env, value)
 		}
 
 		cfg.Env = core.AddEnv(cfg.Env, args.Name, value)
 
 		return cfg
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ctr.WithHistory(fmt.Sprintf("env %s=%s", args.Name, value), true), nil
+}
+
+// expandEnv replaces ${VAR} or $VAR in the value according to the given
+// environment variables.
+func expandEnv(env []string, value string) string {
+	return os.Expand(value, func(k string) string {
+		v, _ := core.LookupEnv(env, k)
+		return v
+	})
 }
 
 type containerWithoutVariableArgs struct {
@@ -426,41 +626,97 @@ func (s *containerSchema) label(ctx *router.Context, parent *core.Container, arg
 }
 
 type containerWithMountedDirectoryArgs struct {
-	Path   string
-	Source core.DirectoryID
-	Owner  string
+	Path     string
+	Source   core.DirectoryID
+	Owner    string
+	Readonly bool
+	Expand   bool
+
+	core.CopyFilter
 }
 
 func (s *containerSchema) withMountedDirectory(ctx *router.Context, parent *core.Container, args containerWithMountedDirectoryArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	dir, err := args.Source.ToDirectory()
 	if err != nil {
 		return nil, err
 	}
-	return parent.WithMountedDirectory(ctx, s.gw, args.Path, dir, args.Owner)
+	return parent.WithMountedDirectory(ctx, s.gw, args.Path, dir, args.CopyFilter, args.Owner, args.Readonly)
 }
 
 type containerPublishArgs struct {
 	Address           string
 	PlatformVariants  []core.ContainerID
 	ForcedCompression core.ImageLayerCompression
+	CompressionLevel  int
+	MediaTypes        core.ImageMediaTypes
+	Provenance        bool
+	SigningKey        core.SecretID
+	SigningPassword   core.SecretID
 }
 
 func (s *containerSchema) publish(ctx *router.Context, parent *core.Container, args containerPublishArgs) (string, error) {
-	return parent.Publish(ctx, args.Address, args.PlatformVariants, args.ForcedCompression, s.bkClient, s.solveOpts, s.solveCh)
+	if err := s.checkPublish(ctx, args.Address); err != nil {
+		return "", err
+	}
+
+	// read the key before pushing, so that a missing secret doesn't leave an
+	// unsigned image behind
+	var signingKey, signingPassword []byte
+	if args.SigningKey != "" {
+		var err error
+		signingKey, err = s.secrets.GetSecret(ctx, args.SigningKey.String())
+		if err != nil {
+			return "", fmt.Errorf("signing key: %w", err)
+		}
+	}
+	if args.SigningPassword != "" {
+		var err error
+		signingPassword, err = s.secrets.GetSecret(ctx, args.SigningPassword.String())
+		if err != nil {
+			return "", fmt.Errorf("signing password: %w", err)
+		}
+	}
+
+	ref, err := parent.Publish(ctx, args.Address, args.PlatformVariants, args.ForcedCompression, args.CompressionLevel, args.MediaTypes, args.Provenance, s.bkClient, s.solveOpts, s.solveCh)
+	if err != nil {
+		return "", err
+	}
+
+	if signingKey != nil {
+		if err := core.SignImage(ctx, ref, signingKey, signingPassword, s.auth); err != nil {
+			return "", err
+		}
+	}
+
+	events.Emit(ctx, events.ImagePublished, map[string]any{
+		"address": ref,
+	})
+
+	return ref, nil
 }
 
 type containerWithMountedFileArgs struct {
-	Path   string
-	Source core.FileID
-	Owner  string
+	Path     string
+	Source   core.FileID
+	Owner    string
+	Readonly bool
+	Expand   bool
 }
 
 func (s *containerSchema) withMountedFile(ctx *router.Context, parent *core.Container, args containerWithMountedFileArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	file, err := args.Source.ToFile()
 	if err != nil {
 		return nil, err
 	}
-	return parent.WithMountedFile(ctx, s.gw, args.Path, file, args.Owner)
+	return parent.WithMountedFile(ctx, s.gw, args.Path, file, args.Owner, args.Readonly)
 }
 
 type containerWithMountedCacheArgs struct {
@@ -469,9 +725,14 @@ type containerWithMountedCacheArgs struct {
 	Source      core.DirectoryID
 	Concurrency core.CacheSharingMode
 	Owner       string
+	Expand      bool
 }
 
 func (s *containerSchema) withMountedCache(ctx *router.Context, parent *core.Container, args containerWithMountedCacheArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	var dir *core.Directory
 	if args.Source != "" {
 		var err error
@@ -490,11 +751,17 @@ func (s *containerSchema) withMountedCache(ctx *router.Context, parent *core.Con
 }
 
 type containerWithMountedTempArgs struct {
-	Path string
+	Path   string
+	Size   int
+	Expand bool
 }
 
 func (s *containerSchema) withMountedTemp(ctx *router.Context, parent *core.Container, args containerWithMountedTempArgs) (*core.Container, error) {
-	return parent.WithMountedTemp(ctx, args.Path)
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
+	return parent.WithMountedTemp(ctx, args.Path, int64(args.Size))
 }
 
 type containerWithoutMountArgs struct {
@@ -509,6 +776,31 @@ func (s *containerSchema) mounts(ctx *router.Context, parent *core.Container, _
 	return parent.MountTargets(ctx)
 }
 
+type Mount struct {
+	Path       string         `json:"path"`
+	Type       core.MountType `json:"type"`
+	SourcePath *string        `json:"sourcePath"`
+}
+
+func (s *containerSchema) mountPoints(ctx *router.Context, parent *core.Container, _ any) ([]Mount, error) {
+	mounts := make([]Mount, 0, len(parent.Mounts))
+	for _, mnt := range parent.Mounts {
+		mount := Mount{
+			Path: mnt.Target,
+			Type: mnt.Type(),
+		}
+
+		if mnt.SourcePath != "" {
+			sourcePath := mnt.SourcePath
+			mount.SourcePath = &sourcePath
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return mounts, nil
+}
+
 type containerWithLabelArgs struct {
 	Name  string
 	Value string
@@ -535,6 +827,40 @@ func (s *containerSchema) withoutLabel(ctx *router.Context, parent *core.Contain
 	})
 }
 
+type containerWithHostnameArgs struct {
+	Hostname string
+}
+
+func (s *containerSchema) withHostname(ctx *router.Context, parent *core.Container, args containerWithHostnameArgs) (*core.Container, error) {
+	return parent.WithHostname(args.Hostname)
+}
+
+type containerWithDNSArgs struct {
+	core.DNSConfig
+}
+
+func (s *containerSchema) withDNS(ctx *router.Context, parent *core.Container, args containerWithDNSArgs) (*core.Container, error) {
+	return parent.WithDNS(args.DNSConfig)
+}
+
+type containerWithExtraHostArgs struct {
+	Hostname string
+	IP       string
+}
+
+func (s *containerSchema) withExtraHost(ctx *router.Context, parent *core.Container, args containerWithExtraHostArgs) (*core.Container, error) {
+	return parent.WithExtraHost(args.Hostname, args.IP)
+}
+
+type containerWithAnnotationArgs struct {
+	Name  string
+	Value string
+}
+
+func (s *containerSchema) withAnnotation(ctx *router.Context, parent *core.Container, args containerWithAnnotationArgs) (*core.Container, error) {
+	return parent.WithAnnotation(args.Name, args.Value)
+}
+
 type containerDirectoryArgs struct {
 	Path string
 }
@@ -551,18 +877,6 @@ func (s *containerSchema) file(ctx *router.Context, parent *core.Container, args
 	return parent.File(ctx, s.gw, args.Path)
 }
 
-func absPath(workDir string, containerPath string) string {
-	if path.IsAbs(containerPath) {
-		return containerPath
-	}
-
-	if workDir == "" {
-		workDir = "/"
-	}
-
-	return path.Join(workDir, containerPath)
-}
-
 type containerWithSecretVariableArgs struct {
 	Name   string
 	Secret core.SecretID
@@ -580,9 +894,14 @@ type containerWithMountedSecretArgs struct {
 	Path   string
 	Source core.SecretID
 	Owner  string
+	Expand bool
 }
 
 func (s *containerSchema) withMountedSecret(ctx *router.Context, parent *core.Container, args containerWithMountedSecretArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	secret, err := args.Source.ToSecret()
 	if err != nil {
 		return nil, err
@@ -592,10 +911,15 @@ func (s *containerSchema) withMountedSecret(ctx *router.Context, parent *core.Co
 
 type containerWithDirectoryArgs struct {
 	withDirectoryArgs
-	Owner string
+	Owner  string
+	Expand bool
 }
 
 func (s *containerSchema) withDirectory(ctx *router.Context, parent *core.Container, args containerWithDirectoryArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	dir, err := args.Directory.ToDirectory()
 	if err != nil {
 		return nil, err
@@ -605,10 +929,15 @@ func (s *containerSchema) withDirectory(ctx *router.Context, parent *core.Contai
 
 type containerWithFileArgs struct {
 	withFileArgs
-	Owner string
+	Owner  string
+	Expand bool
 }
 
 func (s *containerSchema) withFile(ctx *router.Context, parent *core.Container, args containerWithFileArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	file, err := args.Source.ToFile()
 	if err != nil {
 		return nil, err
@@ -618,10 +947,15 @@ func (s *containerSchema) withFile(ctx *router.Context, parent *core.Container,
 
 type containerWithNewFileArgs struct {
 	withNewFileArgs
-	Owner string
+	Owner  string
+	Expand bool
 }
 
 func (s *containerSchema) withNewFile(ctx *router.Context, parent *core.Container, args containerWithNewFileArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	return parent.WithNewFile(ctx, s.gw, args.Path, []byte(args.Contents), args.Permissions, args.Owner)
 }
 
@@ -629,9 +963,14 @@ type containerWithUnixSocketArgs struct {
 	Path   string
 	Source core.SocketID
 	Owner  string
+	Expand bool
 }
 
 func (s *containerSchema) withUnixSocket(ctx *router.Context, parent *core.Container, args containerWithUnixSocketArgs) (*core.Container, error) {
+	if args.Expand {
+		args.Path = expandEnv(parent.Config.Env, args.Path)
+	}
+
 	socket, err := args.Source.ToSocket()
 	if err != nil {
 		return nil, err
@@ -655,16 +994,46 @@ type containerExportArgs struct {
 	Path              string
 	PlatformVariants  []core.ContainerID
 	ForcedCompression core.ImageLayerCompression
+	CompressionLevel  int
+	MediaTypes        core.ImageMediaTypes
 }
 
 func (s *containerSchema) export(ctx *router.Context, parent *core.Container, args containerExportArgs) (bool, error) {
-	if err := parent.Export(ctx, s.host, args.Path, args.PlatformVariants, args.ForcedCompression, s.bkClient, s.solveOpts, s.solveCh); err != nil {
+	if err := parent.Export(ctx, s.host, args.Path, args.PlatformVariants, args.ForcedCompression, args.CompressionLevel, args.MediaTypes, s.bkClient, s.solveOpts, s.solveCh); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+type containerAsTarballArgs struct {
+	PlatformVariants  []core.ContainerID
+	ForcedCompression core.ImageLayerCompression
+	CompressionLevel  int
+	MediaTypes        core.ImageMediaTypes
+}
+
+func (s *containerSchema) asTarball(ctx *router.Context, parent *core.Container, args containerAsTarballArgs) (*core.File, error) {
+	return parent.AsTarball(ctx, s.ociStore, args.PlatformVariants, args.ForcedCompression, args.CompressionLevel, args.MediaTypes, s.bkClient, s.solveOpts, s.solveCh)
+}
+
+func (s *containerSchema) exportLayout(ctx *router.Context, parent *core.Container, args containerExportArgs) (bool, error) {
+	if err := parent.ExportLayout(ctx, s.host, args.Path, args.PlatformVariants, args.ForcedCompression, s.bkClient, s.solveOpts, s.solveCh); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+type containerImportLayoutArgs struct {
+	Path string
+	Tag  string
+}
+
+func (s *containerSchema) importLayout(ctx *router.Context, parent *core.Container, args containerImportLayoutArgs) (*core.Container, error) {
+	return parent.ImportLayout(ctx, s.host, args.Path, args.Tag, s.ociStore)
+}
+
 type containerImportArgs struct {
 	Source core.FileID
 	Tag    string
@@ -709,6 +1078,14 @@ func (s *containerSchema) imageRef(ctx *router.Context, parent *core.Container,
 	return parent.ImageRefOrErr(ctx, s.gw)
 }
 
+func (s *containerSchema) asService(ctx *router.Context, parent *core.Container, args any) (*core.Container, error) {
+	if !s.servicesEnabled {
+		return nil, ErrServicesDisabled
+	}
+
+	return s.withDefaultExec(ctx, parent)
+}
+
 func (s *containerSchema) hostname(ctx *router.Context, parent *core.Container, args any) (string, error) {
 	if !s.servicesEnabled {
 		return "", ErrServicesDisabled
@@ -763,10 +1140,36 @@ func (s *containerSchema) withServiceBinding(ctx *router.Context, parent *core.C
 	return parent.WithServiceBinding(svc, args.Alias)
 }
 
+type containerWithStackArgs struct {
+	Stack core.StackID
+}
+
+func (s *containerSchema) withStack(ctx *router.Context, parent *core.Container, args containerWithStackArgs) (*core.Container, error) {
+	if !s.servicesEnabled {
+		return nil, ErrServicesDisabled
+	}
+
+	stack, err := args.Stack.ToStack()
+	if err != nil {
+		return nil, err
+	}
+
+	return parent.WithStack(stack, s.stackExec(ctx))
+}
+
+// stackExec runs a stack service's default command, subject to the same
+// checks as withExec.
+func (s *containerSchema) stackExec(ctx *router.Context) core.StackExecFunc {
+	return func(ctr *core.Container) (*core.Container, error) {
+		return s.withExec(ctx, ctr, containerExecArgs{})
+	}
+}
+
 type containerWithExposedPortArgs struct {
-	Protocol    core.NetworkProtocol
-	Port        int
-	Description *string
+	Protocol        core.NetworkProtocol
+	Port            int
+	Description     *string
+	SkipHealthcheck bool
 }
 
 func (s *containerSchema) withExposedPort(ctx *router.Context, parent *core.Container, args containerWithExposedPortArgs) (*core.Container, error) {
@@ -775,12 +1178,25 @@ func (s *containerSchema) withExposedPort(ctx *router.Context, parent *core.Cont
 	}
 
 	return parent.WithExposedPort(core.ContainerPort{
-		Protocol:    args.Protocol,
-		Port:        args.Port,
-		Description: args.Description,
+		Protocol:        args.Protocol,
+		Port:            args.Port,
+		Description:     args.Description,
+		SkipHealthcheck: args.SkipHealthcheck,
 	})
 }
 
+type containerWithStartTimeoutArgs struct {
+	Timeout int
+}
+
+func (s *containerSchema) withStartTimeout(ctx *router.Context, parent *core.Container, args containerWithStartTimeoutArgs) (*core.Container, error) {
+	if !s.servicesEnabled {
+		return nil, ErrServicesDisabled
+	}
+
+	return parent.WithHealthcheckTimeout(args.Timeout)
+}
+
 type containerWithoutExposedPortArgs struct {
 	Protocol core.NetworkProtocol
 	Port     int
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/router"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreloadImagesAdmission(t *testing.T) {
+	t.Parallel()
+
+	// no gateway: pulling an image would panic, so this also checks that
+	// nothing is pulled before every address has been admitted
+	containers := &containerSchema{
+		baseSchema: &baseSchema{
+			platform: specs.Platform{OS: "linux", Architecture: "amd64"},
+			admission: admission.NewController(admission.Policy{
+				AllowedRegistries: []string{"docker.io"},
+			}),
+		},
+	}
+
+	ctx := &router.Context{Context: context.Background()}
+
+	_, err := containers.preloadImages(ctx, &core.Query{}, preloadImagesArgs{
+		Addresses: []string{"alpine:3.16", "ghcr.io/dagger/engine:latest"},
+	})
+
+	var denied *admission.DeniedError
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, "ghcr.io/dagger/engine:latest", denied.Request.ImageRef)
+}
+
+func TestDockerBuildAdmission(t *testing.T) {
+	t.Parallel()
+
+	// the base images are only known once the Dockerfile runs, so builds are
+	// denied under any policy
+	base := &baseSchema{
+		platform:  specs.Platform{OS: "linux", Architecture: "amd64"},
+		admission: admission.NewController(admission.Policy{}),
+	}
+	containers := &containerSchema{baseSchema: base}
+	directories := &directorySchema{baseSchema: base}
+
+	ctx := &router.Context{Context: context.Background()}
+
+	ctr, err := core.NewContainer("", nil, base.platform)
+	require.NoError(t, err)
+
+	var denied *admission.DeniedError
+
+	_, err = containers.build(ctx, ctr, containerBuildArgs{})
+	require.ErrorAs(t, err, &denied)
+
+	_, err = directories.dockerBuild(ctx, &core.Directory{}, dirDockerBuildArgs{})
+	require.ErrorAs(t, err, &denied)
+}
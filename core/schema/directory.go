@@ -3,6 +3,8 @@ package schema
 import (
 	"io/fs"
 
+	"github.com/containerd/containerd/content"
+	"github.com/dagger/dagger/admission"
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/core/pipeline"
 	"github.com/dagger/dagger/router"
@@ -13,7 +15,8 @@ import (
 type directorySchema struct {
 	*baseSchema
 
-	host *core.Host
+	host     *core.Host
+	ociStore content.Store
 }
 
 var _ router.ExecutableSchema = &directorySchema{}
@@ -33,11 +36,15 @@ func (s *directorySchema) Resolvers() router.Resolvers {
 		"DirectoryID": directoryIDResolver,
 		"Query": router.ObjectResolver{
 			"directory": router.ToResolver(s.directory),
+			"artifact":  router.ToResolver(s.artifact),
 		},
 		"Directory": router.ToIDableObjectResolver(core.DirectoryID.ToDirectory, router.ObjectResolver{
 			"id":               router.ToResolver(s.id),
+			"sync":             router.ToResolver(s.sync),
 			"pipeline":         router.ToResolver(s.pipeline),
 			"entries":          router.ToResolver(s.entries),
+			"glob":             router.ToResolver(s.glob),
+			"digest":           router.ToResolver(s.digest),
 			"file":             router.ToResolver(s.file),
 			"withFile":         router.ToResolver(s.withFile),
 			"withNewFile":      router.ToResolver(s.withNewFile),
@@ -49,6 +56,8 @@ func (s *directorySchema) Resolvers() router.Resolvers {
 			"withoutDirectory": router.ToResolver(s.withoutDirectory),
 			"diff":             router.ToResolver(s.diff),
 			"export":           router.ToResolver(s.export),
+			"archive":          router.ToResolver(s.archive),
+			"publish":          router.ToResolver(s.publish),
 			"dockerBuild":      router.ToResolver(s.dockerBuild),
 		}),
 	}
@@ -58,6 +67,14 @@ func (s *directorySchema) Dependencies() []router.ExecutableSchema {
 	return nil
 }
 
+func (s *directorySchema) sync(ctx *router.Context, parent *core.Directory, _ any) (core.DirectoryID, error) {
+	err := parent.Evaluate(ctx, s.gw)
+	if err != nil {
+		return "", err
+	}
+	return parent.ID()
+}
+
 type directoryPipelineArgs struct {
 	Name        string
 	Description string
@@ -81,6 +98,25 @@ func (s *directorySchema) directory(ctx *router.Context, parent *core.Query, arg
 	return core.NewDirectorySt(ctx, llb.Scratch(), "", parent.PipelinePath(), platform, nil)
 }
 
+type artifactArgs struct {
+	Address string
+}
+
+func (s *directorySchema) artifact(ctx *router.Context, parent *core.Query, args artifactArgs) (*core.Directory, error) {
+	if err := s.admission.Admit(ctx, admission.Request{
+		Operation: admission.OperationFrom,
+		ImageRef:  args.Address,
+	}); err != nil {
+		return nil, err
+	}
+
+	if s.offline {
+		return nil, core.ErrOffline
+	}
+
+	return core.LoadArtifact(ctx, args.Address, s.auth, s.ociStore, parent.PipelinePath(), s.platform)
+}
+
 func (s *directorySchema) id(ctx *router.Context, parent *core.Directory, args any) (core.DirectoryID, error) {
 	return parent.ID()
 }
@@ -133,6 +169,22 @@ func (s *directorySchema) entries(ctx *router.Context, parent *core.Directory, a
 	return parent.Entries(ctx, s.gw, args.Path)
 }
 
+type globArgs struct {
+	Pattern string
+}
+
+func (s *directorySchema) glob(ctx *router.Context, parent *core.Directory, args globArgs) ([]string, error) {
+	return parent.Glob(ctx, s.gw, args.Pattern)
+}
+
+func (s *directorySchema) digest(ctx *router.Context, parent *core.Directory, args any) (string, error) {
+	dgst, err := parent.ContentDigest(ctx, s.gw)
+	if err != nil {
+		return "", err
+	}
+	return dgst.String(), nil
+}
+
 type dirFileArgs struct {
 	Path string
 }
@@ -207,6 +259,28 @@ func (s *directorySchema) export(ctx *router.Context, parent *core.Directory, ar
 	return true, nil
 }
 
+type dirArchiveArgs struct {
+	Format      core.ArchiveFormat
+	Compression core.ArchiveCompression
+}
+
+func (s *directorySchema) archive(ctx *router.Context, parent *core.Directory, args dirArchiveArgs) (*core.File, error) {
+	return parent.Archive(ctx, s.gw, s.ociStore, args.Format, args.Compression)
+}
+
+type dirPublishArgs struct {
+	Address   string
+	MediaType string
+}
+
+func (s *directorySchema) publish(ctx *router.Context, parent *core.Directory, args dirPublishArgs) (string, error) {
+	if err := s.checkPublish(ctx, args.Address); err != nil {
+		return "", err
+	}
+
+	return parent.Publish(ctx, s.gw, args.Address, args.MediaType, s.auth)
+}
+
 type dirDockerBuildArgs struct {
 	Platform   *specs.Platform
 	Dockerfile string
@@ -216,6 +290,9 @@ type dirDockerBuildArgs struct {
 }
 
 func (s *directorySchema) dockerBuild(ctx *router.Context, parent *core.Directory, args dirDockerBuildArgs) (*core.Container, error) {
+	if err := s.checkDockerBuild(); err != nil {
+		return nil, err
+	}
 	platform := s.baseSchema.platform
 	if args.Platform != nil {
 		platform = *args.Platform
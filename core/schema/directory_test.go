@@ -0,0 +1,38 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/gatewaytest"
+	"github.com/dagger/dagger/router"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectorySyncScratch(t *testing.T) {
+	t.Parallel()
+
+	gw := gatewaytest.New(nil)
+	directories := &directorySchema{
+		baseSchema: &baseSchema{
+			gw:       gw,
+			platform: specs.Platform{OS: "linux", Architecture: "amd64"},
+		},
+	}
+
+	ctx := &router.Context{Context: context.Background()}
+
+	scratch := core.NewDirectory(ctx, nil, "", nil, directories.platform, nil)
+
+	id, err := directories.sync(ctx, scratch, nil)
+	require.NoError(t, err)
+
+	expected, err := scratch.ID()
+	require.NoError(t, err)
+	require.Equal(t, expected, id)
+
+	// there's nothing to evaluate
+	require.Empty(t, gw.Solves())
+}
@@ -35,7 +35,9 @@ func (s *fileSchema) Resolvers() router.Resolvers {
 			"secret":         router.ToResolver(s.secret),
 			"size":           router.ToResolver(s.size),
 			"export":         router.ToResolver(s.export),
+			"publish":        router.ToResolver(s.publish),
 			"withTimestamps": router.ToResolver(s.withTimestamps),
+			"unpack":         router.ToResolver(s.unpack),
 		}),
 	}
 }
@@ -92,6 +94,19 @@ func (s *fileSchema) export(ctx *router.Context, parent *core.File, args fileExp
 	return true, nil
 }
 
+type filePublishArgs struct {
+	Address   string
+	MediaType string
+}
+
+func (s *fileSchema) publish(ctx *router.Context, parent *core.File, args filePublishArgs) (string, error) {
+	if err := s.checkPublish(ctx, args.Address); err != nil {
+		return "", err
+	}
+
+	return parent.Publish(ctx, s.gw, args.Address, args.MediaType, s.auth)
+}
+
 type fileWithTimestampsArgs struct {
 	Timestamp int
 }
@@ -99,3 +114,7 @@ type fileWithTimestampsArgs struct {
 func (s *fileSchema) withTimestamps(ctx *router.Context, parent *core.File, args fileWithTimestampsArgs) (*core.File, error) {
 	return parent.WithTimestamps(ctx, args.Timestamp)
 }
+
+func (s *fileSchema) unpack(ctx *router.Context, parent *core.File, args any) (*core.Directory, error) {
+	return parent.Unpack(ctx, s.gw, s.host)
+}
@@ -35,5 +35,11 @@ var Platform string
 //go:embed socket.graphqls
 var Socket string
 
+//go:embed stack.graphqls
+var Stack string
+
+//go:embed service.graphqls
+var Service string
+
 //go:embed project.graphqls
 var Project string
@@ -122,6 +122,10 @@ type gitTreeArgs struct {
 }
 
 func (s *gitSchema) tree(ctx *router.Context, parent gitRef, args gitTreeArgs) (*core.Directory, error) {
+	if s.offline && parent.Repository.ServiceHost == nil {
+		return nil, core.ErrOffline
+	}
+
 	opts := []llb.GitOption{}
 
 	if parent.Repository.KeepGitDir {
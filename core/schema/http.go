@@ -39,6 +39,10 @@ type httpArgs struct {
 }
 
 func (s *httpSchema) http(ctx *router.Context, parent *core.Query, args httpArgs) (*core.File, error) {
+	if s.offline && args.ExperimentalServiceHost == nil {
+		return nil, core.ErrOffline
+	}
+
 	pipeline := parent.PipelinePath()
 
 	// Use a filename that is set to the URL. Buildkit internally stores some cache metadata of etags
@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/content/local"
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/router"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffline(t *testing.T) {
+	t.Parallel()
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	base := &baseSchema{
+		platform: specs.Platform{OS: "linux", Architecture: "amd64"},
+		offline:  true,
+	}
+	containers := &containerSchema{baseSchema: base, ociStore: store}
+	directories := &directorySchema{baseSchema: base, ociStore: store}
+	files := &fileSchema{baseSchema: base}
+
+	ctx := &router.Context{Context: context.Background()}
+
+	ctr, err := core.NewContainer("", nil, base.platform)
+	require.NoError(t, err)
+
+	for name, fetch := range map[string]func() error{
+		"Container.from": func() error {
+			_, err := containers.from(ctx, ctr, containerFromArgs{Address: "alpine:3.16"})
+			return err
+		},
+		"Container.build": func() error {
+			_, err := containers.build(ctx, ctr, containerBuildArgs{})
+			return err
+		},
+		"Directory.dockerBuild": func() error {
+			_, err := directories.dockerBuild(ctx, &core.Directory{}, dirDockerBuildArgs{})
+			return err
+		},
+		"Query.preloadImages": func() error {
+			_, err := containers.preloadImages(ctx, &core.Query{}, preloadImagesArgs{Addresses: []string{"alpine:3.16"}})
+			return err
+		},
+		"Query.artifact": func() error {
+			_, err := directories.artifact(ctx, &core.Query{}, artifactArgs{Address: "registry/artifact:latest"})
+			return err
+		},
+		"Container.publish": func() error {
+			_, err := containers.publish(ctx, ctr, containerPublishArgs{Address: "registry/image:latest"})
+			return err
+		},
+		"Directory.publish": func() error {
+			_, err := directories.publish(ctx, &core.Directory{}, dirPublishArgs{Address: "registry/artifact:latest"})
+			return err
+		},
+		"File.publish": func() error {
+			_, err := files.publish(ctx, &core.File{}, filePublishArgs{Address: "registry/artifact:latest"})
+			return err
+		},
+		"GitRef.tree": func() error {
+			_, err := (&gitSchema{base}).tree(ctx, gitRef{Repository: gitRepository{URL: "https://github.com/dagger/dagger"}, Name: "main"}, gitTreeArgs{})
+			return err
+		},
+		"Query.http": func() error {
+			_, err := (&httpSchema{base}).http(ctx, &core.Query{}, httpArgs{URL: "https://dagger.io"})
+			return err
+		},
+		"Project.load": func() error {
+			_, err := (&projectSchema{base}).load(ctx, &core.Project{}, loadArgs{})
+			return err
+		},
+	} {
+		require.ErrorIs(t, fetch(), core.ErrOffline, name)
+	}
+}
@@ -69,6 +69,10 @@ type loadArgs struct {
 }
 
 func (s *projectSchema) load(ctx *router.Context, parent *core.Project, args loadArgs) (*core.Project, error) {
+	if s.offline {
+		// project runtimes are built from images pulled from a registry
+		return nil, core.ErrOffline
+	}
 	source, err := args.Source.ToDirectory()
 	if err != nil {
 		return nil, err
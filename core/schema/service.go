@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/router"
+)
+
+type serviceSchema struct {
+	*baseSchema
+
+	running *core.Services
+}
+
+var _ router.ExecutableSchema = &serviceSchema{}
+
+func (s *serviceSchema) Name() string {
+	return "service"
+}
+
+func (s *serviceSchema) Schema() string {
+	return Service
+}
+
+var serviceIDResolver = stringResolver(core.ServiceID(""))
+
+func (s *serviceSchema) Resolvers() router.Resolvers {
+	return router.Resolvers{
+		"ServiceID": serviceIDResolver,
+		"Query": router.ObjectResolver{
+			"service": router.ToResolver(s.service),
+		},
+		"Service": router.ObjectResolver{
+			"id":        router.ToResolver(s.id),
+			"container": router.ToResolver(s.container),
+			"hostname":  router.ToResolver(s.hostname),
+			"endpoint":  router.ToResolver(s.endpoint),
+			"start":     router.ToResolver(s.start),
+			"stop":      router.ToResolver(s.stop),
+		},
+	}
+}
+
+func (s *serviceSchema) Dependencies() []router.ExecutableSchema {
+	return nil
+}
+
+type serviceArgs struct {
+	ID core.ServiceID
+}
+
+func (s *serviceSchema) service(ctx *router.Context, parent any, args serviceArgs) (*core.Container, error) {
+	if !s.servicesEnabled {
+		return nil, ErrServicesDisabled
+	}
+
+	return args.ID.ToContainer()
+}
+
+func (s *serviceSchema) id(ctx *router.Context, parent *core.Container, args any) (core.ServiceID, error) {
+	return parent.ServiceID()
+}
+
+func (s *serviceSchema) container(ctx *router.Context, parent *core.Container, args any) (*core.Container, error) {
+	return parent, nil
+}
+
+func (s *serviceSchema) hostname(ctx *router.Context, parent *core.Container, args any) (string, error) {
+	return parent.HostnameOrErr()
+}
+
+func (s *serviceSchema) endpoint(ctx *router.Context, parent *core.Container, args containerEndpointArgs) (string, error) {
+	return parent.Endpoint(args.Port, args.Scheme)
+}
+
+func (s *serviceSchema) start(ctx *router.Context, parent *core.Container, args any) (core.ServiceID, error) {
+	if _, err := s.running.Start(ctx, s.gw, parent); err != nil {
+		return "", err
+	}
+
+	return parent.ServiceID()
+}
+
+func (s *serviceSchema) stop(ctx *router.Context, parent *core.Container, args any) (core.ServiceID, error) {
+	if err := s.running.Stop(parent); err != nil {
+		return "", err
+	}
+
+	return parent.ServiceID()
+}
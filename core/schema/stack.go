@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/router"
+)
+
+type stackSchema struct {
+	*baseSchema
+
+	containers *containerSchema
+}
+
+var _ router.ExecutableSchema = &stackSchema{}
+
+func (s *stackSchema) Name() string {
+	return "stack"
+}
+
+func (s *stackSchema) Schema() string {
+	return Stack
+}
+
+var stackIDResolver = stringResolver(core.StackID(""))
+
+func (s *stackSchema) Resolvers() router.Resolvers {
+	return router.Resolvers{
+		"StackID": stackIDResolver,
+		"Query": router.ObjectResolver{
+			"stack": router.ToResolver(s.stack),
+		},
+		"Stack": router.ObjectResolver{
+			"id":          router.ToResolver(s.id),
+			"withService": router.ToResolver(s.withService),
+			"services":    router.ToResolver(s.services),
+			"service":     router.ToResolver(s.service),
+		},
+	}
+}
+
+func (s *stackSchema) Dependencies() []router.ExecutableSchema {
+	return nil
+}
+
+type stackArgs struct {
+	ID core.StackID
+}
+
+func (s *stackSchema) stack(ctx *router.Context, parent any, args stackArgs) (*core.Stack, error) {
+	if !s.servicesEnabled {
+		return nil, ErrServicesDisabled
+	}
+
+	return args.ID.ToStack()
+}
+
+func (s *stackSchema) id(ctx *router.Context, parent *core.Stack, args any) (core.StackID, error) {
+	return parent.ID()
+}
+
+type stackWithServiceArgs struct {
+	Name      string
+	Container core.ContainerID
+	DependsOn []string
+}
+
+func (s *stackSchema) withService(ctx *router.Context, parent *core.Stack, args stackWithServiceArgs) (*core.Stack, error) {
+	return parent.WithService(args.Name, args.Container, args.DependsOn)
+}
+
+func (s *stackSchema) services(ctx *router.Context, parent *core.Stack, args any) ([]string, error) {
+	return parent.ServiceNames(), nil
+}
+
+type stackServiceArgs struct {
+	Name string
+}
+
+func (s *stackSchema) service(ctx *router.Context, parent *core.Stack, args stackServiceArgs) (*core.Container, error) {
+	return parent.Service(args.Name, s.containers.stackExec(ctx))
+}
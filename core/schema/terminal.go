@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/audit"
+	"github.com/dagger/dagger/core"
+)
+
+// TerminalRequest is the body POSTed to the terminal endpoint.
+type TerminalRequest struct {
+	// The container to open a terminal in.
+	Container core.ContainerID `json:"container"`
+
+	// The command to run; defaults to sh.
+	Args []string `json:"args,omitempty"`
+
+	// The initial size of the terminal.
+	Rows uint32 `json:"rows,omitempty"`
+	Cols uint32 `json:"cols,omitempty"`
+}
+
+// terminalOperation is the operation recorded in the audit log when a
+// terminal is opened.
+const terminalOperation = "terminal"
+
+// TerminalHandler serves interactive terminals into containers, e.g. to debug
+// the state of a container before a failing exec. The command is run like
+// Container.withExec would run it, so it is subject to the same admission
+// checks, and each terminal is recorded in the audit log.
+//
+// Once the request is accepted, the connection is upgraded to a raw stream:
+// the client's input is sent to the process, which runs with a TTY, and its
+// output is sent back until it exits.
+//
+// The handler is meant to be registered with Router.Handle, so it is only
+// served to clients with unrestricted access; clients restricted by a policy
+// can't open terminals.
+func TerminalHandler(params InitializeArgs) http.Handler {
+	return &terminalHandler{newBaseSchema(params)}
+}
+
+type terminalHandler struct {
+	*baseSchema
+}
+
+func (s *terminalHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var treq TerminalRequest
+	if err := json.NewDecoder(req.Body).Decode(&treq); err != nil {
+		http.Error(w, fmt.Sprintf("decode request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	container, err := treq.Container.ToContainer()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decode container: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	args := treq.Args
+	if len(args) == 0 {
+		args = []string{"sh"}
+	}
+
+	ctx := req.Context()
+
+	auditArgs := map[string]any{
+		"container": treq.Container,
+		"args":      audit.Redacted,
+	}
+
+	if err := s.admission.Admit(ctx, admission.Request{
+		Operation: admission.OperationExec,
+		Args:      args,
+	}); err != nil {
+		audit.Record(ctx, terminalOperation, auditArgs, err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection does not support streaming", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = core.WithServices(ctx, s.gw, container.Services, func() (any, error) {
+		// set up the container before upgrading, so that errors can still be
+		// reported as a response
+		progSock := &core.Socket{HostPath: s.progSock}
+		term, err := container.NewTerminal(ctx, s.gw, progSock, s.platform, s.secrets.GetSecret, core.ContainerExecOpts{
+			Args:    args,
+			Offline: s.offline,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer term.Release(context.Background())
+
+		audit.Record(ctx, terminalOperation, auditArgs, nil)
+
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return nil, nil
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: dagger-terminal\r\n\r\n")
+
+		if err := term.Run(ctx, io.NopCloser(rw.Reader), nopWriteCloser{conn}, treq.Rows, treq.Cols); err != nil {
+			fmt.Fprintf(conn, "\r\n%s\r\n", err)
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		audit.Record(ctx, terminalOperation, auditArgs, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,144 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/audit"
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/gatewaytest"
+	"github.com/dagger/dagger/secret"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerminal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {},
+	})
+
+	// deny removing anything
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req admission.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res := admission.Response{Allowed: true}
+		if req.Operation == admission.OperationExec && req.Args[0] == "rm" {
+			res = admission.Response{Allowed: false, Reason: "no deleting"}
+		}
+
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer webhook.Close()
+
+	logPath := filepath.Join(t.TempDir(), "audit.json")
+	log, err := audit.Open(logPath)
+	require.NoError(t, err)
+
+	handler := TerminalHandler(InitializeArgs{
+		Gateway:   core.NewGatewayClient(gw, "", nil),
+		Platform:  specs.Platform{OS: "linux", Architecture: "amd64"},
+		Secrets:   secret.NewStore(),
+		Admission: admission.NewController(admission.Policy{Webhook: webhook.URL}),
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(audit.ToContext(r.Context(), log)))
+	}))
+	defer srv.Close()
+
+	ctr, err := core.NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+	ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+	require.NoError(t, err)
+	ctrID, err := ctr.ID()
+	require.NoError(t, err)
+
+	open := func(args ...string) (*http.Response, string) {
+		body, err := json.Marshal(TerminalRequest{Container: ctrID, Args: args})
+		require.NoError(t, err)
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/terminal", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, req.Write(conn))
+
+		connR := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(connR, req)
+		require.NoError(t, err)
+
+		out := io.Reader(resp.Body)
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			// the process exits right away, closing the stream
+			out = connR
+		}
+
+		rest, err := io.ReadAll(out)
+		require.NoError(t, err)
+
+		return resp, string(rest)
+	}
+
+	resp, msg := open("rm", "-rf", "/")
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Contains(t, msg, "no deleting")
+	require.Empty(t, gw.Containers())
+
+	resp, _ = open()
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	containers := gw.Containers()
+	require.Len(t, containers, 1)
+	require.True(t, containers[0].Released())
+
+	// refs are passed to the gateway unwrapped
+	require.IsType(t, &gatewaytest.Ref{}, containers[0].Request.Mounts[0].Ref)
+
+	starts := containers[0].Starts()
+	require.Len(t, starts, 1)
+	require.Equal(t, []string{"sh"}, starts[0].Args)
+	require.True(t, starts[0].Tty)
+
+	require.NoError(t, log.Close())
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+
+	var entries []audit.Entry
+	dec := json.NewDecoder(strings.NewReader(string(content)))
+	for dec.More() {
+		var entry audit.Entry
+		require.NoError(t, dec.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	require.Equal(t, "terminal", entries[0].Operation)
+	require.Equal(t, audit.Redacted, entries[0].Args["args"])
+	require.Contains(t, entries[0].Error, "no deleting")
+
+	require.Equal(t, "terminal", entries[1].Operation)
+	require.Equal(t, audit.Redacted, entries[1].Args["args"])
+	require.Empty(t, entries[1].Error)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,6 +20,129 @@ import (
 type Service struct {
 	Container *Container
 	Detach    func()
+
+	// Exited is closed once the service stops running, whether it was
+	// detached or exited on its own.
+	Exited <-chan struct{}
+}
+
+// ServiceID is an encoded container which is run as a service.
+type ServiceID string
+
+func (id ServiceID) ToContainer() (*Container, error) {
+	return ContainerID(id).ToContainer()
+}
+
+func (container *Container) ServiceID() (ServiceID, error) {
+	id, err := container.ID()
+	if err != nil {
+		return "", err
+	}
+
+	return ServiceID(id), nil
+}
+
+// Services tracks services which were started explicitly, rather than for the
+// duration of an exec, so that they can be stopped later.
+type Services struct {
+	ctx     context.Context
+	l       sync.Mutex
+	running map[string]*runningService
+}
+
+type runningService struct {
+	wg  sync.WaitGroup
+	svc *Service
+	err error
+}
+
+// NewServices returns an empty set of services, which are stopped once the
+// given session context is done.
+func NewServices(ctx context.Context) *Services {
+	return &Services{
+		ctx:     ctx,
+		running: map[string]*runningService{},
+	}
+}
+
+// Start starts the service and waits for it to pass its health check. If
+// the service is already running, it is left as-is.
+func (ss *Services) Start(ctx context.Context, gw bkgw.Client, container *Container) (*Service, error) {
+	host, err := container.HostnameOrErr()
+	if err != nil {
+		return nil, err
+	}
+
+	ss.l.Lock()
+	if r, ok := ss.running[host]; ok {
+		ss.l.Unlock()
+		r.wg.Wait()
+		return r.svc, r.err
+	}
+
+	r := &runningService{}
+	r.wg.Add(1)
+	ss.running[host] = r
+	ss.l.Unlock()
+
+	r.svc, r.err = container.Start(ctx, gw)
+	r.wg.Done()
+
+	if r.err != nil {
+		ss.forget(host, r)
+		return nil, r.err
+	}
+
+	go ss.watch(host, r)
+
+	return r.svc, nil
+}
+
+// watch stops the service when the session ends, and forgets it once it
+// exits so that it can be started again.
+func (ss *Services) watch(host string, r *runningService) {
+	select {
+	case <-r.svc.Exited:
+	case <-ss.ctx.Done():
+	}
+
+	r.svc.Detach()
+	ss.forget(host, r)
+}
+
+// forget removes the service from the running set, unless it has already been
+// replaced.
+func (ss *Services) forget(host string, r *runningService) {
+	ss.l.Lock()
+	if ss.running[host] == r {
+		delete(ss.running, host)
+	}
+	ss.l.Unlock()
+}
+
+// Stop stops the service if it was started by Start.
+func (ss *Services) Stop(container *Container) error {
+	host, err := container.HostnameOrErr()
+	if err != nil {
+		return err
+	}
+
+	ss.l.Lock()
+	r, ok := ss.running[host]
+	delete(ss.running, host)
+	ss.l.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	r.wg.Wait()
+
+	if r.svc != nil {
+		r.svc.Detach()
+	}
+
+	return nil
 }
 
 type ServiceBindings map[ContainerID]AliasSet
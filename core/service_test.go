@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicesWatch(t *testing.T) {
+	t.Parallel()
+
+	start := func(ss *Services, host string) (chan struct{}, <-chan struct{}) {
+		exited := make(chan struct{})
+		detached := make(chan struct{})
+
+		var once sync.Once
+		r := &runningService{
+			svc: &Service{
+				Container: &Container{Hostname: host},
+				Detach:    func() { once.Do(func() { close(detached) }) },
+				Exited:    exited,
+			},
+		}
+
+		ss.l.Lock()
+		ss.running[host] = r
+		ss.l.Unlock()
+
+		go ss.watch(host, r)
+
+		return exited, detached
+	}
+
+	isRunning := func(ss *Services, host string) bool {
+		ss.l.Lock()
+		defer ss.l.Unlock()
+		_, found := ss.running[host]
+		return found
+	}
+
+	t.Run("crashed services are forgotten", func(t *testing.T) {
+		ss := NewServices(context.Background())
+
+		exited, _ := start(ss, "crashy")
+		require.True(t, isRunning(ss, "crashy"))
+
+		close(exited)
+
+		require.Eventually(t, func() bool {
+			return !isRunning(ss, "crashy")
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("services are stopped with the session", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ss := NewServices(ctx)
+
+		_, detached := start(ss, "db")
+
+		cancel()
+
+		select {
+		case <-detached:
+		case <-time.After(10 * time.Second):
+			t.Fatal("service was not detached")
+		}
+
+		require.Eventually(t, func() bool {
+			return !isRunning(ss, "db")
+		}, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("restarted services are kept", func(t *testing.T) {
+		ss := NewServices(context.Background())
+
+		exited, _ := start(ss, "web")
+
+		// replace the entry as if the service was stopped and started again
+		restarted := &runningService{svc: &Service{}}
+		ss.l.Lock()
+		ss.running["web"] = restarted
+		ss.l.Unlock()
+
+		close(exited)
+
+		require.Never(t, func() bool {
+			return !isRunning(ss, "web")
+		}, 100*time.Millisecond, 10*time.Millisecond)
+	})
+}
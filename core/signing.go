@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// cosignPayloadMediaType is the media type of the payload layers of a
+	// cosign signature image.
+	cosignPayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// cosignSignatureAnnotation holds the base64-encoded signature of a
+	// payload layer.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// cosignSignatureType is the type of cosign's payloads.
+	cosignSignatureType = "cosign container image signature"
+)
+
+// cosignPayload is the "simple signing" payload cosign signs, which binds the
+// repository to the image's digest.
+type cosignPayload struct {
+	Critical cosignCritical `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+type cosignCritical struct {
+	Identity cosignIdentity `json:"identity"`
+	Image    cosignImage    `json:"image"`
+	Type     string         `json:"type"`
+}
+
+type cosignIdentity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type cosignImage struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// SignImage signs the image at address, which must include its digest, and
+// pushes the signature next to it like cosign does: as a layer of the image
+// tagged sha256-<digest>.sig in the same repository. Signatures previously
+// pushed for the image are kept.
+//
+// The key is a PEM-encoded ECDSA, RSA or Ed25519 private key. Encrypted cosign
+// keys, as created by cosign generate-key-pair, are decrypted with password.
+// The signature isn't recorded in a transparency log, so it must be verified
+// with cosign verify --insecure-ignore-tlog.
+func SignImage(ctx context.Context, address string, key, password []byte, keychain authn.Keychain) error {
+	ref, err := name.NewDigest(address)
+	if err != nil {
+		return fmt.Errorf("signing requires the image digest: %w", err)
+	}
+
+	signer, err := parseSigningKey(key, password)
+	if err != nil {
+		return fmt.Errorf("signing key: %w", err)
+	}
+
+	payload, err := json.Marshal(cosignPayload{
+		Critical: cosignCritical{
+			Identity: cosignIdentity{DockerReference: ref.Context().Name()},
+			Image:    cosignImage{DockerManifestDigest: ref.DigestStr()},
+			Type:     cosignSignatureType,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	sig, err := signPayload(signer, payload)
+	if err != nil {
+		return fmt.Errorf("sign %s: %w", address, err)
+	}
+
+	sigRef := cosignSignatureTag(ref)
+
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+	}
+
+	sigImg, err := remote.Image(sigRef, opts...)
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("pull %s: %w", sigRef, err)
+		}
+
+		sigImg = mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+		sigImg = mutate.ConfigMediaType(sigImg, types.OCIConfigJSON)
+	}
+
+	sigImg, err = mutate.Append(sigImg, mutate.Addendum{
+		Layer: static.NewLayer(payload, cosignPayloadMediaType),
+		Annotations: map[string]string{
+			cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := remote.Write(sigRef, sigImg, opts...); err != nil {
+		return fmt.Errorf("push %s: %w", sigRef, err)
+	}
+
+	return nil
+}
+
+// cosignSignatureTag returns the tag cosign stores an image's signatures at.
+func cosignSignatureTag(ref name.Digest) name.Tag {
+	return ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + ".sig")
+}
+
+// signPayload signs payload like cosign: Ed25519 keys sign it directly, and
+// other keys sign its SHA-256 digest.
+func signPayload(signer crypto.Signer, payload []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+
+	digest := sha256.Sum256(payload)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// encryptedCosignKey is the JSON body of an encrypted cosign private key.
+type encryptedCosignKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// parseSigningKey parses a PEM-encoded private key, decrypting cosign's
+// encrypted keys with password.
+func parseSigningKey(key, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY":
+		der, decErr := decryptCosignKey(block.Bytes, password)
+		if decErr != nil {
+			return nil, decErr
+		}
+		parsed, err = x509.ParsePKCS8PrivateKey(der)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return signer, nil
+}
+
+func decryptCosignKey(body, password []byte) ([]byte, error) {
+	var enc encryptedCosignKey
+	if err := json.Unmarshal(body, &enc); err != nil {
+		return nil, fmt.Errorf("parse encrypted key: %w", err)
+	}
+
+	if enc.KDF.Name != "scrypt" || enc.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported key encryption %s/%s", enc.KDF.Name, enc.Cipher.Name)
+	}
+
+	if len(enc.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("invalid nonce length %d", len(enc.Cipher.Nonce))
+	}
+
+	derived, err := scrypt.Key(password, enc.KDF.Salt, enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretKey [32]byte
+	copy(secretKey[:], derived)
+
+	var nonce [24]byte
+	copy(nonce[:], enc.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, enc.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, fmt.Errorf("decrypt key: wrong password")
+	}
+
+	return der, nil
+}
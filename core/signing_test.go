@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestSignImage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	tag, err := name.NewTag(host + "/app:v1")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+
+	dig, err := img.Digest()
+	require.NoError(t, err)
+
+	address := tag.Context().Digest(dig.String()).String()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	require.NoError(t, SignImage(ctx, address, pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: ecDER,
+	}), nil, authn.DefaultKeychain))
+
+	require.NoError(t, SignImage(ctx, address, encryptCosignKey(t, edDER, "hunter2"), []byte("hunter2"), authn.DefaultKeychain))
+
+	sigImg, err := remote.Image(tag.Context().Tag(strings.Replace(dig.String(), ":", "-", 1) + ".sig"))
+	require.NoError(t, err)
+
+	manifest, err := sigImg.Manifest()
+	require.NoError(t, err)
+
+	// both signatures are kept
+	require.Len(t, manifest.Layers, 2)
+
+	layers, err := sigImg.Layers()
+	require.NoError(t, err)
+
+	payloads := make([][]byte, len(layers))
+	sigs := make([][]byte, len(layers))
+	for i, layer := range layers {
+		require.Equal(t, cosignPayloadMediaType, string(manifest.Layers[i].MediaType))
+
+		rc, err := layer.Compressed()
+		require.NoError(t, err)
+		payloads[i], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+
+		sigs[i], err = base64.StdEncoding.DecodeString(manifest.Layers[i].Annotations[cosignSignatureAnnotation])
+		require.NoError(t, err)
+	}
+
+	var payload cosignPayload
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	require.Equal(t, tag.Context().Name(), payload.Critical.Identity.DockerReference)
+	require.Equal(t, dig.String(), payload.Critical.Image.DockerManifestDigest)
+	require.Equal(t, cosignSignatureType, payload.Critical.Type)
+
+	digest := sha256.Sum256(payloads[0])
+	require.True(t, ecdsa.VerifyASN1(&ecKey.PublicKey, digest[:], sigs[0]))
+
+	require.True(t, ed25519.Verify(edKey.Public().(ed25519.PublicKey), payloads[1], sigs[1]))
+}
+
+func TestSignImageErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+
+	key := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecDER})
+
+	err = SignImage(ctx, "registry/app:v1", key, nil, authn.DefaultKeychain)
+	require.ErrorContains(t, err, "signing requires the image digest")
+
+	address := "registry/app@sha256:" + strings.Repeat("0", 64)
+
+	err = SignImage(ctx, address, []byte("not a key"), nil, authn.DefaultKeychain)
+	require.ErrorContains(t, err, "no PEM block found")
+
+	err = SignImage(ctx, address, encryptCosignKey(t, ecDER, "hunter2"), []byte("wrong"), authn.DefaultKeychain)
+	require.ErrorContains(t, err, "wrong password")
+}
+
+// encryptCosignKey encrypts a PKCS#8 key like cosign generate-key-pair, with
+// a cheaper scrypt cost.
+func encryptCosignKey(t *testing.T, der []byte, password string) []byte {
+	t.Helper()
+
+	var enc encryptedCosignKey
+	enc.KDF.Name = "scrypt"
+	enc.KDF.Params.N = 1024
+	enc.KDF.Params.R = 8
+	enc.KDF.Params.P = 1
+	enc.KDF.Salt = make([]byte, 32)
+	enc.Cipher.Name = "nacl/secretbox"
+	enc.Cipher.Nonce = make([]byte, 24)
+
+	_, err := rand.Read(enc.KDF.Salt)
+	require.NoError(t, err)
+	_, err = rand.Read(enc.Cipher.Nonce)
+	require.NoError(t, err)
+
+	derived, err := scrypt.Key([]byte(password), enc.KDF.Salt, enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P, 32)
+	require.NoError(t, err)
+
+	var secretKey [32]byte
+	copy(secretKey[:], derived)
+
+	var nonce [24]byte
+	copy(nonce[:], enc.Cipher.Nonce)
+
+	enc.Ciphertext = secretbox.Seal(nil, der, &nonce, &secretKey)
+
+	body, err := json.Marshal(enc)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "ENCRYPTED SIGSTORE PRIVATE KEY",
+		Bytes: body,
+	})
+}
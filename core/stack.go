@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Stack is a named set of services which are brought up and down together,
+// in the style of a Compose file.
+type Stack struct {
+	Services []StackService `json:"services"`
+}
+
+// StackService is a service in a stack.
+type StackService struct {
+	// Name of the service, which is also the hostname that other services and
+	// clients of the stack use to reach it.
+	Name string `json:"name"`
+
+	// Container to run the service in, using its default command.
+	Container ContainerID `json:"container"`
+
+	// Names of the services which this service depends on.
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+var ErrInvalidStackID = errors.New("invalid stack ID; create one using stack")
+
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// StackID is an encoded Stack.
+type StackID string
+
+func (id StackID) ToStack() (*Stack, error) {
+	var stack Stack
+	if id == "" {
+		return &stack, nil
+	}
+
+	if err := decodeID(&stack, id); err != nil {
+		return nil, ErrInvalidStackID
+	}
+
+	return &stack, nil
+}
+
+func (stack *Stack) ID() (StackID, error) {
+	return encodeID[StackID](stack)
+}
+
+func (stack *Stack) Clone() *Stack {
+	cp := *stack
+	cp.Services = cloneSlice(cp.Services)
+	return &cp
+}
+
+// WithService returns the stack with the given service added, replacing any
+// existing service with the same name.
+func (stack *Stack) WithService(name string, ctr ContainerID, dependsOn []string) (*Stack, error) {
+	if name == "" {
+		return nil, fmt.Errorf("service name must not be empty")
+	}
+
+	stack = stack.Clone()
+
+	svc := StackService{
+		Name:      name,
+		Container: ctr,
+		DependsOn: dependsOn,
+	}
+
+	for i, existing := range stack.Services {
+		if existing.Name == name {
+			stack.Services[i] = svc
+			return stack, nil
+		}
+	}
+
+	stack.Services = append(stack.Services, svc)
+
+	return stack, nil
+}
+
+// ServiceNames returns the names of the stack's services in the order they
+// were added.
+func (stack *Stack) ServiceNames() []string {
+	names := make([]string, 0, len(stack.Services))
+	for _, svc := range stack.Services {
+		names = append(names, svc.Name)
+	}
+	return names
+}
+
+// StackExecFunc runs a service container's default command.
+type StackExecFunc func(*Container) (*Container, error)
+
+// Service returns the container running the named service, with each of its
+// dependencies bound to it under their names.
+func (stack *Stack) Service(name string, exec StackExecFunc) (*Container, error) {
+	return stack.service(name, exec, map[string]bool{})
+}
+
+func (stack *Stack) service(name string, exec StackExecFunc, visiting map[string]bool) (*Container, error) {
+	var svc *StackService
+	for i := range stack.Services {
+		if stack.Services[i].Name == name {
+			svc = &stack.Services[i]
+			break
+		}
+	}
+
+	if svc == nil {
+		return nil, fmt.Errorf("stack has no service %q", name)
+	}
+
+	if visiting[name] {
+		return nil, fmt.Errorf("service %q has a circular dependency", name)
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	ctr, err := svc.Container.ToContainer()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dep := range svc.DependsOn {
+		depCtr, err := stack.service(dep, exec, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", name, err)
+		}
+
+		ctr, err = ctr.WithServiceBinding(depCtr, dep)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// like Compose, run the container's default command now that its
+	// dependencies are reachable
+	ctr, err = exec(ctr)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+
+	return ctr, nil
+}
+
+// WithStack binds every service in the stack to the container under their
+// names. The services are started before the container's next command runs
+// and stopped once it exits.
+func (container *Container) WithStack(stack *Stack, exec StackExecFunc) (*Container, error) {
+	for _, name := range stack.ServiceNames() {
+		svc, err := stack.Service(name, exec)
+		if err != nil {
+			return nil, err
+		}
+
+		container, err = container.WithServiceBinding(svc, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return container, nil
+}
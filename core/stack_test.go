@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackService(t *testing.T) {
+	t.Parallel()
+
+	ctrID, err := (&Container{}).ID()
+	require.NoError(t, err)
+
+	started := []string{}
+	exec := func(ctr *Container) (*Container, error) {
+		ctr = ctr.Clone()
+		ctr.Hostname = "svc" + string(rune('a'+len(started)))
+		started = append(started, ctr.Hostname)
+		return ctr, nil
+	}
+
+	stack, err := NewStack().WithService("db", ctrID, nil)
+	require.NoError(t, err)
+	stack, err = stack.WithService("web", ctrID, []string{"db"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"db", "web"}, stack.ServiceNames())
+
+	web, err := stack.Service("web", exec)
+	require.NoError(t, err)
+	require.Equal(t, []string{"svca", "svcb"}, started)
+	require.Len(t, web.Services, 1)
+	require.Equal(t, []HostAlias{{Alias: "db", Target: "svca"}}, web.HostAliases)
+
+	_, err = stack.Service("cache", exec)
+	require.ErrorContains(t, err, `stack has no service "cache"`)
+
+	// replacing a service keeps its position
+	stack, err = stack.WithService("db", ctrID, []string{"web"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"db", "web"}, stack.ServiceNames())
+
+	_, err = stack.Service("web", exec)
+	require.ErrorContains(t, err, "circular dependency")
+}
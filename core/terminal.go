@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Terminal is a container set up to run an interactive process.
+type Terminal struct {
+	ctr bkgw.Container
+	req bkgw.StartRequest
+}
+
+// NewTerminal sets up a container to run a command with a TTY, as configured
+// by opts, ignoring the entrypoint.
+//
+// The container is derived from the exec that WithExec would run, so it has
+// the same environment, mounts (including cache mounts), secrets, sockets,
+// hostname and extra hosts. Only the exec's metadata mount is left out, so the
+// process doesn't run under the shim and its output isn't captured.
+//
+// Secrets exposed as environment variables are looked up with getSecret.
+// Services the container is bound to are not started; see WithServices.
+func (container *Container) NewTerminal(
+	ctx context.Context,
+	gw bkgw.Client,
+	progSock *Socket,
+	defaultPlatform specs.Platform,
+	getSecret func(context.Context, string) ([]byte, error),
+	opts ContainerExecOpts,
+) (*Terminal, error) {
+	if container.FS == nil {
+		return nil, fmt.Errorf("container has no rootfs")
+	}
+
+	opts.SkipEntrypoint = true
+
+	execCtr, err := container.WithExec(ctx, gw, progSock, defaultPlatform, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	def := execCtr.FS
+
+	op, err := rootExec(def)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := op.GetExec()
+
+	mounts := []bkgw.Mount{}
+	for _, mnt := range exec.Mounts {
+		if mnt.Dest == metaMountDestPath {
+			continue
+		}
+
+		gwMnt := bkgw.Mount{
+			Selector:  mnt.Selector,
+			Dest:      mnt.Dest,
+			Readonly:  mnt.Readonly,
+			MountType: mnt.MountType,
+			CacheOpt:  mnt.CacheOpt,
+			SecretOpt: mnt.SecretOpt,
+			SSHOpt:    mnt.SSHOpt,
+		}
+
+		// secret and SSH mounts don't have inputs, even if they name one
+		hasInput := mnt.MountType == pb.MountType_BIND || mnt.MountType == pb.MountType_CACHE
+		if hasInput && mnt.Input != pb.Empty {
+			inputDef, err := inputDefinition(def, op.Inputs[mnt.Input])
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := gw.Solve(ctx, bkgw.SolveRequest{
+				Definition: inputDef,
+				Evaluate:   true,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("solve mount %s: %w", mnt.Dest, err)
+			}
+
+			gwMnt.Ref, err = res.SingleRef()
+			if err != nil {
+				return nil, fmt.Errorf("solve mount %s: %w", mnt.Dest, err)
+			}
+		}
+
+		mounts = append(mounts, gwMnt)
+	}
+
+	env := append([]string{}, exec.Meta.Env...)
+	for _, secretEnv := range exec.Secretenv {
+		plaintext, err := getSecret(ctx, secretEnv.ID)
+		if err != nil {
+			if secretEnv.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("secret env %s: %w", secretEnv.Name, err)
+		}
+
+		env = AddEnv(env, secretEnv.Name, string(plaintext))
+	}
+
+	if _, ok := LookupEnv(env, "TERM"); !ok {
+		env = AddEnv(env, "TERM", "xterm")
+	}
+
+	ctr, err := gw.NewContainer(ctx, bkgw.NewContainerRequest{
+		Mounts:      mounts,
+		Hostname:    exec.Meta.Hostname,
+		NetMode:     exec.Network,
+		ExtraHosts:  exec.Meta.ExtraHosts,
+		Platform:    op.Platform,
+		Constraints: op.Constraints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Terminal{
+		ctr: ctr,
+		req: bkgw.StartRequest{
+			Args:         exec.Meta.Args,
+			Env:          env,
+			User:         exec.Meta.User,
+			Cwd:          exec.Meta.Cwd,
+			Tty:          true,
+			SecurityMode: exec.Security,
+		},
+	}, nil
+}
+
+// Run starts the process, resizes its TTY if rows and cols are set, and
+// waits for it to exit.
+func (term *Terminal) Run(ctx context.Context, stdin io.ReadCloser, stdout io.WriteCloser, rows, cols uint32) error {
+	req := term.req
+	req.Stdin = stdin
+	req.Stdout = stdout
+
+	proc, err := term.ctr.Start(ctx, req)
+	if err != nil {
+		return fmt.Errorf("start %s: %w", strings.Join(req.Args, " "), err)
+	}
+
+	if rows > 0 && cols > 0 {
+		_ = proc.Resize(ctx, bkgw.WinSize{Rows: rows, Cols: cols})
+	}
+
+	return proc.Wait()
+}
+
+// Release removes the terminal's container.
+func (term *Terminal) Release(ctx context.Context) error {
+	return term.ctr.Release(ctx)
+}
+
+// rootExec returns the exec op whose output a definition returns.
+func rootExec(def *pb.Definition) (*pb.Op, error) {
+	if len(def.Def) == 0 {
+		return nil, fmt.Errorf("empty definition")
+	}
+
+	var ret pb.Op
+	if err := (&ret).Unmarshal(def.Def[len(def.Def)-1]); err != nil {
+		return nil, err
+	}
+
+	if len(ret.Inputs) != 1 {
+		return nil, fmt.Errorf("definition returns %d inputs, expected 1", len(ret.Inputs))
+	}
+
+	for _, dt := range def.Def {
+		if digest.FromBytes(dt) != ret.Inputs[0].Digest {
+			continue
+		}
+
+		var op pb.Op
+		if err := (&op).Unmarshal(dt); err != nil {
+			return nil, err
+		}
+
+		if op.GetExec() == nil {
+			return nil, fmt.Errorf("definition does not return an exec")
+		}
+
+		return &op, nil
+	}
+
+	return nil, fmt.Errorf("definition does not contain its output")
+}
+
+// inputDefinition returns a definition which returns the given input of an op
+// in def instead of def's own output.
+func inputDefinition(def *pb.Definition, input *pb.Input) (*pb.Definition, error) {
+	ret, err := (&pb.Op{Inputs: []*pb.Input{input}}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	ops := append([][]byte{}, def.Def[:len(def.Def)-1]...)
+
+	return &pb.Definition{
+		Def:      append(ops, ret),
+		Metadata: def.Metadata,
+	}, nil
+}
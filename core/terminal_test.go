@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/dagger/dagger/core/gatewaytest"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerTerminal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:3.16": {
+			Config: specs.ImageConfig{
+				Env:        []string{"PATH=/usr/bin:/bin"},
+				Entrypoint: []string{"/entrypoint"},
+				WorkingDir: "/root",
+				User:       "app",
+			},
+		},
+	})
+
+	ctr, err := NewContainer("", nil, specs.Platform{OS: "linux", Architecture: "amd64"})
+	require.NoError(t, err)
+
+	ctr, err = ctr.From(ctx, gw, "alpine:3.16")
+	require.NoError(t, err)
+
+	token := NewDynamicSecret("token")
+	tokenID, err := token.ID()
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithSecretVariable(ctx, "TOKEN", token)
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithMountedSecret(ctx, gw, "/run/token", token, "")
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithMountedCache(ctx, gw, "/cache", NewCache("go-mod"), nil, CacheSharingModeLocked, "")
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithMountedTemp(ctx, "/tmp", 0)
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithUnixSocket(ctx, gw, "/run/docker.sock", &Socket{HostPath: "/var/run/docker.sock"}, "")
+	require.NoError(t, err)
+
+	getSecret := func(_ context.Context, id string) ([]byte, error) {
+		if id != tokenID.String() {
+			return nil, errors.New("not found")
+		}
+		return []byte("hunter2"), nil
+	}
+
+	term, err := ctr.NewTerminal(ctx, gw, &Socket{}, ctr.Platform, getSecret, ContainerExecOpts{
+		Args: []string{"bash"},
+	})
+	require.NoError(t, err)
+
+	containers := gw.Containers()
+	require.Len(t, containers, 1)
+
+	req := containers[0].Request
+	require.NotEmpty(t, req.Hostname)
+
+	mounts := map[string]bkgw.Mount{}
+	for _, mnt := range req.Mounts {
+		mounts[mnt.Dest] = mnt
+	}
+
+	// the exec's metadata mount is left out
+	require.Len(t, mounts, 5)
+
+	require.Equal(t, pb.MountType_BIND, mounts["/"].MountType)
+	require.NotNil(t, mounts["/"].Ref)
+
+	require.Equal(t, pb.MountType_CACHE, mounts["/cache"].MountType)
+	require.Equal(t, pb.CacheSharingOpt_LOCKED, mounts["/cache"].CacheOpt.Sharing)
+
+	require.Equal(t, pb.MountType_TMPFS, mounts["/tmp"].MountType)
+
+	require.Equal(t, pb.MountType_SECRET, mounts["/run/token"].MountType)
+	require.Equal(t, tokenID.String(), mounts["/run/token"].SecretOpt.ID)
+	require.Nil(t, mounts["/run/token"].Ref)
+
+	require.Equal(t, pb.MountType_SSH, mounts["/run/docker.sock"].MountType)
+	require.Nil(t, mounts["/run/docker.sock"].Ref)
+
+	require.NoError(t, term.Run(ctx, io.NopCloser(nil), nopCloser{io.Discard}, 24, 80))
+
+	starts := containers[0].Starts()
+	require.Len(t, starts, 1)
+
+	start := starts[0]
+	require.True(t, start.Tty)
+
+	// the entrypoint is ignored
+	require.Equal(t, []string{"bash"}, start.Args)
+	require.Equal(t, "/root", start.Cwd)
+	require.Equal(t, "app", start.User)
+	require.Contains(t, start.Env, "PATH=/usr/bin:/bin")
+	require.Contains(t, start.Env, "TOKEN=hunter2")
+	require.Contains(t, start.Env, "TERM=xterm")
+
+	require.NoError(t, term.Release(ctx))
+	require.True(t, containers[0].Released())
+
+	_, err = ctr.NewTerminal(ctx, gw, &Socket{}, ctr.Platform, func(context.Context, string) ([]byte, error) {
+		return nil, errors.New("not found")
+	}, ContainerExecOpts{Args: []string{"sh"}})
+	require.ErrorContains(t, err, "secret env TOKEN: not found")
+}
@@ -46,7 +46,10 @@ func decodeID[T ~string](payload any, id T) error {
 	return json.Unmarshal(jsonBytes, payload)
 }
 
-func absPath(workDir string, containerPath string) string {
+// AbsPath resolves a path given to a Container field against the working
+// directory, like a Dockerfile WORKDIR. Absolute paths are returned as-is and
+// an empty working directory is treated as /.
+func AbsPath(workDir string, containerPath string) string {
 	if path.IsAbs(containerPath) {
 		return containerPath
 	}
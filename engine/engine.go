@@ -15,11 +15,18 @@ import (
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/content/local"
 	"github.com/containerd/containerd/platforms"
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/audit"
 	"github.com/dagger/dagger/auth"
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/gatewayrecord"
 	"github.com/dagger/dagger/core/pipeline"
 	"github.com/dagger/dagger/core/schema"
+	"github.com/dagger/dagger/events"
 	"github.com/dagger/dagger/internal/engine"
+	"github.com/dagger/dagger/logging"
+	"github.com/dagger/dagger/native"
+	"github.com/dagger/dagger/plugin"
 	"github.com/dagger/dagger/router"
 	"github.com/dagger/dagger/secret"
 	"github.com/dagger/dagger/telemetry"
@@ -53,12 +60,73 @@ type Config struct {
 	UserAgent          string
 	EngineNameCallback func(string)
 	CloudURLCallback   func(string)
+	RateLimits         router.RateLimits
+	// AuditLog is a comma-separated list of file paths or HTTP endpoints
+	// that API operations are recorded to.
+	AuditLog string
+	// Log configures the session's structured logger. If no level is set,
+	// nothing is logged.
+	Log logging.Config
+	// Webhooks is a comma-separated list of HTTP endpoints that lifecycle
+	// events, such as failed operations and published images, are POSTed to.
+	Webhooks string
+	// Policy is the path to a JSON policy restricting which fields clients
+	// may use. Clients other than the session owner can't open terminals
+	// while a policy is set. It does not apply to the native client passed to
+	// StartNative, which acts as the session owner.
+	Policy string
+	// AdmissionPolicy is the path to a JSON policy that image references and
+	// exec options are checked against. Dockerfile builds are denied when it
+	// is set, since the images they pull can't be checked.
+	AdmissionPolicy string
+	// Playground serves a GraphiQL UI at /playground.
+	Playground bool
+	// Offline rejects operations that require external network access, such
+	// as pulling images that haven't been preloaded or fetching git and HTTP
+	// sources. Commands run without network access, unless their container
+	// is bound to services or exposes ports.
+	Offline bool
+	// Plugins is a comma-separated list of plugin directories whose
+	// WebAssembly resolvers are added to the API.
+	Plugins string
+	// GatewayRecording is a path that the session's buildkit gateway
+	// interactions are saved to, for replaying in unit tests with
+	// core/gatewaytest. File contents are only recorded for exec output,
+	// with GatewayRecordExecOutput.
+	GatewayRecording string
+	// GatewayRecordExecOutput includes the output of execs in the gateway
+	// recording, so that e.g. Container.stdout can be replayed.
+	GatewayRecordExecOutput bool
 }
 
 type StartCallback func(context.Context, *router.Router) error
 
-// nolint: gocyclo
+// NativeCallback is called with a client for building pipelines in Go.
+type NativeCallback func(context.Context, *native.Client) error
+
 func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
+	var cb sessionCallback
+	if fn != nil {
+		cb = func(ctx context.Context, r *router.Router, _ *native.Client) error {
+			return fn(ctx, r)
+		}
+	}
+
+	return start(ctx, startOpts, cb)
+}
+
+// StartNative starts a session like Start, but calls fn with a client for
+// building pipelines in-process rather than through the GraphQL API.
+func StartNative(ctx context.Context, startOpts Config, fn NativeCallback) error {
+	return start(ctx, startOpts, func(ctx context.Context, _ *router.Router, c *native.Client) error {
+		return fn(ctx, c)
+	})
+}
+
+type sessionCallback func(context.Context, *router.Router, *native.Client) error
+
+// nolint: gocyclo
+func start(ctx context.Context, startOpts Config, fn sessionCallback) error {
 	if startOpts.RunnerHost == "" {
 		return fmt.Errorf("must specify runner host")
 	}
@@ -146,7 +214,67 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 		return fmt.Errorf("normalize workdir: %w", err)
 	}
 
+	var admissionController *admission.Controller
+	if startOpts.AdmissionPolicy != "" {
+		admissionController, err = admission.Load(startOpts.AdmissionPolicy)
+		if err != nil {
+			return fmt.Errorf("admission policy: %w", err)
+		}
+	}
+
+	var policy *router.Policy
+	if startOpts.Policy != "" {
+		policy, err = router.LoadPolicy(startOpts.Policy)
+		if err != nil {
+			return fmt.Errorf("policy: %w", err)
+		}
+	}
+
 	router := router.New(startOpts.SessionToken, recorder)
+	router.SetRateLimits(startOpts.RateLimits)
+	router.SetPolicy(policy)
+	router.SetPlayground(startOpts.Playground)
+
+	var logger *logging.Logger
+	if startOpts.Log.Level != "" {
+		logger, err = logging.Open(startOpts.Log)
+		if err != nil {
+			return fmt.Errorf("logging: %w", err)
+		}
+		defer logger.Close()
+
+		router.SetLogger(&logger.Logger)
+	}
+
+	if startOpts.AuditLog != "" {
+		auditLog, err := audit.Open(startOpts.AuditLog)
+		if err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+		defer auditLog.Close()
+
+		if logger != nil {
+			auditLog.SetLogger(&logger.Logger)
+		}
+
+		router.SetAuditLog(auditLog)
+	}
+
+	var emitter *events.Emitter
+	if startOpts.Webhooks != "" {
+		emitter, err = events.Open(startOpts.Webhooks)
+		if err != nil {
+			return fmt.Errorf("webhooks: %w", err)
+		}
+		defer emitter.Close()
+
+		if logger != nil {
+			emitter.SetLogger(&logger.Logger)
+		}
+
+		router.SetEvents(emitter)
+	}
+
 	secretStore := secret.NewStore()
 
 	socketProviders := SocketProvider{
@@ -154,6 +282,7 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 	}
 
 	registryAuth := auth.NewRegistryAuthProvider(config.LoadDefaultConfigFile(os.Stderr))
+	registryAuth.SetOffline(startOpts.Offline)
 
 	var allowedEntitlements []entitlements.Entitlement
 	if c.PrivilegedExecEnabled {
@@ -216,7 +345,7 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 	})
 
 	eg.Go(func() error {
-		_, err := c.BuildkitClient.Build(groupCtx, solveOpts, "", func(ctx context.Context, gw bkgw.Client) (*bkgw.Result, error) {
+		_, err := c.BuildkitClient.Build(groupCtx, solveOpts, "", func(ctx context.Context, gw bkgw.Client) (_ *bkgw.Result, rerr error) {
 			// Secret store is a circular dependency, since it needs to resolve
 			// SecretIDs using the gateway, we don't have a gateway until we call
 			// Build, which needs SolveOpts, which needs to contain the secret store.
@@ -224,8 +353,21 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 			// Thankfully we can just yeet the gateway into the store.
 			secretStore.SetGateway(gw)
 
+			if startOpts.GatewayRecording != "" {
+				gwRecorder := gatewayrecord.NewRecorder(gw)
+				if startOpts.GatewayRecordExecOutput {
+					gwRecorder.RecordContents(gatewayrecord.ExecOutput)
+				}
+				defer func() {
+					if err := gwRecorder.Save(startOpts.GatewayRecording); err != nil && rerr == nil {
+						rerr = fmt.Errorf("save gateway recording: %w", err)
+					}
+				}()
+				gw = gwRecorder
+			}
+
 			gwClient := core.NewGatewayClient(gw, cacheConfigType, cacheConfigAttrs)
-			coreAPI, err := schema.New(schema.InitializeArgs{
+			initArgs := schema.InitializeArgs{
 				Router:         router,
 				Workdir:        startOpts.Workdir,
 				Gateway:        gwClient,
@@ -239,19 +381,51 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 				Secrets:        secretStore,
 				OCIStore:       ociStore,
 				ProgrockSocket: progSock,
-			})
+				Admission:      admissionController,
+				Offline:        startOpts.Offline,
+				SessionContext: ctx,
+			}
+			coreAPI, err := schema.New(initArgs)
 			if err != nil {
 				return nil, err
 			}
 			if err := router.Add(coreAPI); err != nil {
 				return nil, err
 			}
+			router.Handle("/terminal", schema.TerminalHandler(initArgs))
+
+			if startOpts.Plugins != "" {
+				runtime := plugin.NewWasmRuntime()
+				defer runtime.Close(ctx)
+
+				for _, dir := range strings.Split(startOpts.Plugins, ",") {
+					mod, err := plugin.Load(ctx, router, runtime, dir)
+					if err != nil {
+						return nil, fmt.Errorf("plugin %s: %w", dir, err)
+					}
+					defer mod.Close(ctx)
+				}
+			}
+
+			if logger != nil {
+				if err := router.Add(logging.Schema(logger)); err != nil {
+					return nil, err
+				}
+			}
+
+			emitter.Emit(events.SessionStarted, map[string]any{
+				"engine": c.EngineName,
+			})
 
 			if fn == nil {
 				return nil, nil
 			}
 
-			if err := fn(ctx, router); err != nil {
+			nativeOpts := []native.ClientOpt{native.WithAdmission(admissionController)}
+			if startOpts.Offline {
+				nativeOpts = append(nativeOpts, native.WithOffline(ociStore))
+			}
+			if err := fn(ctx, router, native.New(gwClient, progSock, *platform, nativeOpts...)); err != nil {
 				return nil, err
 			}
 
@@ -268,6 +442,13 @@ func Start(ctx context.Context, startOpts Config, fn StartCallback) error {
 	})
 
 	err = eg.Wait()
+
+	finished := map[string]any{}
+	if err != nil {
+		finished["error"] = err.Error()
+	}
+	emitter.Emit(events.SessionFinished, finished)
+
 	if err != nil {
 		// preserve context error if any, otherwise we get an error sent over gRPC
 		// that loses the original context error
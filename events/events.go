@@ -0,0 +1,174 @@
+// Package events notifies external systems of pipeline lifecycle events,
+// such as a session starting, an operation failing, or an image being
+// published.
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Type identifies the kind of an event.
+type Type string
+
+const (
+	// SessionStarted is emitted once the engine session is ready to serve
+	// queries.
+	SessionStarted Type = "session.started"
+
+	// SessionFinished is emitted when the session ends. Its data includes
+	// the session's error, if any.
+	SessionFinished Type = "session.finished"
+
+	// OperationFailed is emitted when a resolver returns an error. Its data
+	// includes the operation, in Type.field form, and the error.
+	OperationFailed Type = "operation.failed"
+
+	// ImagePublished is emitted when a container is pushed to a registry.
+	// Its data includes the published address, which is pinned to the
+	// image's digest.
+	ImagePublished Type = "image.published"
+)
+
+// Event is a single lifecycle event.
+type Event struct {
+	Type      Type           `json:"type"`
+	Timestamp time.Time      `json:"ts"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// Sink is a destination for events.
+type Sink interface {
+	Send(Event) error
+	Close() error
+}
+
+// Emitter sends events to a set of sinks.
+type Emitter struct {
+	sinks []Sink
+
+	mu     sync.Mutex
+	logger *zerolog.Logger
+}
+
+func New(sinks ...Sink) *Emitter {
+	return &Emitter{sinks: sinks}
+}
+
+// Open configures an Emitter from a comma-separated list of webhook URLs.
+//
+// Each http:// or https:// URL receives every event as a JSON object via
+// POST.
+func Open(config string) (*Emitter, error) {
+	sinks := []Sink{}
+	for _, dest := range strings.Split(config, ",") {
+		dest = strings.TrimSpace(dest)
+		if dest == "" {
+			continue
+		}
+
+		u, err := url.Parse(dest)
+		if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+			err = fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, fmt.Errorf("webhook %q: %w", dest, err)
+		}
+
+		sinks = append(sinks, NewWebhook(u.String()))
+	}
+
+	return New(sinks...), nil
+}
+
+// SetLogger configures the logger that events which can't be sent are
+// reported to. Sinks which send in the background, like Webhook, report to it
+// too.
+func (e *Emitter) SetLogger(logger *zerolog.Logger) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.logger = logger
+
+	for _, sink := range e.sinks {
+		if s, ok := sink.(interface{ SetLogger(*zerolog.Logger) }); ok {
+			s.SetLogger(logger)
+		}
+	}
+}
+
+// Emit sends the event to every sink. Errors are logged rather than failing
+// the pipeline.
+func (e *Emitter) Emit(typ Type, data map[string]any) {
+	if e == nil {
+		return
+	}
+
+	event := Event{
+		Type:      typ,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	e.mu.Lock()
+	logger := loggerOrNop(e.logger)
+	e.mu.Unlock()
+
+	for _, sink := range e.sinks {
+		if err := sink.Send(event); err != nil {
+			logger.Warn().Err(err).Str("type", string(typ)).Msg("events: send event")
+		}
+	}
+}
+
+// loggerOrNop returns logger, or a logger that discards everything if it's
+// nil.
+func loggerOrNop(logger *zerolog.Logger) *zerolog.Logger {
+	if logger == nil {
+		nop := zerolog.Nop()
+		return &nop
+	}
+	return logger
+}
+
+// Close delivers any pending events and closes every sink.
+func (e *Emitter) Close() error {
+	if e == nil {
+		return nil
+	}
+
+	var firstErr error
+	for _, sink := range e.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+type emitterKey struct{}
+
+// ToContext returns a context that emits events to the given emitter.
+func ToContext(ctx context.Context, e *Emitter) context.Context {
+	return context.WithValue(ctx, emitterKey{}, e)
+}
+
+// Emit sends an event to the emitter in the context, if any.
+func Emit(ctx context.Context, typ Type, data map[string]any) {
+	e, ok := ctx.Value(emitterKey{}).(*Emitter)
+	if !ok {
+		return
+	}
+
+	e.Emit(typ, data)
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	received := []Event{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	emitter, err := Open(srv.URL + ", ")
+	require.NoError(t, err)
+
+	ctx := ToContext(context.Background(), emitter)
+	Emit(ctx, SessionStarted, nil)
+	Emit(ctx, ImagePublished, map[string]any{
+		"address": "registry.example.com/app@sha256:abc",
+	})
+
+	require.NoError(t, emitter.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, received, 2)
+	require.Equal(t, SessionStarted, received[0].Type)
+	require.False(t, received[0].Timestamp.IsZero())
+	require.Equal(t, ImagePublished, received[1].Type)
+	require.Equal(t, "registry.example.com/app@sha256:abc", received[1].Data["address"])
+
+	// sending after close doesn't panic
+	emitter.Emit(SessionFinished, nil)
+}
+
+func TestWebhookFailure(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	emitter, err := Open(srv.URL)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	emitter.SetLogger(&logger)
+
+	emitter.Emit(SessionStarted, nil)
+	require.NoError(t, emitter.Close())
+
+	// sending after close fails
+	emitter.Emit(SessionFinished, nil)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	require.Equal(t, "warn", line["level"])
+	require.Equal(t, "events: unexpected response", line["message"])
+	require.Equal(t, "502 Bad Gateway", line["status"])
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	require.Equal(t, "warn", line["level"])
+	require.Equal(t, "events: send event", line["message"])
+	require.Equal(t, string(SessionFinished), line["type"])
+}
+
+func TestOpenInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := Open("file:///tmp/events.json")
+	require.ErrorContains(t, err, "unsupported scheme")
+}
+
+func TestEmitWithoutEmitter(t *testing.T) {
+	t.Parallel()
+
+	Emit(context.Background(), SessionStarted, nil)
+
+	var emitter *Emitter
+	emitter.Emit(SessionStarted, nil)
+	require.NoError(t, emitter.Close())
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	webhookQueueSize = 256
+	webhookTimeout   = 10 * time.Second
+)
+
+// Webhook POSTs each event to an endpoint as JSON.
+//
+// Events are delivered in order in the background so that a slow endpoint
+// doesn't hold up the pipeline.
+type Webhook struct {
+	url    string
+	client *http.Client
+
+	mu     sync.Mutex
+	logger *zerolog.Logger
+	closed bool
+	queue  chan Event
+	doneCh chan struct{}
+}
+
+func NewWebhook(url string) *Webhook {
+	hook := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan Event, webhookQueueSize),
+		doneCh: make(chan struct{}),
+	}
+
+	go hook.start()
+
+	return hook
+}
+
+func (hook *Webhook) Send(event Event) error {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	if hook.closed {
+		return fmt.Errorf("webhook closed")
+	}
+
+	select {
+	case hook.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("queue full; dropping %s event", event.Type)
+	}
+}
+
+// SetLogger configures the logger that failed deliveries are reported to.
+func (hook *Webhook) SetLogger(logger *zerolog.Logger) {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	hook.logger = logger
+}
+
+func (hook *Webhook) start() {
+	defer close(hook.doneCh)
+
+	for event := range hook.queue {
+		hook.post(event)
+	}
+}
+
+func (hook *Webhook) post(event Event) {
+	hook.mu.Lock()
+	logger := loggerOrNop(hook.logger)
+	hook.mu.Unlock()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Error().Err(err).Str("type", string(event.Type)).Msg("events: encode event")
+		return
+	}
+
+	resp, err := hook.client.Post(hook.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		logger.Warn().Err(err).Str("url", hook.url).Str("type", string(event.Type)).Msg("events: post event")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logger.Warn().Str("url", hook.url).Str("type", string(event.Type)).Str("status", resp.Status).Msg("events: unexpected response")
+	}
+}
+
+func (hook *Webhook) Close() error {
+	hook.mu.Lock()
+	if hook.closed {
+		hook.mu.Unlock()
+		return nil
+	}
+	hook.closed = true
+	close(hook.queue)
+	hook.mu.Unlock()
+
+	// Wait for queued events to be delivered
+	<-hook.doneCh
+
+	return nil
+}
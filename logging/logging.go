@@ -0,0 +1,160 @@
+// Package logging configures the engine session's structured logger, whose
+// level can be changed while the session is running.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Config configures a Logger.
+type Config struct {
+	// Level is the minimum level that is logged (trace, debug, info, warn,
+	// or error). Defaults to info.
+	Level string
+
+	// Format is either json or console. Defaults to json.
+	Format string
+
+	// Output is a comma-separated list of file paths that logs are appended
+	// to. Empty or - means stderr.
+	Output string
+}
+
+// Logger is a zerolog.Logger whose level can be changed at runtime.
+type Logger struct {
+	zerolog.Logger
+
+	level   *atomic.Int32
+	closers []io.Closer
+}
+
+// Open configures a Logger.
+func Open(config Config) (*Logger, error) {
+	level := zerolog.InfoLevel
+	if config.Level != "" {
+		var err error
+		level, err = ParseLevel(config.Level)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Logger{
+		level: &atomic.Int32{},
+	}
+	l.level.Store(int32(level))
+
+	writers := []io.Writer{}
+	for _, dest := range strings.Split(config.Output, ",") {
+		dest = strings.TrimSpace(dest)
+
+		var w io.Writer
+		switch dest {
+		case "", "-":
+			if len(writers) > 0 && dest == "" {
+				continue
+			}
+			w = os.Stderr
+		default:
+			f, err := openFile(dest)
+			if err != nil {
+				l.Close()
+				return nil, fmt.Errorf("log output %q: %w", dest, err)
+			}
+			l.closers = append(l.closers, f)
+			w = f
+		}
+
+		switch config.Format {
+		case "", FormatJSON:
+		case FormatConsole:
+			w = zerolog.ConsoleWriter{Out: w, NoColor: w != os.Stderr}
+		default:
+			l.Close()
+			return nil, fmt.Errorf("unknown log format %q", config.Format)
+		}
+
+		writers = append(writers, w)
+	}
+
+	l.Logger = zerolog.New(&levelWriter{
+		w:     zerolog.MultiLevelWriter(writers...),
+		level: l.level,
+	}).With().Timestamp().Logger()
+
+	return l, nil
+}
+
+func openFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+}
+
+// ParseLevel parses a level name, e.g. debug.
+func ParseLevel(name string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(strings.ToLower(name))
+	if err != nil || name == "" || level == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", name)
+	}
+
+	return level, nil
+}
+
+// Level returns the current minimum level.
+func (l *Logger) Level() zerolog.Level {
+	return zerolog.Level(l.level.Load())
+}
+
+// SetLevel changes the minimum level, returning the previous one.
+func (l *Logger) SetLevel(name string) (zerolog.Level, error) {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return zerolog.NoLevel, err
+	}
+
+	return zerolog.Level(l.level.Swap(int32(level))), nil
+}
+
+func (l *Logger) Close() error {
+	var firstErr error
+	for _, c := range l.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// levelWriter drops events below a level that can be changed at runtime.
+type levelWriter struct {
+	w     zerolog.LevelWriter
+	level *atomic.Int32
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *levelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.Level(w.level.Load()) {
+		return len(p), nil
+	}
+
+	return w.w.WriteLevel(level, p)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLevel(t *testing.T) {
+	t.Parallel()
+
+	logPath := filepath.Join(t.TempDir(), "logs", "engine.json")
+
+	l, err := Open(Config{
+		Level:  "info",
+		Output: logPath,
+	})
+	require.NoError(t, err)
+
+	l.Debug().Msg("hidden")
+	l.Info().Msg("shown")
+
+	prev, err := l.SetLevel("DEBUG")
+	require.NoError(t, err)
+	require.Equal(t, zerolog.InfoLevel, prev)
+	require.Equal(t, zerolog.DebugLevel, l.Level())
+
+	// loggers derived before the change use the new level too
+	derived := l.With().Str("op", "Query.container").Logger()
+	derived.Debug().Msg("now shown")
+
+	_, err = l.SetLevel("loud")
+	require.Error(t, err)
+
+	require.NoError(t, l.Close())
+
+	f, err := os.Open(logPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	messages := []string{}
+	scan := bufio.NewScanner(f)
+	for scan.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scan.Bytes(), &line))
+		require.Contains(t, line, "time")
+		messages = append(messages, line["message"].(string))
+	}
+	require.NoError(t, scan.Err())
+
+	require.Equal(t, []string{"shown", "now shown"}, messages)
+}
+
+func TestOpenInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := Open(Config{Level: "loud"})
+	require.ErrorContains(t, err, "unknown log level")
+
+	_, err = Open(Config{Format: "xml"})
+	require.ErrorContains(t, err, "unknown log format")
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"github.com/dagger/dagger/router"
+)
+
+// Schema returns a schema for reading and changing the logger's level.
+func Schema(l *Logger) router.ExecutableSchema {
+	return router.StaticSchema(router.StaticSchemaParams{
+		Name: "logging",
+		Schema: `
+extend type Query {
+  "The engine session's current log level."
+  logLevel: String!
+
+  "Sets the engine session's log level, returning the previous level."
+  setLogLevel(
+    "The new level: trace, debug, info, warn, or error."
+    level: String!
+  ): String!
+}
+`,
+		Resolvers: router.Resolvers{
+			"Query": router.ObjectResolver{
+				"logLevel":    router.ToResolver(logLevel(l)),
+				"setLogLevel": router.ToResolver(setLogLevel(l)),
+			},
+		},
+	})
+}
+
+func logLevel(l *Logger) func(*router.Context, any, any) (string, error) {
+	return func(ctx *router.Context, parent any, args any) (string, error) {
+		return l.Level().String(), nil
+	}
+}
+
+type setLogLevelArgs struct {
+	Level string
+}
+
+func setLogLevel(l *Logger) func(*router.Context, any, setLogLevelArgs) (string, error) {
+	return func(ctx *router.Context, parent any, args setLogLevelArgs) (string, error) {
+		prev, err := l.SetLevel(args.Level)
+		if err != nil {
+			return "", err
+		}
+
+		l.Info().Str("from", prev.String()).Str("to", args.Level).Msg("log level changed")
+
+		return prev.String(), nil
+	}
+}
@@ -0,0 +1,222 @@
+package native
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/core"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Container is an immutable container; each With method returns a new
+// Container.
+type Container struct {
+	c   *Client
+	ctr *core.Container
+}
+
+// Core returns the underlying core container, e.g. to pass its ID to a
+// GraphQL client.
+func (ctr *Container) Core() *core.Container {
+	return ctr.ctr
+}
+
+func (ctr *Container) with(fn func(*core.Container) (*core.Container, error)) (*Container, error) {
+	updated, err := fn(ctr.ctr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Container{c: ctr.c, ctr: updated}, nil
+}
+
+// From initializes the container from an image reference.
+func (ctr *Container) From(ctx context.Context, address string) (*Container, error) {
+	if err := ctr.c.admission.Admit(ctx, admission.Request{
+		Operation: admission.OperationFrom,
+		ImageRef:  address,
+	}); err != nil {
+		return nil, err
+	}
+
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		if ctr.c.offline {
+			return c.FromLocal(ctx, ctr.c.ociStore, address)
+		}
+		return c.From(ctx, ctr.c.gw, address)
+	})
+}
+
+// ExecOpt configures a command run with WithExec.
+type ExecOpt func(*core.ContainerExecOpts)
+
+// WithStdin writes content to the command's standard input.
+func WithStdin(content string) ExecOpt {
+	return func(opts *core.ContainerExecOpts) {
+		opts.Stdin = content
+	}
+}
+
+// WithoutEntrypoint runs the command without the container's entrypoint.
+func WithoutEntrypoint() ExecOpt {
+	return func(opts *core.ContainerExecOpts) {
+		opts.SkipEntrypoint = true
+	}
+}
+
+// WithRootCapabilities grants the command all root capabilities.
+func WithRootCapabilities() ExecOpt {
+	return func(opts *core.ContainerExecOpts) {
+		opts.InsecureRootCapabilities = true
+	}
+}
+
+// WithGPUs makes count GPUs available to the command, or all of them if
+// count is zero.
+func WithGPUs(count int) ExecOpt {
+	return func(opts *core.ContainerExecOpts) {
+		opts.GPU = &core.GPURequest{Count: count}
+	}
+}
+
+// WithExec runs a command in the container. A nil args runs the container's
+// default command.
+func (ctr *Container) WithExec(ctx context.Context, args []string, opts ...ExecOpt) (*Container, error) {
+	execOpts := core.ContainerExecOpts{Args: args, Offline: ctr.c.offline}
+	for _, opt := range opts {
+		opt(&execOpts)
+	}
+
+	if err := ctr.c.admission.Admit(ctx, admission.Request{
+		Operation:  admission.OperationExec,
+		Args:       execOpts.Args,
+		Privileged: execOpts.InsecureRootCapabilities || execOpts.ExperimentalPrivilegedNesting,
+	}); err != nil {
+		return nil, err
+	}
+
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.WithExec(ctx, ctr.c.gw, ctr.c.progSock, ctr.c.platform, execOpts)
+	})
+}
+
+// WithEnvVariable sets an environment variable in the container.
+func (ctr *Container) WithEnvVariable(ctx context.Context, name, value string) (*Container, error) {
+	return ctr.withImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		cfg.Env = core.AddEnv(cfg.Env, name, value)
+		return cfg
+	})
+}
+
+// WithWorkdir sets the working directory, relative to the current one.
+func (ctr *Container) WithWorkdir(ctx context.Context, dir string) (*Container, error) {
+	return ctr.withImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		cfg.WorkingDir = core.AbsPath(cfg.WorkingDir, dir)
+		return cfg
+	})
+}
+
+// WithDefaultArgs sets the container's default command.
+func (ctr *Container) WithDefaultArgs(ctx context.Context, args []string) (*Container, error) {
+	return ctr.withImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		cfg.Cmd = args
+		return cfg
+	})
+}
+
+func (ctr *Container) withImageConfig(ctx context.Context, fn func(specs.ImageConfig) specs.ImageConfig) (*Container, error) {
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.UpdateImageConfig(ctx, fn)
+	})
+}
+
+// WithNewFile writes a file to the container.
+func (ctr *Container) WithNewFile(ctx context.Context, dest string, content []byte, permissions fs.FileMode) (*Container, error) {
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.WithNewFile(ctx, ctr.c.gw, ctr.absPath(ctx, dest), content, permissions, "")
+	})
+}
+
+// WithDirectory copies a directory into the container.
+func (ctr *Container) WithDirectory(ctx context.Context, dest string, dir *Directory) (*Container, error) {
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.WithDirectory(ctx, ctr.c.gw, ctr.absPath(ctx, dest), dir.dir, core.CopyFilter{}, "")
+	})
+}
+
+// WithMountedDirectory mounts a directory into the container.
+func (ctr *Container) WithMountedDirectory(ctx context.Context, target string, dir *Directory) (*Container, error) {
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.WithMountedDirectory(ctx, ctr.c.gw, ctr.absPath(ctx, target), dir.dir, core.CopyFilter{}, "", false)
+	})
+}
+
+// WithMountedCache mounts a cache volume identified by key into the
+// container.
+func (ctr *Container) WithMountedCache(ctx context.Context, target string, key string) (*Container, error) {
+	return ctr.with(func(c *core.Container) (*core.Container, error) {
+		return c.WithMountedCache(ctx, ctr.c.gw, ctr.absPath(ctx, target), core.NewCache(key), nil, core.CacheSharingModeShared, "")
+	})
+}
+
+// Directory returns a directory from the container's filesystem.
+func (ctr *Container) Directory(ctx context.Context, dirPath string) (*Directory, error) {
+	dir, err := ctr.ctr.Directory(ctx, ctr.c.gw, ctr.absPath(ctx, dirPath))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Directory{c: ctr.c, dir: dir}, nil
+}
+
+// File returns a file from the container's filesystem.
+func (ctr *Container) File(ctx context.Context, filePath string) (*File, error) {
+	file, err := ctr.ctr.File(ctx, ctr.c.gw, ctr.absPath(ctx, filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{c: ctr.c, file: file}, nil
+}
+
+// Stdout returns the output of the last command run with WithExec.
+func (ctr *Container) Stdout(ctx context.Context) (string, error) {
+	return ctr.ctr.MetaFileContents(ctx, ctr.c.gw, ctr.c.progSock, "stdout")
+}
+
+// Stderr returns the error output of the last command run with WithExec.
+func (ctr *Container) Stderr(ctx context.Context) (string, error) {
+	return ctr.ctr.MetaFileContents(ctx, ctr.c.gw, ctr.c.progSock, "stderr")
+}
+
+// ExitCode returns the exit code of the last command run with WithExec.
+func (ctr *Container) ExitCode(ctx context.Context) (int, error) {
+	return ctr.ctr.ExitCode(ctx, ctr.c.gw, ctr.c.progSock)
+}
+
+// Sync forces evaluation of the container's pipeline.
+func (ctr *Container) Sync(ctx context.Context) error {
+	return ctr.ctr.Evaluate(ctx, ctr.c.gw)
+}
+
+// absPath resolves a path relative to the container's working directory.
+func (ctr *Container) absPath(ctx context.Context, p string) string {
+	cfg, err := ctr.ctr.ImageConfig(ctx)
+	if err != nil {
+		return core.AbsPath("", p)
+	}
+
+	return core.AbsPath(cfg.WorkingDir, p)
+}
+
+// LookupEnv returns the value of an environment variable in the container.
+func (ctr *Container) LookupEnv(ctx context.Context, name string) (string, bool, error) {
+	cfg, err := ctr.ctr.ImageConfig(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, found := core.LookupEnv(cfg.Env, name)
+	return value, found, nil
+}
@@ -0,0 +1,94 @@
+package native
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/dagger/dagger/core"
+	"github.com/moby/buildkit/client/llb"
+)
+
+// Directory is an immutable directory; each With method returns a new
+// Directory.
+type Directory struct {
+	c   *Client
+	dir *core.Directory
+}
+
+// Directory returns an empty directory.
+func (c *Client) Directory(ctx context.Context) (*Directory, error) {
+	dir, err := core.NewDirectorySt(ctx, llb.Scratch(), "", c.pipeline.Copy(), c.platform, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Directory{c: c, dir: dir}, nil
+}
+
+// Core returns the underlying core directory, e.g. to pass its ID to a
+// GraphQL client.
+func (dir *Directory) Core() *core.Directory {
+	return dir.dir
+}
+
+func (dir *Directory) with(fn func(*core.Directory) (*core.Directory, error)) (*Directory, error) {
+	updated, err := fn(dir.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Directory{c: dir.c, dir: updated}, nil
+}
+
+// WithNewFile writes a file to the directory.
+func (dir *Directory) WithNewFile(ctx context.Context, dest string, content []byte, permissions fs.FileMode) (*Directory, error) {
+	return dir.with(func(d *core.Directory) (*core.Directory, error) {
+		return d.WithNewFile(ctx, dest, content, permissions, nil)
+	})
+}
+
+// WithDirectory copies another directory into the directory.
+func (dir *Directory) WithDirectory(ctx context.Context, dest string, src *Directory) (*Directory, error) {
+	return dir.with(func(d *core.Directory) (*core.Directory, error) {
+		return d.WithDirectory(ctx, dest, src.dir, core.CopyFilter{}, nil)
+	})
+}
+
+// Directory returns a subdirectory.
+func (dir *Directory) Directory(ctx context.Context, subdir string) (*Directory, error) {
+	return dir.with(func(d *core.Directory) (*core.Directory, error) {
+		return d.Directory(ctx, subdir)
+	})
+}
+
+// File returns a file in the directory.
+func (dir *Directory) File(ctx context.Context, filePath string) (*File, error) {
+	file, err := dir.dir.File(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{c: dir.c, file: file}, nil
+}
+
+// Entries lists the entries of a path in the directory.
+func (dir *Directory) Entries(ctx context.Context, src string) ([]string, error) {
+	return dir.dir.Entries(ctx, dir.c.gw, src)
+}
+
+// File is an immutable file.
+type File struct {
+	c    *Client
+	file *core.File
+}
+
+// Core returns the underlying core file, e.g. to pass its ID to a GraphQL
+// client.
+func (file *File) Core() *core.File {
+	return file.file
+}
+
+// Contents returns the contents of the file.
+func (file *File) Contents(ctx context.Context) ([]byte, error) {
+	return file.file.Contents(ctx, file.c.gw)
+}
@@ -0,0 +1,126 @@
+// Package native is a Go API for building and running pipelines in-process,
+// backed directly by the core types rather than going through GraphQL.
+//
+// A Client is obtained from engine.StartNative:
+//
+//	engine.StartNative(ctx, cfg, func(ctx context.Context, c *native.Client) error {
+//		ctr, err := c.Container().From(ctx, "alpine")
+//		if err != nil {
+//			return err
+//		}
+//
+//		ctr, err = ctr.WithExec(ctx, []string{"echo", "hello"})
+//		if err != nil {
+//			return err
+//		}
+//
+//		out, err := ctr.Stdout(ctx)
+//		...
+//	})
+//
+// The client acts on behalf of the session owner, so the field policy
+// configured with engine.Config.Policy, which grants the session token
+// unrestricted access, does not apply to it. The admission policy and
+// offline mode apply just as they do to GraphQL clients.
+package native
+
+import (
+	"github.com/containerd/containerd/content"
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/pipeline"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Client constructs containers and directories for a session.
+type Client struct {
+	gw       bkgw.Client
+	progSock *core.Socket
+	platform specs.Platform
+	pipeline pipeline.Path
+
+	admission *admission.Controller
+	offline   bool
+	ociStore  content.Store
+}
+
+// ClientOpt configures a new client.
+type ClientOpt func(*Client)
+
+// WithAdmission checks images pulled and commands run by the client against
+// an admission controller.
+func WithAdmission(controller *admission.Controller) ClientOpt {
+	return func(c *Client) {
+		c.admission = controller
+	}
+}
+
+// WithOffline rejects operations that require external network access, and
+// runs commands without network access unless their container is bound to
+// services or exposes ports. Images are instead loaded from the given OCI
+// store, where they must have been preloaded.
+func WithOffline(store content.Store) ClientOpt {
+	return func(c *Client) {
+		c.offline = true
+		c.ociStore = store
+	}
+}
+
+// New returns a client which solves using the given gateway. progSock is the
+// path to the session's progress socket, and platform is the default
+// platform of the builder.
+func New(gw bkgw.Client, progSock string, platform specs.Platform, opts ...ClientOpt) *Client {
+	c := &Client{
+		gw:       gw,
+		progSock: &core.Socket{HostPath: progSock},
+		platform: platform,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Pipeline returns a client whose containers and directories are grouped
+// under a named sub-pipeline.
+func (c *Client) Pipeline(name string) *Client {
+	cp := *c
+	cp.pipeline = c.pipeline.Add(pipeline.Pipeline{Name: name})
+	return &cp
+}
+
+// ContainerOpt configures a new container.
+type ContainerOpt func(*containerConfig)
+
+type containerConfig struct {
+	platform *specs.Platform
+}
+
+// WithPlatform sets the platform of the container, rather than the default
+// platform of the builder.
+func WithPlatform(platform specs.Platform) ContainerOpt {
+	return func(cfg *containerConfig) {
+		cfg.platform = &platform
+	}
+}
+
+// Container returns an empty container.
+func (c *Client) Container(opts ...ContainerOpt) *Container {
+	cfg := containerConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	platform := c.platform
+	if cfg.platform != nil {
+		platform = *cfg.platform
+	}
+
+	ctr := &core.Container{
+		Pipeline: c.pipeline.Copy(),
+		Platform: platform,
+	}
+
+	return &Container{c: c, ctr: ctr}
+}
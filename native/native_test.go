@@ -0,0 +1,138 @@
+package native
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/content/local"
+	"github.com/dagger/dagger/admission"
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/gatewaytest"
+	"github.com/moby/buildkit/solver/pb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+var testPlatform = specs.Platform{OS: "linux", Architecture: "amd64"}
+
+func TestContainer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:latest": {
+			Config: specs.ImageConfig{
+				Env:        []string{"PATH=/bin"},
+				WorkingDir: "/root",
+			},
+		},
+	})
+
+	c := New(gw, "", testPlatform)
+
+	ctr, err := c.Container().From(ctx, "alpine")
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithEnvVariable(ctx, "FOO", "bar")
+	require.NoError(t, err)
+
+	ctr, err = ctr.WithWorkdir(ctx, "src")
+	require.NoError(t, err)
+
+	value, found, err := ctr.LookupEnv(ctx, "FOO")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "bar", value)
+
+	cfg, err := ctr.Core().ImageConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "/root/src", cfg.WorkingDir)
+	require.Equal(t, testPlatform, ctr.Core().Platform)
+
+	arm := specs.Platform{OS: "linux", Architecture: "arm64"}
+	require.Equal(t, arm, c.Container(WithPlatform(arm)).Core().Platform)
+}
+
+func TestContainerAdmission(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(nil)
+
+	c := New(gw, "", testPlatform, WithAdmission(admission.NewController(admission.Policy{
+		RequirePinnedImages: true,
+		DenyPrivileged:      true,
+	})))
+
+	var denied *admission.DeniedError
+	_, err := c.Container().From(ctx, "alpine")
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, admission.OperationFrom, denied.Request.Operation)
+
+	_, err = c.Container().WithExec(ctx, []string{"true"}, WithRootCapabilities())
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, admission.OperationExec, denied.Request.Operation)
+
+	// nothing was resolved or solved
+	require.Empty(t, gw.Solves())
+}
+
+func TestContainerOffline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	gw := gatewaytest.New(map[string]specs.Image{
+		"docker.io/library/alpine:latest": {},
+	})
+
+	c := New(gw, "", testPlatform, WithOffline(store))
+
+	_, err = c.Container().From(ctx, "alpine")
+	require.ErrorIs(t, err, core.ErrOffline)
+
+	_, err = c.Container().From(ctx, "alpine@sha256:"+strings.Repeat("a", 64))
+	require.ErrorIs(t, err, core.ErrOffline)
+}
+
+func TestDirectory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	gw := gatewaytest.New(nil)
+
+	c := New(gw, "", testPlatform)
+
+	dir, err := c.Directory(ctx)
+	require.NoError(t, err)
+
+	dir, err = dir.WithNewFile(ctx, "hello.txt", []byte("hello, world!"), 0)
+	require.NoError(t, err)
+
+	_, err = dir.Entries(ctx, ".")
+	require.NoError(t, err)
+
+	var mkfile *pb.FileActionMkFile
+	for _, def := range gw.Solves() {
+		ops, err := gatewaytest.Ops(def)
+		require.NoError(t, err)
+
+		for _, op := range ops {
+			for _, action := range op.GetFile().GetActions() {
+				if mk := action.GetMkfile(); mk != nil {
+					mkfile = mk
+				}
+			}
+		}
+	}
+	require.NotNil(t, mkfile)
+	require.Equal(t, "/hello.txt", mkfile.Path)
+	require.Equal(t, "hello, world!", string(mkfile.Data))
+}
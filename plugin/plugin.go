@@ -0,0 +1,228 @@
+// Package plugin loads WebAssembly modules which provide resolvers for
+// extending the API without running containers.
+//
+// A plugin is a directory containing a plugin.json manifest and the module
+// it names. The manifest declares the schema the plugin adds and the module
+// export that resolves each field:
+//
+//	{
+//	  "name": "greeter",
+//	  "module": "greeter.wasm",
+//	  "schema": "extend type Query { greet(name: String!): String! }",
+//	  "resolvers": {"Query.greet": "greet"}
+//	}
+//
+// Each resolver export is called with a JSON-encoded Request and returns a
+// JSON-encoded Response. Plugins are sandboxed: the only host function
+// available to them is Query, which runs a GraphQL query against the
+// router, so they can call core resolvers but have no direct access to the
+// host's filesystem or network.
+//
+// The engine loads the plugins listed in engine.Config.Plugins using a
+// WasmRuntime, which documents the calling convention modules must follow.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dagger/dagger/router"
+	"github.com/dagger/graphql"
+)
+
+const ManifestName = "plugin.json"
+
+// Manifest describes a plugin.
+type Manifest struct {
+	Name string `json:"name"`
+
+	// Path of the WebAssembly module, relative to the manifest.
+	Module string `json:"module"`
+
+	// Schema added by the plugin, in SDL.
+	Schema string `json:"schema"`
+
+	// Module exports which resolve each field, keyed by Type.field.
+	Resolvers map[string]string `json:"resolvers"`
+}
+
+// Request is passed to a resolver export.
+type Request struct {
+	// The object the field is being resolved on, if it's not the Query
+	// type.
+	Parent any `json:"parent,omitempty"`
+
+	Args map[string]any `json:"args"`
+}
+
+// Response is returned by a resolver export.
+type Response struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// HostFunc is the host API available to modules. It is called with a JSON
+// object with "query" and "variables" keys and returns the query's data as
+// JSON.
+type HostFunc func(ctx context.Context, request []byte) ([]byte, error)
+
+// Runtime instantiates WebAssembly modules.
+//
+// Implementations must not give modules access to any host functionality
+// other than the HostFunc, which modules import as "dagger"."query".
+type Runtime interface {
+	Instantiate(ctx context.Context, name string, wasm []byte, host HostFunc) (Module, error)
+}
+
+// Module is an instantiated WebAssembly module.
+type Module interface {
+	// Call calls an export with JSON input, returning its JSON output.
+	Call(ctx context.Context, export string, input []byte) ([]byte, error)
+
+	Close(ctx context.Context) error
+}
+
+// LoadManifest reads the manifest in a plugin directory.
+func LoadManifest(dir string) (*Manifest, error) {
+	payload, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ManifestName, err)
+	}
+
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("%s: name must be set", ManifestName)
+	}
+
+	if manifest.Module == "" {
+		return nil, fmt.Errorf("%s: module must be set", ManifestName)
+	}
+
+	return &manifest, nil
+}
+
+// Load instantiates the plugin in dir and adds its resolvers to the router.
+// The returned module should be closed once the router is no longer used.
+func Load(ctx context.Context, r *router.Router, runtime Runtime, dir string) (Module, error) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	wasm, err := os.ReadFile(filepath.Join(dir, manifest.Module))
+	if err != nil {
+		return nil, err
+	}
+
+	mod, err := runtime.Instantiate(ctx, manifest.Name, wasm, hostQuery(r))
+	if err != nil {
+		return nil, fmt.Errorf("instantiate %s: %w", manifest.Name, err)
+	}
+
+	schema, err := Schema(manifest, mod)
+	if err != nil {
+		mod.Close(ctx)
+		return nil, err
+	}
+
+	if err := r.Add(schema); err != nil {
+		mod.Close(ctx)
+		return nil, fmt.Errorf("add %s: %w", manifest.Name, err)
+	}
+
+	return mod, nil
+}
+
+// Schema returns a schema which resolves the manifest's fields by calling
+// the module.
+func Schema(manifest *Manifest, mod Module) (router.ExecutableSchema, error) {
+	resolvers := router.Resolvers{}
+
+	for field, export := range manifest.Resolvers {
+		typeName, fieldName, ok := strings.Cut(field, ".")
+		if !ok || typeName == "" || fieldName == "" {
+			return nil, fmt.Errorf("invalid resolver %q: must be Type.field", field)
+		}
+
+		obj, found := resolvers[typeName].(router.ObjectResolver)
+		if !found {
+			obj = router.ObjectResolver{}
+			resolvers[typeName] = obj
+		}
+
+		obj[fieldName] = resolver(mod, typeName, export)
+	}
+
+	return router.StaticSchema(router.StaticSchemaParams{
+		Name:      "plugin-" + manifest.Name,
+		Schema:    manifest.Schema,
+		Resolvers: resolvers,
+	}), nil
+}
+
+func resolver(mod Module, typeName, export string) graphql.FieldResolveFn {
+	return router.ToResolver(func(ctx *router.Context, parent any, args map[string]any) (any, error) {
+		req := Request{Args: args}
+		if typeName != "Query" {
+			req.Parent = parent
+		}
+
+		input, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := mod.Call(ctx, export, input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", export, err)
+		}
+
+		var res Response
+		if err := json.Unmarshal(output, &res); err != nil {
+			return nil, fmt.Errorf("%s: decode response: %w", export, err)
+		}
+
+		if res.Error != "" {
+			return nil, errors.New(res.Error)
+		}
+
+		var data any
+		if len(res.Data) > 0 {
+			if err := json.Unmarshal(res.Data, &data); err != nil {
+				return nil, fmt.Errorf("%s: decode data: %w", export, err)
+			}
+		}
+
+		return data, nil
+	})
+}
+
+type hostQueryRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+func hostQuery(r *router.Router) HostFunc {
+	return func(ctx context.Context, request []byte) ([]byte, error) {
+		var req hostQueryRequest
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil, err
+		}
+
+		var data any
+		if _, err := r.Do(ctx, req.Query, "", req.Variables, &data); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(data)
+	}
+}
@@ -0,0 +1,227 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dagger/dagger/audit"
+	"github.com/dagger/dagger/router"
+	"github.com/dagger/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntime stands in for a WebAssembly runtime, running Go functions
+// registered by module name instead.
+type fakeRuntime map[string]func(ctx context.Context, host HostFunc, export string, req Request) (any, error)
+
+func (rt fakeRuntime) Instantiate(ctx context.Context, name string, wasm []byte, host HostFunc) (Module, error) {
+	fn, found := rt[name]
+	if !found {
+		return nil, fmt.Errorf("unknown module %s", name)
+	}
+	return &fakeModule{fn: fn, host: host}, nil
+}
+
+type fakeModule struct {
+	fn   func(ctx context.Context, host HostFunc, export string, req Request) (any, error)
+	host HostFunc
+}
+
+func (m *fakeModule) Call(ctx context.Context, export string, input []byte) ([]byte, error) {
+	var req Request
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	data, err := m.fn(ctx, m.host, export, req)
+	if err != nil {
+		return json.Marshal(Response{Error: err.Error()})
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Response{Data: payload})
+}
+
+func (m *fakeModule) Close(context.Context) error {
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r := router.New("", nil)
+	require.NoError(t, r.Add(router.StaticSchema(router.StaticSchemaParams{
+		Name:   "core",
+		Schema: `type Query { version: String! }`,
+		Resolvers: router.Resolvers{
+			"Query": router.ObjectResolver{
+				"version": func(p graphql.ResolveParams) (any, error) {
+					return "v1.2.3", nil
+				},
+			},
+		},
+	})))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.wasm"), []byte("\x00asm"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestName), []byte(`{
+		"name": "greeter",
+		"module": "greeter.wasm",
+		"schema": "extend type Query { greet(name: String!): String! }",
+		"resolvers": {"Query.greet": "greet"}
+	}`), 0o600))
+
+	runtime := fakeRuntime{
+		"greeter": func(ctx context.Context, host HostFunc, export string, req Request) (any, error) {
+			if req.Args["name"] == "" {
+				return nil, fmt.Errorf("name must not be empty")
+			}
+
+			res, err := host(ctx, []byte(`{"query": "{ version }"}`))
+			if err != nil {
+				return nil, err
+			}
+
+			var data struct {
+				Version string
+			}
+			if err := json.Unmarshal(res, &data); err != nil {
+				return nil, err
+			}
+
+			return fmt.Sprintf("hello, %s! (engine %s)", req.Args["name"], data.Version), nil
+		},
+	}
+
+	mod, err := Load(ctx, r, runtime, dir)
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	logPath := filepath.Join(t.TempDir(), "audit.json")
+	log, err := audit.Open(logPath)
+	require.NoError(t, err)
+
+	ctx = audit.ToContext(ctx, log)
+
+	var res struct {
+		Greet string
+	}
+	_, err = r.Do(ctx, `{ greet(name: "world") }`, "", nil, &res)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world! (engine v1.2.3)", res.Greet)
+
+	_, err = r.Do(ctx, `{ greet(name: "") }`, "", nil, &res)
+	require.ErrorContains(t, err, "name must not be empty")
+
+	require.NoError(t, log.Close())
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+
+	var ops []string
+	dec := json.NewDecoder(bytes.NewReader(content))
+	for dec.More() {
+		var entry audit.Entry
+		require.NoError(t, dec.Decode(&entry))
+		ops = append(ops, entry.Operation)
+	}
+
+	// plugin fields are audited like any other
+	require.Contains(t, ops, "Query.greet")
+}
+
+func TestLoadWasm(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	r := router.New("", nil)
+	require.NoError(t, r.Add(router.StaticSchema(router.StaticSchemaParams{
+		Name:   "core",
+		Schema: `type Query { version: String! }`,
+		Resolvers: router.Resolvers{
+			"Query": router.ObjectResolver{
+				"version": func(p graphql.ResolveParams) (any, error) {
+					return "v1.2.3", nil
+				},
+			},
+		},
+	})))
+
+	runtime := NewWasmRuntime()
+	defer runtime.Close(ctx)
+
+	mod, err := Load(ctx, r, runtime, filepath.Join("testdata", "info"))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	var res struct {
+		Engine struct {
+			Version string
+		}
+	}
+	_, err = r.Do(ctx, `{ engine { version } }`, "", nil, &res)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.3", res.Engine.Version)
+
+	_, err = r.Do(ctx, `{ fail }`, "", nil, &res)
+	require.ErrorContains(t, err, "boom")
+
+	_, err = mod.Call(ctx, "missing", nil)
+	require.ErrorContains(t, err, "module does not export missing")
+
+	// querying a field the module resolves fails instead of deadlocking
+	_, err = r.Do(ctx, `{ reenter }`, "", nil, &res)
+	require.ErrorContains(t, err, "re-entrant call")
+
+	// a canceled call aborts the module, which is instantiated again for the
+	// next call
+	spinCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = r.Do(spinCtx, `{ spin }`, "", nil, &res)
+	require.Error(t, err)
+
+	res.Engine.Version = ""
+	_, err = r.Do(ctx, `{ engine { version } }`, "", nil, &res)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.3", res.Engine.Version)
+}
+
+func TestWasmRuntimeNoWASI(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	runtime := NewWasmRuntime()
+	defer runtime.Close(ctx)
+
+	// (module (import "wasi_snapshot_preview1" "proc_exit" (func (param i32))))
+	wasm := []byte("\x00asm\x01\x00\x00\x00" +
+		"\x01\x05\x01\x60\x01\x7f\x00" +
+		"\x02\x24\x01\x16wasi_snapshot_preview1\x09proc_exit\x00\x00")
+
+	_, err := runtime.Instantiate(ctx, "wasi", wasm, nil)
+	require.ErrorContains(t, err, "wasi_snapshot_preview1")
+}
+
+func TestSchemaInvalidResolver(t *testing.T) {
+	t.Parallel()
+
+	_, err := Schema(&Manifest{
+		Name:      "bad",
+		Resolvers: map[string]string{"greet": "greet"},
+	}, nil)
+	require.ErrorContains(t, err, "must be Type.field")
+}
@@ -0,0 +1,44 @@
+;; A plugin resolving the engine's version by querying the host, plus a few
+;; misbehaving resolvers. Rebuild
+;; info.wasm after editing with:
+;;
+;;   wat2wasm --no-debug-names info.wat
+(module
+  (import "dagger" "query" (func $query (param i32 i32) (result i64)))
+
+  (memory (export "memory") 1)
+
+  (global $heap (mut i32) (i32.const 1024))
+
+  (data (i32.const 0) "{\"query\":\"{ version }\"}")
+  (data (i32.const 64) "{\"error\":\"boom\"}")
+  (data (i32.const 128) "{\"query\":\"{ reenter }\"}")
+
+  ;; bump allocator; memory is never freed
+  (func (export "alloc") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $heap))
+    (global.set $heap (i32.add (global.get $heap) (local.get $size)))
+    (if (i32.gt_u (global.get $heap) (i32.shl (memory.size) (i32.const 16)))
+      (then
+        (drop (memory.grow
+          (i32.sub
+            (i32.add (i32.shr_u (i32.sub (global.get $heap) (i32.const 1)) (i32.const 16)) (i32.const 1))
+            (memory.size))))))
+    (local.get $ptr))
+
+  ;; returns the host's response to { version } as its own
+  (func (export "engine") (param i32 i32) (result i64)
+    (call $query (i32.const 0) (i32.const 23)))
+
+  (func (export "fail") (param i32 i32) (result i64)
+    (i64.const 274877906960))
+
+  ;; never returns
+  (func (export "spin") (param i32 i32) (result i64)
+    (loop $forever (br $forever))
+    (unreachable))
+
+  ;; queries the field it resolves
+  (func (export "reenter") (param i32 i32) (result i64)
+    (call $query (i32.const 128) (i32.const 23))))
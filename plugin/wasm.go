@@ -0,0 +1,245 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WasmRuntime is a Runtime backed by wazero.
+//
+// Modules must export their memory as "memory" and a function
+// "alloc(size i32) i32" returning a buffer of the given size. Resolver
+// exports have the signature "(ptr i32, len i32) i64": they are passed the
+// request in a buffer returned by alloc, and return a pointer to the
+// response in the upper 32 bits and its length in the lower 32 bits.
+//
+// The "dagger"."query" import has the same signature as a resolver export.
+// It returns a JSON-encoded Response whose data is the query's data, in a
+// buffer allocated with the module's alloc.
+//
+// Modules are given no other imports; in particular WASI is not available.
+//
+// A module is aborted if the query calling it is canceled, after which it is
+// instantiated again for the next call, losing any state it had.
+//
+// Calls to a module are serialized, so a module can't query a field it
+// resolves itself; such queries fail rather than waiting for the outer call.
+type WasmRuntime struct {
+	cache wazero.CompilationCache
+}
+
+var _ Runtime = (*WasmRuntime)(nil)
+
+// NewWasmRuntime returns a runtime which shares compiled code between the
+// modules it instantiates.
+func NewWasmRuntime() *WasmRuntime {
+	return &WasmRuntime{
+		cache: wazero.NewCompilationCache(),
+	}
+}
+
+// Close releases compiled code. Modules instantiated by the runtime must be
+// closed first.
+func (rt *WasmRuntime) Close(ctx context.Context) error {
+	return rt.cache.Close(ctx)
+}
+
+// Instantiate compiles and instantiates a module. Each module runs in its own
+// wazero runtime, so that modules can't import each other.
+func (rt *WasmRuntime) Instantiate(ctx context.Context, name string, wasm []byte, host HostFunc) (Module, error) {
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(rt.cache).
+		// abort modules which are still running when the query is canceled;
+		// wasmModule.Call instantiates them again
+		WithCloseOnContextDone(true))
+
+	_, err := r.NewHostModuleBuilder("dagger").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, mod api.Module, ptr, size uint32) uint64 {
+			return hostCall(ctx, mod, host, ptr, size)
+		}).
+		Export("query").
+		Instantiate(ctx)
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+
+	m := &wasmModule{
+		runtime:  r,
+		compiled: compiled,
+		name:     name,
+	}
+
+	if err := m.instantiate(ctx); err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// hostCall implements the "dagger"."query" import. Errors are returned to the
+// module in the response, rather than trapping.
+func hostCall(ctx context.Context, mod api.Module, host HostFunc, ptr, size uint32) uint64 {
+	var res Response
+
+	req, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		res.Error = "request out of range"
+	} else if data, err := host(ctx, req); err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Data = data
+	}
+
+	payload, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+
+	out, err := write(ctx, mod, mod.ExportedFunction("alloc"), payload)
+	if err != nil {
+		// traps the module; wazero returns the panic as the caller's error
+		panic(err)
+	}
+
+	return out
+}
+
+type wasmModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	name     string
+
+	mod   api.Module
+	alloc api.Function
+
+	// calls share the module's memory and allocator, so they must not run
+	// concurrently
+	l sync.Mutex
+}
+
+// callingKey marks the context of a call to a module, so that queries the
+// module makes back into itself can be rejected.
+type callingKey struct {
+	m *wasmModule
+}
+
+// instantiate (re-)instantiates the compiled module.
+func (m *wasmModule) instantiate(ctx context.Context) error {
+	mod, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName(m.name))
+	if err != nil {
+		return err
+	}
+
+	alloc := mod.ExportedFunction("alloc")
+	if alloc == nil || mod.Memory() == nil {
+		mod.Close(ctx)
+		return fmt.Errorf("module must export memory and alloc")
+	}
+
+	m.mod = mod
+	m.alloc = alloc
+	return nil
+}
+
+func (m *wasmModule) Call(ctx context.Context, export string, input []byte) ([]byte, error) {
+	if ctx.Value(callingKey{m}) != nil {
+		return nil, fmt.Errorf("%s: re-entrant call to module %s", export, m.name)
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	// the module is closed when a call is aborted, e.g. because its query was
+	// canceled; start over with a fresh instance
+	if m.mod == nil {
+		if err := m.instantiate(context.Background()); err != nil {
+			return nil, fmt.Errorf("re-instantiate %s: %w", m.name, err)
+		}
+	}
+
+	fn := m.mod.ExportedFunction(export)
+	if fn == nil {
+		return nil, fmt.Errorf("module does not export %s", export)
+	}
+
+	ctx = context.WithValue(ctx, callingKey{m}, true)
+
+	in, err := write(ctx, m.mod, m.alloc, input)
+	if err != nil {
+		m.discardIfExited(err)
+		return nil, err
+	}
+
+	results, err := fn.Call(ctx, in>>32, in&0xffffffff)
+	if err != nil {
+		m.discardIfExited(err)
+		return nil, err
+	}
+
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s returned %d results, expected 1", export, len(results))
+	}
+
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+
+	output, ok := m.mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s returned a response out of range", export)
+	}
+
+	// the memory may be reused by the next call
+	return append([]byte(nil), output...), nil
+}
+
+// discardIfExited drops the module instance if err shows it was closed, so
+// that the next call instantiates it again.
+func (m *wasmModule) discardIfExited(err error) {
+	var exitErr *sys.ExitError
+	if !errors.As(err, &exitErr) {
+		return
+	}
+
+	m.mod.Close(context.Background())
+	m.mod = nil
+	m.alloc = nil
+}
+
+func (m *wasmModule) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
+
+// write copies data into a buffer allocated by the module, returning the
+// buffer's pointer and length packed like a resolver's result.
+func write(ctx context.Context, mod api.Module, alloc api.Function, data []byte) (uint64, error) {
+	results, err := alloc.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("alloc: %w", err)
+	}
+
+	if len(results) != 1 {
+		return 0, fmt.Errorf("alloc returned %d results, expected 1", len(results))
+	}
+
+	ptr := uint32(results[0])
+	if !mod.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("alloc returned a buffer out of range")
+	}
+
+	return uint64(ptr)<<32 | uint64(len(data)), nil
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"github.com/dagger/dagger/audit"
+	"github.com/dagger/graphql"
+)
+
+// auditArgs returns the arguments of the field being resolved as they should
+// be recorded in the audit log, with sensitive values redacted based on the
+// argument names and types declared in the schema.
+func auditArgs(p graphql.ResolveParams) map[string]any {
+	types := map[string]string{}
+	if parent, ok := p.Info.ParentType.(interface {
+		Fields() graphql.FieldDefinitionMap
+	}); ok {
+		if field, found := parent.Fields()[p.Info.FieldName]; found {
+			for _, arg := range field.Args {
+				types[arg.Name()] = graphql.GetNamed(arg.Type).String()
+			}
+		}
+	}
+
+	args := make(map[string]any, len(p.Args))
+	for name, val := range p.Args {
+		if val != nil && audit.IsSensitiveArg(name, types[name]) {
+			val = audit.Redacted
+		}
+		args[name] = val
+	}
+
+	return args
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dagger/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditArgs(t *testing.T) {
+	t.Parallel()
+
+	container := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Container",
+		Fields: graphql.Fields{
+			"withExec": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					{Name: "args", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+					{Name: "stdin", Type: graphql.String},
+				},
+			},
+			"withNewFile": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					{Name: "path", Type: graphql.NewNonNull(graphql.String)},
+					{Name: "contents", Type: graphql.String},
+					{Name: "permissions", Type: graphql.Int},
+				},
+			},
+			"withEnvVariable": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+					{Name: "value", Type: graphql.NewNonNull(graphql.String)},
+				},
+			},
+		},
+	})
+
+	params := func(field string, args map[string]any) graphql.ResolveParams {
+		return graphql.ResolveParams{
+			Context: context.Background(),
+			Args:    args,
+			Info: graphql.ResolveInfo{
+				FieldName:  field,
+				ParentType: container,
+			},
+		}
+	}
+
+	// short plaintext values are redacted too, regardless of their Go type
+	require.Equal(t, map[string]any{
+		"args":  "***",
+		"stdin": "***",
+	}, auditArgs(params("withExec", map[string]any{
+		"args":  []any{"sh", "-c", "echo hunter2"},
+		"stdin": "hunter2",
+	})))
+
+	require.Equal(t, map[string]any{
+		"path":        "/token",
+		"contents":    "***",
+		"permissions": 0o600,
+	}, auditArgs(params("withNewFile", map[string]any{
+		"path":        "/token",
+		"contents":    "hunter2",
+		"permissions": 0o600,
+	})))
+
+	require.Equal(t, map[string]any{
+		"name":  "TOKEN",
+		"value": "***",
+	}, auditArgs(params("withEnvVariable", map[string]any{
+		"name":  "TOKEN",
+		"value": "hunter2",
+	})))
+
+	// arguments missing from the schema are judged by name alone
+	require.Equal(t, map[string]any{
+		"contents": "***",
+		"path":     "/token",
+	}, auditArgs(params("withUnknown", map[string]any{
+		"contents": "hunter2",
+		"path":     "/token",
+	})))
+}
@@ -0,0 +1,42 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle(t *testing.T) {
+	t.Parallel()
+
+	r := New("token", nil)
+	r.Handle("/hello", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		req.SetBasicAuth(user, "")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("token")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "hello", w.Body.String())
+
+	require.Equal(t, http.StatusUnauthorized, get("bogus").Code)
+
+	// once a policy is set, only the session token reaches handlers
+	r.SetPolicy(&Policy{
+		Tokens: map[string][]string{
+			"admin-token": {"admin"},
+		},
+	})
+
+	require.Equal(t, http.StatusOK, get("token").Code)
+	require.Equal(t, http.StatusNotFound, get("admin-token").Code)
+}
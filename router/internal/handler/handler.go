@@ -20,11 +20,15 @@ const (
 
 type ResultCallbackFn func(ctx context.Context, params *graphql.Params, result *graphql.Result, responseBody []byte)
 
+// ExtensionsFn returns extensions to include in the response to a request.
+type ExtensionsFn func(params *graphql.Params) map[string]any
+
 type Handler struct {
 	Schema           *graphql.Schema
 	pretty           bool
 	rootObjectFn     RootObjectFn
 	resultCallbackFn ResultCallbackFn
+	extensionsFn     ExtensionsFn
 	formatErrorFn    func(err error) gqlerrors.FormattedError
 }
 
@@ -137,6 +141,17 @@ func (h *Handler) ContextHandler(ctx context.Context, w http.ResponseWriter, r *
 	}
 	result := graphql.Do(params)
 
+	if h.extensionsFn != nil {
+		if extensions := h.extensionsFn(&params); len(extensions) > 0 {
+			if result.Extensions == nil {
+				result.Extensions = map[string]any{}
+			}
+			for k, v := range extensions {
+				result.Extensions[k] = v
+			}
+		}
+	}
+
 	if formatErrorFn := h.formatErrorFn; formatErrorFn != nil && len(result.Errors) > 0 {
 		formatted := make([]gqlerrors.FormattedError, len(result.Errors))
 		for i, formattedError := range result.Errors {
@@ -179,6 +194,7 @@ type Config struct {
 	Pretty           bool
 	RootObjectFn     RootObjectFn
 	ResultCallbackFn ResultCallbackFn
+	ExtensionsFn     ExtensionsFn
 	FormatErrorFn    func(err error) gqlerrors.FormattedError
 }
 
@@ -203,6 +219,7 @@ func New(p *Config) *Handler {
 		pretty:           p.Pretty,
 		rootObjectFn:     p.RootObjectFn,
 		resultCallbackFn: p.ResultCallbackFn,
+		extensionsFn:     p.ExtensionsFn,
 		formatErrorFn:    p.FormatErrorFn,
 	}
 }
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/dagger/graphql"
+)
+
+// ScalarHintsExtension is the key of the response extension which describes
+// the schema's custom scalars. It is included in responses to introspection
+// queries, so that SDK code generators don't need to hard-code them.
+const ScalarHintsExtension = "daggerScalars"
+
+type ScalarKind string
+
+const (
+	// ScalarKindString is an opaque string, e.g. Platform.
+	ScalarKindString ScalarKind = "string"
+
+	// ScalarKindID is an opaque string identifying an object, e.g.
+	// ContainerID. SDKs may accept the object wherever the ID is expected.
+	ScalarKindID ScalarKind = "id"
+)
+
+// ScalarHint describes how a custom scalar is serialized.
+type ScalarHint struct {
+	Kind ScalarKind `json:"kind"`
+
+	// Name of the object type that an ID identifies.
+	Object string `json:"object,omitempty"`
+}
+
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// scalarHints describes each custom scalar in the schema. A scalar is the ID
+// of an object if the object's id field returns it.
+func scalarHints(schema *graphql.Schema) map[string]ScalarHint {
+	hints := map[string]ScalarHint{}
+
+	for name, t := range schema.TypeMap() {
+		if _, ok := t.(*graphql.Scalar); ok && !builtinScalars[name] && !strings.HasPrefix(name, "__") {
+			if _, found := hints[name]; !found {
+				hints[name] = ScalarHint{Kind: ScalarKindString}
+			}
+		}
+
+		obj, ok := t.(*graphql.Object)
+		if !ok || strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		id, found := obj.Fields()["id"]
+		if !found {
+			continue
+		}
+
+		idType := id.Type
+		if nonNull, ok := idType.(*graphql.NonNull); ok {
+			idType = nonNull.OfType
+		}
+
+		scalar, ok := idType.(*graphql.Scalar)
+		if !ok || builtinScalars[scalar.Name()] {
+			continue
+		}
+
+		hints[scalar.Name()] = ScalarHint{
+			Kind:   ScalarKindID,
+			Object: name,
+		}
+	}
+
+	return hints
+}
+
+// isIntrospection returns true if the query requests the schema.
+func isIntrospection(query string) bool {
+	return strings.Contains(query, "__schema")
+}
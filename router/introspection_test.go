@@ -0,0 +1,56 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/dagger/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScalarHints(t *testing.T) {
+	t.Parallel()
+
+	thingID := graphql.NewScalar(graphql.ScalarConfig{
+		Name:      "ThingID",
+		Serialize: func(v any) any { return v },
+	})
+
+	platform := graphql.NewScalar(graphql.ScalarConfig{
+		Name:      "Platform",
+		Serialize: func(v any) any { return v },
+	})
+
+	thing := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Thing",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(thingID)},
+			"platform": &graphql.Field{Type: platform},
+		},
+	})
+
+	other := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Other",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.ID},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"thing": &graphql.Field{Type: thing},
+				"other": &graphql.Field{Type: other},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]ScalarHint{
+		"ThingID":  {Kind: ScalarKindID, Object: "Thing"},
+		"Platform": {Kind: ScalarKindString},
+	}, scalarHints(&schema))
+
+	require.True(t, isIntrospection(`query { __schema { types { name } } }`))
+	require.False(t, isIntrospection(`query { thing { id } }`))
+}
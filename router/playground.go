@@ -0,0 +1,22 @@
+package router
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed playground.html
+var playgroundHTML []byte
+
+// playgroundHandler serves a GraphiQL UI for exploring the schema and
+// running queries against /query.
+func playgroundHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(playgroundHTML)
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayground(t *testing.T) {
+	t.Parallel()
+
+	r := New("token", nil)
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/playground", nil)
+		req.SetBasicAuth(user, "")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusNotFound, get("token").Code)
+
+	r.SetPlayground(true)
+
+	w := get("token")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, w.Body.String(), "GraphiQL")
+
+	require.Equal(t, http.StatusUnauthorized, get("bogus").Code)
+}
@@ -0,0 +1,146 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/dagger/graphql"
+)
+
+// Policy restricts access to schema fields to authenticated roles, so that a
+// shared engine can offer building broadly while limiting dangerous
+// operations like host access or publishing.
+//
+// Clients authenticate by passing a token as the basic auth username. The
+// session token grants unrestricted access. Clients without a token are
+// anonymous and hold no roles.
+//
+// Endpoints registered with Router.Handle, such as interactive terminals,
+// can't be restricted by rules, so once a policy is set they are only served
+// to the session token.
+type Policy struct {
+	// Tokens maps client tokens to the roles they are granted.
+	Tokens map[string][]string `json:"tokens"`
+
+	// Rules maps fields to the roles allowed to use them.
+	//
+	// Keys take one of the following forms:
+	//
+	//   - Type.field restricts a single field (e.g. Container.publish).
+	//   - Type.* restricts every field of a type (e.g. Host.*).
+	//   - Type.field.arg restricts a field only when the argument is set to a
+	//     non-zero value (e.g. Container.withExec.insecureRootCapabilities).
+	Rules map[string][]string `json:"rules"`
+}
+
+// LoadPolicy reads a JSON policy from the given path.
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// ForbiddenError is returned when a client does not hold a role required to
+// use a field.
+type ForbiddenError struct {
+	Rule string
+}
+
+func (err *ForbiddenError) Error() string {
+	return fmt.Sprintf("access denied: %s requires one of the roles granted by policy", err.Rule)
+}
+
+// roles returns the roles granted to the given token, and whether the token
+// is known to the policy.
+func (policy *Policy) roles(token string) ([]string, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	roles, found := policy.Tokens[token]
+	return roles, found
+}
+
+// authorize checks that the given roles satisfy every rule matching the
+// field being resolved.
+func (policy *Policy) authorize(roles []string, p graphql.ResolveParams) error {
+	typeName := p.Info.ParentType.Name()
+	fieldName := p.Info.FieldName
+
+	rules := []string{
+		typeName + ".*",
+		typeName + "." + fieldName,
+	}
+
+	for argName, val := range p.Args {
+		if val == nil || reflect.ValueOf(val).IsZero() {
+			continue
+		}
+
+		rules = append(rules, typeName+"."+fieldName+"."+argName)
+	}
+
+	for _, rule := range rules {
+		allowed, found := policy.Rules[rule]
+		if !found {
+			continue
+		}
+
+		if !hasRole(roles, allowed) {
+			return &ForbiddenError{Rule: rule}
+		}
+	}
+
+	return nil
+}
+
+func hasRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if strings.EqualFold(role, a) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type accessKey struct{}
+
+type access struct {
+	policy *Policy
+	roles  []string
+}
+
+// withAccess returns a context whose resolvers are checked against the
+// policy using the given roles.
+func withAccess(ctx context.Context, policy *Policy, roles []string) context.Context {
+	return context.WithValue(ctx, accessKey{}, access{
+		policy: policy,
+		roles:  roles,
+	})
+}
+
+// authorize checks the field being resolved against the policy in the
+// context, if any.
+func authorize(p graphql.ResolveParams) error {
+	acc, ok := p.Context.Value(accessKey{}).(access)
+	if !ok || acc.policy == nil {
+		return nil
+	}
+
+	return acc.policy.authorize(acc.roles, p)
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dagger/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyAuthorize(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		Tokens: map[string][]string{
+			"builder-token": {"builder"},
+			"admin-token":   {"builder", "admin"},
+		},
+		Rules: map[string][]string{
+			"Host.*":            {"admin"},
+			"Container.publish": {"admin"},
+			"Container.withExec.insecureRootCapabilities": {"admin"},
+		},
+	}
+
+	host := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Host",
+		Fields: graphql.Fields{"directory": &graphql.Field{Type: graphql.String}},
+	})
+
+	container := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Container",
+		Fields: graphql.Fields{"from": &graphql.Field{Type: graphql.String}},
+	})
+
+	params := func(parent *graphql.Object, field string, args map[string]any) graphql.ResolveParams {
+		return graphql.ResolveParams{
+			Context: context.Background(),
+			Args:    args,
+			Info: graphql.ResolveInfo{
+				FieldName:  field,
+				ParentType: parent,
+			},
+		}
+	}
+
+	builder, known := policy.roles("builder-token")
+	require.True(t, known)
+
+	admin, known := policy.roles("admin-token")
+	require.True(t, known)
+
+	anonymous, known := policy.roles("")
+	require.False(t, known)
+
+	for _, roles := range [][]string{anonymous, builder, admin} {
+		require.NoError(t, policy.authorize(roles, params(container, "from", map[string]any{"address": "alpine"})))
+		require.NoError(t, policy.authorize(roles, params(container, "withExec", map[string]any{
+			"args":                     []any{"echo"},
+			"insecureRootCapabilities": false,
+		})))
+	}
+
+	for _, p := range []graphql.ResolveParams{
+		params(host, "directory", map[string]any{"path": "."}),
+		params(container, "publish", map[string]any{"address": "registry/image"}),
+		params(container, "withExec", map[string]any{
+			"args":                     []any{"echo"},
+			"insecureRootCapabilities": true,
+		}),
+	} {
+		var forbidden *ForbiddenError
+		require.ErrorAs(t, policy.authorize(anonymous, p), &forbidden)
+		require.ErrorAs(t, policy.authorize(builder, p), &forbidden)
+		require.NoError(t, policy.authorize(admin, p))
+	}
+}
+
+func TestPolicyAuthorizeFromContext(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		Rules: map[string][]string{
+			"Host.*": {"admin"},
+		},
+	}
+
+	host := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Host",
+		Fields: graphql.Fields{"directory": &graphql.Field{Type: graphql.String}},
+	})
+
+	p := graphql.ResolveParams{
+		Context: context.Background(),
+		Info: graphql.ResolveInfo{
+			FieldName:  "directory",
+			ParentType: host,
+		},
+	}
+
+	// no policy in context, e.g. the session token or in-process queries
+	require.NoError(t, authorize(p))
+
+	p.Context = withAccess(context.Background(), policy, nil)
+	require.Error(t, authorize(p))
+
+	p.Context = withAccess(context.Background(), policy, []string{"admin"})
+	require.NoError(t, authorize(p))
+}
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimits configures limits enforced on queries served by the router over
+// HTTP, so that a single misbehaving client can't monopolize a shared engine.
+//
+// A zero value for any field disables the corresponding limit.
+type RateLimits struct {
+	// Maximum sustained queries per second accepted from a single client.
+	ClientRPS float64
+	// Maximum burst of queries accepted from a single client.
+	ClientBurst int
+
+	// Maximum sustained queries per second accepted across all clients.
+	GlobalRPS float64
+	// Maximum burst of queries accepted across all clients.
+	GlobalBurst int
+
+	// Maximum number of queries evaluated concurrently. Queries past the limit
+	// wait for a slot to free up.
+	MaxConcurrent int
+}
+
+// Enabled returns true if any limit is configured.
+func (limits RateLimits) Enabled() bool {
+	return limits.ClientRPS > 0 || limits.GlobalRPS > 0 || limits.MaxConcurrent > 0
+}
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// clientLimiterTTL is how long a client's limiter is retained after its last
+// request.
+const clientLimiterTTL = 5 * time.Minute
+
+type rateLimiter struct {
+	limits RateLimits
+
+	global *rate.Limiter
+	slots  chan struct{}
+
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+	l         sync.Mutex
+}
+
+type clientLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	lim := &rateLimiter{
+		limits:  limits,
+		clients: map[string]*clientLimiter{},
+	}
+
+	if limits.GlobalRPS > 0 {
+		lim.global = rate.NewLimiter(rate.Limit(limits.GlobalRPS), burst(limits.GlobalBurst))
+	}
+
+	if limits.MaxConcurrent > 0 {
+		lim.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+
+	return lim
+}
+
+// acquire checks the request from the given client against the configured
+// limits, returning a function that must be called once the request has been
+// served.
+func (lim *rateLimiter) acquire(req *http.Request, client string) (func(), error) {
+	if lim.global != nil && !lim.global.Allow() {
+		return nil, ErrRateLimited
+	}
+
+	// only spend a client token once the global limit has allowed the request,
+	// so that clients aren't penalized for requests they couldn't make
+	if limiter := lim.client(client); limiter != nil && !limiter.Allow() {
+		return nil, ErrRateLimited
+	}
+
+	if lim.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case lim.slots <- struct{}{}:
+		return func() { <-lim.slots }, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func (lim *rateLimiter) client(key string) *rate.Limiter {
+	if lim.limits.ClientRPS <= 0 {
+		return nil
+	}
+
+	lim.l.Lock()
+	defer lim.l.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(lim.lastSweep) > clientLimiterTTL {
+		for k, c := range lim.clients {
+			if now.Sub(c.lastSeen) > clientLimiterTTL {
+				delete(lim.clients, k)
+			}
+		}
+
+		lim.lastSweep = now
+	}
+
+	c, found := lim.clients[key]
+	if !found {
+		c = &clientLimiter{
+			Limiter: rate.NewLimiter(rate.Limit(lim.limits.ClientRPS), burst(lim.limits.ClientBurst)),
+		}
+
+		lim.clients[key] = c
+	}
+
+	c.lastSeen = now
+
+	return c.Limiter
+}
+
+// clientKey identifies the client that sent the request. Clients that
+// authenticated with the session token or a policy token are identified by
+// it, since clients connected through a session all share the same remote
+// address. Other clients are identified by their remote host, so that they
+// can't pick a new limit for every request.
+func clientKey(req *http.Request, token string) string {
+	if token != "" {
+		return "token:" + token
+	}
+
+	return "host:" + remoteHost(req)
+}
+
+// remoteHost returns the host the request was sent from.
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+func burst(n int) int {
+	if n <= 0 {
+		return 1
+	}
+
+	return n
+}
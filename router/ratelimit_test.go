@@ -0,0 +1,202 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitPerClient(t *testing.T) {
+	t.Parallel()
+	lim := newRateLimiter(RateLimits{
+		ClientRPS:   0.001,
+		ClientBurst: 2,
+	})
+
+	alice := httptest.NewRequest("POST", "/query", nil)
+	alice.RemoteAddr = "10.0.0.1:1234"
+
+	bob := httptest.NewRequest("POST", "/query", nil)
+	bob.RemoteAddr = "10.0.0.2:1234"
+
+	for i := 0; i < 2; i++ {
+		release, err := lim.acquire(alice, clientKey(alice, ""))
+		require.NoError(t, err)
+		release()
+	}
+
+	_, err := lim.acquire(alice, clientKey(alice, ""))
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	// a different port on the same host is the same client
+	alice.RemoteAddr = "10.0.0.1:5678"
+	_, err = lim.acquire(alice, clientKey(alice, ""))
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	release, err := lim.acquire(bob, clientKey(bob, ""))
+	require.NoError(t, err)
+	release()
+}
+
+func TestRateLimitPerToken(t *testing.T) {
+	t.Parallel()
+	lim := newRateLimiter(RateLimits{
+		ClientRPS:   0.001,
+		ClientBurst: 1,
+	})
+
+	// clients connected through the session share the same remote address
+	req := httptest.NewRequest("POST", "/query", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+
+	release, err := lim.acquire(req, clientKey(req, "alice-token"))
+	require.NoError(t, err)
+	release()
+
+	_, err = lim.acquire(req, clientKey(req, "alice-token"))
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	release, err = lim.acquire(req, clientKey(req, "bob-token"))
+	require.NoError(t, err)
+	release()
+
+	release, err = lim.acquire(req, clientKey(req, ""))
+	require.NoError(t, err)
+	release()
+}
+
+func TestRateLimitGlobal(t *testing.T) {
+	t.Parallel()
+	lim := newRateLimiter(RateLimits{
+		GlobalRPS:   0.001,
+		GlobalBurst: 1,
+	})
+
+	alice := httptest.NewRequest("POST", "/query", nil)
+	alice.RemoteAddr = "10.0.0.1:1234"
+
+	bob := httptest.NewRequest("POST", "/query", nil)
+	bob.RemoteAddr = "10.0.0.2:1234"
+
+	release, err := lim.acquire(alice, clientKey(alice, ""))
+	require.NoError(t, err)
+	release()
+
+	_, err = lim.acquire(bob, clientKey(bob, ""))
+	require.ErrorIs(t, err, ErrRateLimited)
+}
+
+func TestRateLimitGlobalBeforeClient(t *testing.T) {
+	t.Parallel()
+	lim := newRateLimiter(RateLimits{
+		ClientRPS:   0.001,
+		ClientBurst: 1,
+		GlobalRPS:   0.001,
+		GlobalBurst: 1,
+	})
+
+	alice := httptest.NewRequest("POST", "/query", nil)
+	alice.RemoteAddr = "10.0.0.1:1234"
+
+	bob := httptest.NewRequest("POST", "/query", nil)
+	bob.RemoteAddr = "10.0.0.2:1234"
+
+	release, err := lim.acquire(alice, clientKey(alice, ""))
+	require.NoError(t, err)
+	release()
+
+	// the global limit rejects bob without spending his client token
+	_, err = lim.acquire(bob, clientKey(bob, ""))
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	lim.global.SetLimit(rate.Inf)
+
+	release, err = lim.acquire(bob, clientKey(bob, ""))
+	require.NoError(t, err)
+	release()
+}
+
+func TestRateLimitConcurrent(t *testing.T) {
+	t.Parallel()
+	lim := newRateLimiter(RateLimits{
+		MaxConcurrent: 1,
+	})
+
+	req := httptest.NewRequest("POST", "/query", nil)
+
+	release, err := lim.acquire(req, clientKey(req, ""))
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := lim.acquire(req, clientKey(req, ""))
+		require.NoError(t, err)
+		release()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired slot past the concurrency limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for slot")
+	}
+
+	// canceled requests give up waiting
+	release, err = lim.acquire(req, clientKey(req, ""))
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = lim.acquire(req.WithContext(ctx), clientKey(req, ""))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	t.Parallel()
+
+	r := New("token", nil)
+	r.SetRateLimits(RateLimits{
+		ClientRPS:     0.001,
+		ClientBurst:   1,
+		MaxConcurrent: 1,
+	})
+
+	serve := func(ctx context.Context) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil).WithContext(ctx)
+		req.SetBasicAuth("token", "")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// hold the only slot, so that the next request has to wait for it
+	release, err := r.limiter.acquire(httptest.NewRequest(http.MethodPost, "/query", nil), "")
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := serve(ctx)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Empty(t, w.Header().Get("Retry-After"))
+
+	w = serve(context.Background())
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+}
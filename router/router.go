@@ -10,10 +10,13 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/dagger/dagger/audit"
+	"github.com/dagger/dagger/events"
 	"github.com/dagger/dagger/internal/engine"
 	"github.com/dagger/dagger/router/internal/handler"
 	"github.com/dagger/graphql"
 	"github.com/dagger/graphql/gqlerrors"
+	"github.com/rs/zerolog"
 	"github.com/vito/progrock"
 )
 
@@ -23,12 +26,23 @@ type Router struct {
 	sessionToken string
 
 	recorder *progrock.Recorder
+	limiter  *rateLimiter
+	auditLog *audit.Log
+	events   *events.Emitter
+	logger   *zerolog.Logger
+	policy   *Policy
+
+	playground bool
+
+	// handlers serve non-GraphQL endpoints, e.g. for streaming
+	handlers map[string]http.Handler
 
 	s *graphql.Schema
 	// mergedSchemaString is the merged schemas in SDL format, useful
 	// for projects who need their dynamic schemas validated against
 	// the router's current schema
 	mergedSchemaString string
+	scalarHints        map[string]ScalarHint
 	h                  *handler.Handler
 	l                  sync.RWMutex
 }
@@ -36,6 +50,7 @@ type Router struct {
 func New(sessionToken string, recorder *progrock.Recorder) *Router {
 	r := &Router{
 		schemas:      make(map[string]ExecutableSchema),
+		handlers:     make(map[string]http.Handler),
 		sessionToken: sessionToken,
 		recorder:     recorder,
 	}
@@ -47,8 +62,21 @@ func New(sessionToken string, recorder *progrock.Recorder) *Router {
 func (r *Router) Do(ctx context.Context, query string, opName string, variables map[string]any, data any) (*graphql.Result, error) {
 	r.l.RLock()
 	schema := *r.s
+	auditLog := r.auditLog
+	emitter := r.events
+	logger := r.logger
 	r.l.RUnlock()
 
+	if logger != nil {
+		ctx = logger.WithContext(ctx)
+	}
+	if auditLog != nil {
+		ctx = audit.ToContext(ctx, auditLog)
+	}
+	if emitter != nil {
+		ctx = events.ToContext(ctx, emitter)
+	}
+
 	params := graphql.Params{
 		Context:        ctx,
 		Schema:         schema,
@@ -57,6 +85,7 @@ func (r *Router) Do(ctx context.Context, query string, opName string, variables
 		OperationName:  opName,
 	}
 	result := graphql.Do(params)
+	result.Extensions = r.extensions(&params)
 	if result.HasErrors() {
 		messages := []string{}
 		for _, e := range result.Errors {
@@ -78,6 +107,94 @@ func (r *Router) Do(ctx context.Context, query string, opName string, variables
 	return result, nil
 }
 
+// extensions returns the response extensions for a query.
+func (r *Router) extensions(params *graphql.Params) map[string]any {
+	if !isIntrospection(params.RequestString) {
+		return nil
+	}
+
+	r.l.RLock()
+	hints := r.scalarHints
+	r.l.RUnlock()
+
+	return map[string]any{
+		ScalarHintsExtension: hints,
+	}
+}
+
+// SetRateLimits configures the limits enforced on queries served over HTTP.
+func (r *Router) SetRateLimits(limits RateLimits) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if !limits.Enabled() {
+		r.limiter = nil
+		return
+	}
+
+	r.limiter = newRateLimiter(limits)
+}
+
+// SetAuditLog configures the log that every resolved operation is recorded
+// to.
+func (r *Router) SetAuditLog(log *audit.Log) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.auditLog = log
+}
+
+// SetEvents configures the emitter that lifecycle events are sent to.
+func (r *Router) SetEvents(emitter *events.Emitter) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.events = emitter
+}
+
+// SetLogger configures the logger that is available to resolvers via
+// zerolog.Ctx.
+func (r *Router) SetLogger(logger *zerolog.Logger) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.logger = logger
+}
+
+// SetPolicy configures the policy that restricts which fields clients served
+// over HTTP may use.
+func (r *Router) SetPolicy(policy *Policy) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.policy = policy
+}
+
+// SetPlayground configures whether a GraphiQL UI is served at /playground.
+func (r *Router) SetPlayground(enabled bool) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.playground = enabled
+}
+
+// Handle serves the handler at the given path, alongside /query. Handlers
+// bypass field policies, so they are only reachable with unrestricted access:
+// by any client when no policy is set, and only with the session token
+// otherwise.
+func (r *Router) Handle(path string, h http.Handler) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	// copy on write, since ServeHTTP reads the map without holding the lock
+	handlers := make(map[string]http.Handler, len(r.handlers)+1)
+	for k, v := range r.handlers {
+		handlers[k] = v
+	}
+	handlers[path] = h
+	r.handlers = handlers
+}
+
 func (r *Router) Add(schema ExecutableSchema) error {
 	r.l.Lock()
 	defer r.l.Unlock()
@@ -106,8 +223,10 @@ func (r *Router) Add(schema ExecutableSchema) error {
 	r.s = s
 	r.resolvers = merged.Resolvers()
 	r.mergedSchemaString = merged.Schema()
+	r.scalarHints = scalarHints(s)
 	r.h = handler.New(&handler.Config{
-		Schema: s,
+		Schema:       s,
+		ExtensionsFn: r.extensions,
 	})
 	return nil
 }
@@ -151,17 +270,54 @@ func (r *Router) MergedSchemas() string {
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.l.RLock()
 	h := r.h
+	limiter := r.limiter
+	auditLog := r.auditLog
+	emitter := r.events
+	logger := r.logger
+	policy := r.policy
+	playground := r.playground
+	handlers := r.handlers
 	r.l.RUnlock()
 
 	w.Header().Add("x-dagger-engine", engine.Version)
 
-	if r.sessionToken != "" {
-		username, _, ok := req.BasicAuth()
-		if !ok || username != r.sessionToken {
+	username, _, _ := req.BasicAuth()
+
+	var roles []string
+	var token string
+	if r.sessionToken == "" || username != r.sessionToken {
+		var known bool
+		if policy != nil {
+			roles, known = policy.roles(username)
+		}
+		if known {
+			token = username
+		}
+
+		if r.sessionToken != "" && !known {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Access to the Dagger engine session"`)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
+	} else {
+		// the session token has unrestricted access
+		policy = nil
+		token = username
+	}
+
+	if limiter != nil {
+		release, err := limiter.acquire(req, clientKey(req, token))
+		if err != nil {
+			if errors.Is(err, ErrRateLimited) {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+			} else {
+				// the request was canceled while waiting for a slot
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			}
+			return
+		}
+		defer release()
 	}
 
 	defer func() {
@@ -191,10 +347,35 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}()
 
-	req = req.WithContext(progrock.RecorderToContext(req.Context(), r.recorder))
+	ctx := progrock.RecorderToContext(req.Context(), r.recorder)
+	if logger != nil {
+		ctx = logger.WithContext(ctx)
+	}
+	if policy != nil {
+		ctx = withAccess(ctx, policy, roles)
+	}
+	if auditLog != nil {
+		ctx = audit.ToContext(ctx, auditLog)
+		ctx = audit.WithClient(ctx, audit.Client{
+			Addr:      remoteHost(req),
+			UserAgent: req.UserAgent(),
+		})
+	}
+	if emitter != nil {
+		ctx = events.ToContext(ctx, emitter)
+	}
+	req = req.WithContext(ctx)
 
 	mux := http.NewServeMux()
 	mux.Handle("/query", h)
+	if playground {
+		mux.HandleFunc("/playground", playgroundHandler)
+	}
+	if policy == nil {
+		for path, h := range handlers {
+			mux.Handle(path, h)
+		}
+	}
 	mux.ServeHTTP(w, req)
 }
 
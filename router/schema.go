@@ -7,10 +7,13 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/dagger/dagger/audit"
 	"github.com/dagger/dagger/core/pipeline"
+	"github.com/dagger/dagger/events"
 	"github.com/dagger/graphql"
 	"github.com/iancoleman/strcase"
 	"github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog"
 	"github.com/vito/progrock"
 )
 
@@ -137,6 +140,11 @@ type Digestible interface {
 // into a graphql resolver graphql.FieldResolveFn.
 func ToResolver[P any, A any, R any](f func(*Context, P, A) (R, error)) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (any, error) {
+		if err := authorize(p); err != nil {
+			audit.Record(p.Context, p.Info.ParentType.Name()+"."+p.Info.FieldName, auditArgs(p), err)
+			return nil, err
+		}
+
 		recorder := progrock.RecorderFromContext(p.Context)
 
 		var args A
@@ -176,7 +184,16 @@ func ToResolver[P any, A any, R any](f func(*Context, P, A) (R, error)) graphql.
 		}
 
 		res, err := f(&ctx, parent, args)
+
+		op := p.Info.ParentType.Name() + "." + p.Info.FieldName
+		audit.Record(p.Context, op, auditArgs(p), err)
+
 		if err != nil {
+			zerolog.Ctx(p.Context).Debug().Str("op", op).Err(err).Msg("operation failed")
+			events.Emit(p.Context, events.OperationFailed, map[string]any{
+				"op":    op,
+				"error": err.Error(),
+			})
 			vtx.Done(err)
 			return nil, err
 		}
@@ -190,6 +207,8 @@ func ToResolver[P any, A any, R any](f func(*Context, P, A) (R, error)) graphql.
 			vtx.Output(dg)
 		}
 
+		zerolog.Ctx(p.Context).Trace().Str("op", op).Msg("operation resolved")
+
 		vtx.Done(nil)
 
 		return res, nil
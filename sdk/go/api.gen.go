@@ -35,9 +35,15 @@ type ProjectID string
 // A unique identifier for a secret.
 type SecretID string
 
+// A unique service identifier.
+type ServiceID string
+
 // A content-addressed socket identifier.
 type SocketID string
 
+// A unique stack identifier.
+type StackID string
+
 // Key value object that represents a build argument.
 type BuildArg struct {
 	// The build argument name.
@@ -47,6 +53,16 @@ type BuildArg struct {
 	Value string `json:"value"`
 }
 
+// A request for GPU devices.
+type GPURequest struct {
+	// The number of GPUs to request. Defaults to all GPUs on the worker.
+	Count int `json:"count"`
+
+	// The GPU vendor (e.g., "nvidia"). Defaults to nvidia, which is currently the
+	// only supported vendor.
+	Vendor string `json:"vendor"`
+}
+
 // Key value object that represents a Pipeline label.
 type PipelineLabel struct {
 	// Label name.
@@ -100,21 +116,23 @@ type Container struct {
 	q *querybuilder.Selection
 	c graphql.Client
 
-	endpoint    *string
-	envVariable *string
-	exitCode    *int
-	export      *bool
-	hostname    *string
-	id          *ContainerID
-	imageRef    *string
-	label       *string
-	platform    *Platform
-	publish     *string
-	stderr      *string
-	stdout      *string
-	sync        *ContainerID
-	user        *string
-	workdir     *string
+	endpoint     *string
+	envVariable  *string
+	exitCode     *int
+	export       *bool
+	exportLayout *bool
+	hostname     *string
+	id           *ContainerID
+	imageRef     *string
+	label        *string
+	platform     *Platform
+	publish      *string
+	stderr       *string
+	stdout       *string
+	stopSignal   *string
+	sync         *ContainerID
+	user         *string
+	workdir      *string
 }
 type WithContainerFunc func(r *Container) *Container
 
@@ -122,6 +140,69 @@ func (r *Container) With(f WithContainerFunc) *Container {
 	return f(r)
 }
 
+// Turns the container into a service which runs its default command, and
+// can be started and stopped explicitly.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) AsService() *Service {
+	q := r.q.Select("asService")
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerAsTarballOpts contains options for Container.AsTarball
+type ContainerAsTarballOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
+}
+
+// Returns a File representing the container serialized to a tarball, in the
+// same format as export.
+func (r *Container) AsTarball(opts ...ContainerAsTarballOpts) *File {
+	q := r.q.Select("asTarball")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
 // ContainerBuildOpts contains options for Container.Build
 type ContainerBuildOpts struct {
 	// Path to the Dockerfile to use.
@@ -167,6 +248,18 @@ func (r *Container) Build(context *Directory, opts ...ContainerBuildOpts) *Conta
 	}
 }
 
+// Retrieves the changes made to this container's root filesystem on top of
+// its base image (e.g., by commands and written files), or the whole root
+// filesystem if it has no base image. Deleted files are not included.
+func (r *Container) Changes() *Directory {
+	q := r.q.Select("changes")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves default arguments for future commands.
 func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
 	q := r.q.Select("defaultArgs")
@@ -333,6 +426,18 @@ func (r *Container) Exec(opts ...ContainerExecOpts) *Container {
 	}
 }
 
+// Resource usage statistics of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) ExecStats() *ExecStats {
+	q := r.q.Select("execStats")
+
+	return &ExecStats{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Exit code of the last executed command. Zero means success.
 //
 // Will execute default command if none is set, or error if there's no default.
@@ -359,6 +464,14 @@ type ContainerExportOpts struct {
 	// different layers). If this is unset and a layer has no compressed blob in the
 	// engine's cache, then it will be compressed using Gzip.
 	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the exported image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
 }
 
 // Writes the container as an OCI tarball to the destination file path on the host for the specified platform variants.
@@ -370,6 +483,54 @@ func (r *Container) Export(ctx context.Context, path string, opts ...ContainerEx
 		return *r.export, nil
 	}
 	q := r.q.Select("export")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+	q = q.Arg("path", path)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ContainerExportLayoutOpts contains options for Container.ExportLayout
+type ContainerExportLayoutOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the exported image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+}
+
+// Writes the container to an OCI image layout directory on the host for the
+// specified platform variants, for use with tools like skopeo and crane.
+//
+// Return true on success.
+func (r *Container) ExportLayout(ctx context.Context, path string, opts ...ContainerExportLayoutOpts) (bool, error) {
+	if r.exportLayout != nil {
+		return *r.exportLayout, nil
+	}
+	q := r.q.Select("exportLayout")
 	for i := len(opts) - 1; i >= 0; i-- {
 		// `platformVariants` optional argument
 		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
@@ -549,6 +710,33 @@ func (r *Container) Import(source *File, opts ...ContainerImportOpts) *Container
 	}
 }
 
+// ContainerImportLayoutOpts contains options for Container.ImportLayout
+type ContainerImportLayoutOpts struct {
+	// Identifies the tag to import from the layout, if the layout contains
+	// multiple tags.
+	Tag string
+}
+
+// Reads the container from an OCI image layout directory on the host.
+//
+// NOTE: this involves copying the layout's blobs to an OCI store on the host at
+// $XDG_CACHE_DIR/dagger/oci. This directory can be removed whenever you like.
+func (r *Container) ImportLayout(path string, opts ...ContainerImportLayoutOpts) *Container {
+	q := r.q.Select("importLayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `tag` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Tag) {
+			q = q.Arg("tag", opts[i].Tag)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves the value of the specified label.
 func (r *Container) Label(ctx context.Context, name string) (string, error) {
 	if r.label != nil {
@@ -595,6 +783,39 @@ func (r *Container) Labels(ctx context.Context) ([]Label, error) {
 	return convert(response), nil
 }
 
+// Retrieves the container's mounts, including what kind of source each is mounted from.
+func (r *Container) MountPoints(ctx context.Context) ([]Mount, error) {
+	q := r.q.Select("mountPoints")
+
+	q = q.Select("path sourcePath type")
+
+	type mountPoints struct {
+		Path       string
+		SourcePath string
+		Type       MountType
+	}
+
+	convert := func(fields []mountPoints) []Mount {
+		out := []Mount{}
+
+		for i := range fields {
+			out = append(out, Mount{path: &fields[i].Path, sourcePath: &fields[i].SourcePath, type_: &fields[i].Type})
+		}
+
+		return out
+	}
+	var response []mountPoints
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Retrieves the list of paths where a directory is mounted.
 func (r *Container) Mounts(ctx context.Context) ([]string, error) {
 	q := r.q.Select("mounts")
@@ -658,6 +879,25 @@ type ContainerPublishOpts struct {
 	// different layers). If this is unset and a layer has no compressed blob in the
 	// engine's cache, then it will be compressed using Gzip.
 	ForcedCompression ImageLayerCompression
+	// Compression level to use with forcedCompression (e.g., 1-9 for Gzip and
+	// EStarGZ, 1-22 for Zstd). If this is unset, the algorithm's default level
+	// is used.
+	CompressionLevel int
+	// Use the specified media types for the published image's metadata.
+	// If this is unset, single platform images use Docker media types, while
+	// multi-platform images and EStarGZ compressed images use OCI media types.
+	MediaTypes ImageMediaTypes
+	// Attach a SLSA provenance attestation to the published image.
+	// Requires OCI media types, which are used by default when this is set.
+	Provenance bool
+	// Sign the published image with this PEM-encoded private key, pushing the
+	// signature like cosign sign does. Encrypted cosign keys are supported.
+	//
+	// The signature isn't recorded in a transparency log, so verify it with
+	// cosign verify --insecure-ignore-tlog.
+	SigningKey *Secret
+	// Password decrypting signingKey, if it's an encrypted cosign key.
+	SigningPassword *Secret
 }
 
 // Publishes this container as a new image to the specified address.
@@ -678,6 +918,26 @@ func (r *Container) Publish(ctx context.Context, address string, opts ...Contain
 		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
 			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
 		}
+		// `compressionLevel` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CompressionLevel) {
+			q = q.Arg("compressionLevel", opts[i].CompressionLevel)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
+		// `signingKey` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningKey) {
+			q = q.Arg("signingKey", opts[i].SigningKey)
+		}
+		// `signingPassword` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningPassword) {
+			q = q.Arg("signingPassword", opts[i].SigningPassword)
+		}
 	}
 	q = q.Arg("address", address)
 
@@ -727,6 +987,19 @@ func (r *Container) Stdout(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// Retrieves the signal used to stop the container (e.g., "SIGTERM").
+func (r *Container) StopSignal(ctx context.Context) (string, error) {
+	if r.stopSignal != nil {
+		return *r.stopSignal, nil
+	}
+	q := r.q.Select("stopSignal")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Forces evaluation of the pipeline in the engine.
 //
 // It doesn't run the default command if no exec has been set.
@@ -749,6 +1022,70 @@ func (r *Container) User(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// Retrieves the paths declared as volumes in the image config.
+func (r *Container) Volumes(ctx context.Context) ([]string, error) {
+	q := r.q.Select("volumes")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves this container plus the given OCI annotation.
+//
+// Unlike labels, annotations are not part of the image config: they are set on
+// the image manifest, and on the index for multi-platform images, when the
+// container is published or exported.
+func (r *Container) WithAnnotation(name string, value string) *Container {
+	q := r.q.Select("withAnnotation")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithDNSOpts contains options for Container.WithDNS
+type ContainerWithDNSOpts struct {
+	// Nameserver IP addresses (e.g., ["10.0.0.2"]).
+	Nameservers []string
+	// Domains to search when resolving short names (e.g., ["corp.internal"]).
+	SearchDomains []string
+	// Resolver options (e.g., ["ndots:2"]).
+	Options []string
+}
+
+// Retrieves this container with the given DNS resolver configuration for its
+// subsequent commands. Unset arguments keep the engine's defaults.
+//
+// Note that overriding the nameservers may prevent service hostnames from
+// resolving, unless the given servers forward to the engine's resolver.
+func (r *Container) WithDNS(opts ...ContainerWithDNSOpts) *Container {
+	q := r.q.Select("withDNS")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `nameservers` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Nameservers) {
+			q = q.Arg("nameservers", opts[i].Nameservers)
+		}
+		// `searchDomains` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SearchDomains) {
+			q = q.Arg("searchDomains", opts[i].SearchDomains)
+		}
+		// `options` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Options) {
+			q = q.Arg("options", opts[i].Options)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // ContainerWithDefaultArgsOpts contains options for Container.WithDefaultArgs
 type ContainerWithDefaultArgsOpts struct {
 	// Arguments to prepend to future executions (e.g., ["-v", "--no-cache"]).
@@ -783,6 +1120,9 @@ type ContainerWithDirectoryOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a directory written at the given path.
@@ -801,6 +1141,10 @@ func (r *Container) WithDirectory(path string, directory *Directory, opts ...Con
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("directory", directory)
@@ -867,6 +1211,22 @@ type ContainerWithExecOpts struct {
 	// does not provide any security guarantees when using this option. It should only be used
 	// when absolutely necessary and only with trusted commands.
 	InsecureRootCapabilities bool
+	// GPUs to make available to the command.
+	//
+	// Requires a worker with the NVIDIA Container Toolkit installed.
+	Gpu GPURequest
+	// Kill the command if it is still running after this many seconds. The exec
+	// then fails with exit code 124, or, with allowFailure, reports timedOut in
+	// its execStats.
+	Timeout int
+	// Maximum number of CPUs the command may use (e.g., 1.5).
+	CPULimit float64
+	// Maximum amount of memory the command may use, in MiB (e.g., 512).
+	// The command is killed if it exceeds this limit.
+	MemoryLimit int
+	// Don't fail if the command exits with a non-zero code. The exit code and
+	// output can then be inspected with exitCode, stdout and stderr.
+	AllowFailure bool
 }
 
 // Retrieves this container after executing the specified command inside it.
@@ -897,6 +1257,26 @@ func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Cont
 		if !querybuilder.IsZeroValue(opts[i].InsecureRootCapabilities) {
 			q = q.Arg("insecureRootCapabilities", opts[i].InsecureRootCapabilities)
 		}
+		// `gpu` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Gpu) {
+			q = q.Arg("gpu", opts[i].Gpu)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `cpuLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CPULimit) {
+			q = q.Arg("cpuLimit", opts[i].CPULimit)
+		}
+		// `memoryLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MemoryLimit) {
+			q = q.Arg("memoryLimit", opts[i].MemoryLimit)
+		}
+		// `allowFailure` optional argument
+		if !querybuilder.IsZeroValue(opts[i].AllowFailure) {
+			q = q.Arg("allowFailure", opts[i].AllowFailure)
+		}
 	}
 	q = q.Arg("args", args)
 
@@ -912,6 +1292,8 @@ type ContainerWithExposedPortOpts struct {
 	Protocol NetworkProtocol
 	// Optional port description
 	Description string
+	// Don't wait for the port to accept connections before running commands that use the container as a service
+	SkipHealthcheck bool
 }
 
 // Expose a network port.
@@ -932,6 +1314,10 @@ func (r *Container) WithExposedPort(port int, opts ...ContainerWithExposedPortOp
 		if !querybuilder.IsZeroValue(opts[i].Description) {
 			q = q.Arg("description", opts[i].Description)
 		}
+		// `skipHealthcheck` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SkipHealthcheck) {
+			q = q.Arg("skipHealthcheck", opts[i].SkipHealthcheck)
+		}
 	}
 	q = q.Arg("port", port)
 
@@ -941,6 +1327,19 @@ func (r *Container) WithExposedPort(port int, opts ...ContainerWithExposedPortOp
 	}
 }
 
+// Retrieves this container plus an /etc/hosts entry for its subsequent
+// commands, e.g. to reach internal hosts or stub out external services.
+func (r *Container) WithExtraHost(hostname string, ip string) *Container {
+	q := r.q.Select("withExtraHost")
+	q = q.Arg("hostname", hostname)
+	q = q.Arg("ip", ip)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Initializes this container from this DirectoryID.
 //
 // Deprecated: Replaced by WithRootfs.
@@ -966,6 +1365,9 @@ type ContainerWithFileOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus the contents of the given file copied to the given path.
@@ -980,6 +1382,10 @@ func (r *Container) WithFile(path string, source *File, opts ...ContainerWithFil
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("source", source)
@@ -990,6 +1396,22 @@ func (r *Container) WithFile(path string, source *File, opts ...ContainerWithFil
 	}
 }
 
+// Retrieves this container with the given hostname for its subsequent
+// commands, instead of the one computed from its definition.
+//
+// When the container is used as a service, it is still registered under its
+// computed hostname, and is also reachable via this hostname from the
+// containers it's bound to. Services with the same hostname don't collide.
+func (r *Container) WithHostname(hostname string) *Container {
+	q := r.q.Select("withHostname")
+	q = q.Arg("hostname", hostname)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this container plus the given label.
 func (r *Container) WithLabel(name string, value string) *Container {
 	q := r.q.Select("withLabel")
@@ -1018,6 +1440,9 @@ type ContainerWithMountedCacheOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a cache volume mounted at the given path.
@@ -1036,6 +1461,10 @@ func (r *Container) WithMountedCache(path string, cache *CacheVolume, opts ...Co
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("cache", cache)
@@ -1048,22 +1477,48 @@ func (r *Container) WithMountedCache(path string, cache *CacheVolume, opts ...Co
 
 // ContainerWithMountedDirectoryOpts contains options for Container.WithMountedDirectory
 type ContainerWithMountedDirectoryOpts struct {
+	// Patterns to exclude in the mounted directory (e.g., ["node_modules/**", ".gitignore", ".git/"]).
+	Exclude []string
+	// Patterns to include in the mounted directory (e.g., ["*.go", "go.mod", "go.sum"]).
+	Include []string
 	// A user:group to set for the mounted directory and its contents.
 	//
 	// The user and group can either be an ID (1000:1000) or a name (foo:bar).
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Mount the directory read-only, so that it cannot be modified by
+	// commands run in the container.
+	Readonly bool
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a directory mounted at the given path.
 func (r *Container) WithMountedDirectory(path string, source *Directory, opts ...ContainerWithMountedDirectoryOpts) *Container {
 	q := r.q.Select("withMountedDirectory")
 	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
 		// `owner` optional argument
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `readonly` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Readonly) {
+			q = q.Arg("readonly", opts[i].Readonly)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("source", source)
@@ -1082,6 +1537,12 @@ type ContainerWithMountedFileOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Mount the file read-only, so that it cannot be modified by
+	// commands run in the container.
+	Readonly bool
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a file mounted at the given path.
@@ -1092,6 +1553,14 @@ func (r *Container) WithMountedFile(path string, source *File, opts ...Container
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `readonly` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Readonly) {
+			q = q.Arg("readonly", opts[i].Readonly)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("source", source)
@@ -1110,6 +1579,9 @@ type ContainerWithMountedSecretOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a secret mounted into a file at the given path.
@@ -1120,6 +1592,10 @@ func (r *Container) WithMountedSecret(path string, source *Secret, opts ...Conta
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("source", source)
@@ -1130,9 +1606,28 @@ func (r *Container) WithMountedSecret(path string, source *Secret, opts ...Conta
 	}
 }
 
+// ContainerWithMountedTempOpts contains options for Container.WithMountedTemp
+type ContainerWithMountedTempOpts struct {
+	// Maximum size of the temporary directory in bytes. Unlimited if not set.
+	Size int
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
 // Retrieves this container plus a temporary directory mounted at the given path.
-func (r *Container) WithMountedTemp(path string) *Container {
+func (r *Container) WithMountedTemp(path string, opts ...ContainerWithMountedTempOpts) *Container {
 	q := r.q.Select("withMountedTemp")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `size` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Size) {
+			q = q.Arg("size", opts[i].Size)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
 	q = q.Arg("path", path)
 
 	return &Container{
@@ -1155,6 +1650,9 @@ type ContainerWithNewFileOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a new file written at the given path.
@@ -1173,6 +1671,10 @@ func (r *Container) WithNewFile(path string, opts ...ContainerWithNewFileOpts) *
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 
@@ -1239,7 +1741,49 @@ func (r *Container) WithServiceBinding(alias string, service *Container) *Contai
 	}
 }
 
-// ContainerWithUnixSocketOpts contains options for Container.WithUnixSocket
+// Establishes a runtime dependency on each service in a stack, so that they
+// are started before this container runs its next command and stopped once
+// it is no longer needed.
+//
+// Each service will be reachable from the container via its name in the stack.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithStack(stack *Stack) *Container {
+	q := r.q.Select("withStack")
+	q = q.Arg("stack", stack)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Limits how long to wait for the container's exposed ports to accept
+// connections when it's started as a service.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Container) WithStartTimeout(timeout int) *Container {
+	q := r.q.Select("withStartTimeout")
+	q = q.Arg("timeout", timeout)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with a different signal used to stop it.
+func (r *Container) WithStopSignal(signal string) *Container {
+	q := r.q.Select("withStopSignal")
+	q = q.Arg("signal", signal)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithUnixSocketOpts contains options for Container.WithUnixSocket
 type ContainerWithUnixSocketOpts struct {
 	// A user:group to set for the mounted socket.
 	//
@@ -1247,6 +1791,9 @@ type ContainerWithUnixSocketOpts struct {
 	//
 	// If the group is omitted, it defaults to the same as the user.
 	Owner string
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
 }
 
 // Retrieves this container plus a socket forwarded to the given Unix socket path.
@@ -1257,6 +1804,10 @@ func (r *Container) WithUnixSocket(path string, source *Socket, opts ...Containe
 		if !querybuilder.IsZeroValue(opts[i].Owner) {
 			q = q.Arg("owner", opts[i].Owner)
 		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
 	}
 	q = q.Arg("path", path)
 	q = q.Arg("source", source)
@@ -1278,9 +1829,34 @@ func (r *Container) WithUser(name string) *Container {
 	}
 }
 
+// Retrieves this container plus the given path declared as a volume in its
+// image config. This is metadata only: no mount is added to the container.
+func (r *Container) WithVolume(path string) *Container {
+	q := r.q.Select("withVolume")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ContainerWithWorkdirOpts contains options for Container.WithWorkdir
+type ContainerWithWorkdirOpts struct {
+	// Replace ${VAR} or $VAR in the path according to the current environment
+	// variables defined in the container (e.g., "$HOME/.cache").
+	Expand bool
+}
+
 // Retrieves this container with a different working directory.
-func (r *Container) WithWorkdir(path string) *Container {
+func (r *Container) WithWorkdir(path string, opts ...ContainerWithWorkdirOpts) *Container {
 	q := r.q.Select("withWorkdir")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
 	q = q.Arg("path", path)
 
 	return &Container{
@@ -1289,6 +1865,17 @@ func (r *Container) WithWorkdir(path string) *Container {
 	}
 }
 
+// Retrieves this container with its entrypoint removed, so that future
+// executions run their arguments directly.
+func (r *Container) WithoutEntrypoint() *Container {
+	q := r.q.Select("withoutEntrypoint")
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this container minus the given environment variable.
 func (r *Container) WithoutEnvVariable(name string) *Container {
 	q := r.q.Select("withoutEnvVariable")
@@ -1369,6 +1956,18 @@ func (r *Container) WithoutUnixSocket(path string) *Container {
 	}
 }
 
+// Retrieves this container minus the given path declared as a volume in its
+// image config.
+func (r *Container) WithoutVolume(path string) *Container {
+	q := r.q.Select("withoutVolume")
+	q = q.Arg("path", path)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves the working directory for all commands.
 func (r *Container) Workdir(ctx context.Context) (string, error) {
 	if r.workdir != nil {
@@ -1387,8 +1986,11 @@ type Directory struct {
 	q *querybuilder.Selection
 	c graphql.Client
 
-	export *bool
-	id     *DirectoryID
+	digest  *string
+	export  *bool
+	id      *DirectoryID
+	publish *string
+	sync    *DirectoryID
 }
 type WithDirectoryFunc func(r *Directory) *Directory
 
@@ -1396,6 +1998,40 @@ func (r *Directory) With(f WithDirectoryFunc) *Directory {
 	return f(r)
 }
 
+// DirectoryArchiveOpts contains options for Directory.Archive
+type DirectoryArchiveOpts struct {
+	// Format of the archive.
+	//
+	// Defaults to TAR.
+	Format ArchiveFormat
+	// Compression of the archive. TAR supports GZIP_COMPRESSION,
+	// ZSTD_COMPRESSION and NO_COMPRESSION, while ZIP supports
+	// DEFLATE_COMPRESSION and NO_COMPRESSION.
+	//
+	// Defaults to GZIP_COMPRESSION for TAR, and DEFLATE_COMPRESSION for ZIP.
+	Compression ArchiveCompression
+}
+
+// Retrieves the contents of this directory as an archive file.
+func (r *Directory) Archive(opts ...DirectoryArchiveOpts) *File {
+	q := r.q.Select("archive")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `format` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Format) {
+			q = q.Arg("format", opts[i].Format)
+		}
+		// `compression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Compression) {
+			q = q.Arg("compression", opts[i].Compression)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Gets the difference between this directory and an another directory.
 func (r *Directory) Diff(other *Directory) *Directory {
 	q := r.q.Select("diff")
@@ -1407,6 +2043,22 @@ func (r *Directory) Diff(other *Directory) *Directory {
 	}
 }
 
+// A digest of the directory's contents (e.g., "sha256:...").
+//
+// It only depends on the paths, permissions, ownership and contents of the
+// files, so it's stable across builds that produce the same result.
+func (r *Directory) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves a directory at the given path.
 func (r *Directory) Directory(path string) *Directory {
 	q := r.q.Select("directory")
@@ -1515,6 +2167,18 @@ func (r *Directory) File(path string) *File {
 	}
 }
 
+// Returns the paths of the files and directories that match the given
+// pattern, searching recursively.
+func (r *Directory) Glob(ctx context.Context, pattern string) ([]string, error) {
+	q := r.q.Select("glob")
+	q = q.Arg("pattern", pattern)
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // The content-addressed identifier of the directory.
 func (r *Directory) ID(ctx context.Context) (DirectoryID, error) {
 	if r.id != nil {
@@ -1576,6 +2240,45 @@ func (r *Directory) Pipeline(name string, opts ...DirectoryPipelineOpts) *Direct
 	}
 }
 
+// DirectoryPublishOpts contains options for Directory.Publish
+type DirectoryPublishOpts struct {
+	// Media type of the layer, which must be a gzipped layer type.
+	//
+	// Defaults to application/vnd.oci.image.layer.v1.tar+gzip.
+	MediaType string
+}
+
+// Publishes this directory to a registry as an OCI artifact, with its contents
+// archived into a single gzipped tar layer.
+//
+// Returns a fully qualified reference to the artifact, including its digest.
+func (r *Directory) Publish(ctx context.Context, address string, opts ...DirectoryPublishOpts) (string, error) {
+	if r.publish != nil {
+		return *r.publish, nil
+	}
+	q := r.q.Select("publish")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `mediaType` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaType) {
+			q = q.Arg("mediaType", opts[i].MediaType)
+		}
+	}
+	q = q.Arg("address", address)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Forces evaluation of the directory in the engine, surfacing any errors
+// building it.
+func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
+	q := r.q.Select("sync")
+
+	return r, q.Execute(ctx, r.c)
+}
+
 // DirectoryWithDirectoryOpts contains options for Directory.WithDirectory
 type DirectoryWithDirectoryOpts struct {
 	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
@@ -1751,6 +2454,111 @@ func (r *EnvVariable) Value(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// Resource usage statistics of an executed command.
+type ExecStats struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	duration   *float64
+	maxRSS     *int
+	oomKilled  *bool
+	signal     *string
+	systemTime *float64
+	timedOut   *bool
+	userTime   *float64
+}
+
+// Wall-clock duration of the command, in seconds.
+func (r *ExecStats) Duration(ctx context.Context) (float64, error) {
+	if r.duration != nil {
+		return *r.duration, nil
+	}
+	q := r.q.Select("duration")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Maximum resident set size of the command, in bytes.
+func (r *ExecStats) MaxRSS(ctx context.Context) (int, error) {
+	if r.maxRSS != nil {
+		return *r.maxRSS, nil
+	}
+	q := r.q.Select("maxRSS")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Whether the command or one of its children was killed for running out of memory.
+func (r *ExecStats) OomKilled(ctx context.Context) (bool, error) {
+	if r.oomKilled != nil {
+		return *r.oomKilled, nil
+	}
+	q := r.q.Select("oomKilled")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The signal that killed the command, if any (e.g., SIGKILL).
+func (r *ExecStats) Signal(ctx context.Context) (string, error) {
+	if r.signal != nil {
+		return *r.signal, nil
+	}
+	q := r.q.Select("signal")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// CPU time spent in kernel mode, in seconds.
+func (r *ExecStats) SystemTime(ctx context.Context) (float64, error) {
+	if r.systemTime != nil {
+		return *r.systemTime, nil
+	}
+	q := r.q.Select("systemTime")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Whether the command was killed for exceeding its timeout.
+func (r *ExecStats) TimedOut(ctx context.Context) (bool, error) {
+	if r.timedOut != nil {
+		return *r.timedOut, nil
+	}
+	q := r.q.Select("timedOut")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// CPU time spent in user mode, in seconds.
+func (r *ExecStats) UserTime(ctx context.Context) (float64, error) {
+	if r.userTime != nil {
+		return *r.userTime, nil
+	}
+	q := r.q.Select("userTime")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // A file.
 type File struct {
 	q *querybuilder.Selection
@@ -1759,6 +2567,7 @@ type File struct {
 	contents *string
 	export   *bool
 	id       *FileID
+	publish  *string
 	size     *int
 }
 
@@ -1834,6 +2643,37 @@ func (r *File) XXX_GraphQLID(ctx context.Context) (string, error) {
 	return string(id), nil
 }
 
+// FilePublishOpts contains options for File.Publish
+type FilePublishOpts struct {
+	// Media type of the layer (e.g., "application/spdx+json").
+	//
+	// Defaults to application/vnd.oci.image.layer.v1.tar.
+	MediaType string
+}
+
+// Publishes this file to a registry as an OCI artifact, with its contents as a
+// single layer.
+//
+// Returns a fully qualified reference to the artifact, including its digest.
+func (r *File) Publish(ctx context.Context, address string, opts ...FilePublishOpts) (string, error) {
+	if r.publish != nil {
+		return *r.publish, nil
+	}
+	q := r.q.Select("publish")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `mediaType` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaType) {
+			q = q.Arg("mediaType", opts[i].MediaType)
+		}
+	}
+	q = q.Arg("address", address)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves a secret referencing the contents of this file.
 //
 // Deprecated: insecure, leaves secret in cache. Superseded by SetSecret
@@ -1859,6 +2699,19 @@ func (r *File) Size(ctx context.Context) (int, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// Retrieves the contents of this archive file as a directory.
+//
+// Supports tar archives, optionally compressed with gzip, bzip2, xz or zstd,
+// and zip archives.
+func (r *File) Unpack() *Directory {
+	q := r.q.Select("unpack")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this file with its created/modified timestamps set to the given time.
 func (r *File) WithTimestamps(timestamp int) *File {
 	q := r.q.Select("withTimestamps")
@@ -2143,6 +2996,55 @@ func (r *Label) Value(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// A mount in a container.
+type Mount struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	path       *string
+	sourcePath *string
+	type_      *MountType
+}
+
+// The path the mount is at within the container.
+func (r *Mount) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.q.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The path within the source that is mounted, if it's not the source's root.
+func (r *Mount) SourcePath(ctx context.Context) (string, error) {
+	if r.sourcePath != nil {
+		return *r.sourcePath, nil
+	}
+	q := r.q.Select("sourcePath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The kind of source that is mounted.
+func (r *Mount) Type(ctx context.Context) (MountType, error) {
+	if r.type_ != nil {
+		return *r.type_, nil
+	}
+	q := r.q.Select("type")
+
+	var response MountType
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // A port exposed by a container.
 type Port struct {
 	q *querybuilder.Selection
@@ -2475,6 +3377,22 @@ func (r *ProjectCommandFlag) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// Pulls an OCI artifact (e.g., one pushed by Directory.publish, File.publish, or
+// ORAS) and loads its contents as a directory.
+//
+// Each layer is written to a file named by its org.opencontainers.image.title
+// annotation; layers of published directories are unpacked instead. Layers
+// without a title are skipped.
+func (r *Client) Artifact(address string) *Directory {
+	q := r.q.Select("artifact")
+	q = q.Arg("address", address)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Constructs a cache volume for a given cache key.
 func (r *Client) CacheVolume(key string) *CacheVolume {
 	q := r.q.Select("cacheVolume")
@@ -2650,6 +3568,43 @@ func (r *Client) Pipeline(name string, opts ...PipelineOpts) *Client {
 	}
 }
 
+// PreloadImagesOpts contains options for Query.PreloadImages
+type PreloadImagesOpts struct {
+	// Image references to pull (e.g., "docker.io/library/alpine:3.18").
+	Addresses []string
+	// OCI tarball to load images from (e.g., one written by Container.export).
+	Archive *File
+	// Platform to pull images for. Defaults to that of the builder's host.
+	Platform Platform
+}
+
+// Loads images into the engine's cache ahead of time, so that pipelines using
+// them don't need to pull during their run.
+//
+// Returns true once every image has been loaded.
+func (r *Client) PreloadImages(ctx context.Context, opts ...PreloadImagesOpts) (bool, error) {
+	q := r.q.Select("preloadImages")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `addresses` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Addresses) {
+			q = q.Arg("addresses", opts[i].Addresses)
+		}
+		// `archive` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Archive) {
+			q = q.Arg("archive", opts[i].Archive)
+		}
+		// `platform` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Platform) {
+			q = q.Arg("platform", opts[i].Platform)
+		}
+	}
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // ProjectOpts contains options for Query.Project
 type ProjectOpts struct {
 	ID ProjectID
@@ -2703,6 +3658,19 @@ func (r *Client) Secret(id SecretID) *Secret {
 	}
 }
 
+// Loads a service from ID.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Client) Service(id ServiceID) *Service {
+	q := r.q.Select("service")
+	q = q.Arg("id", id)
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Sets a secret given a user defined name to its plaintext and returns the secret.
 // The plaintext value is limited to a size of 128000 bytes.
 func (r *Client) SetSecret(name string, plaintext string) *Secret {
@@ -2737,6 +3705,31 @@ func (r *Client) Socket(opts ...SocketOpts) *Socket {
 	}
 }
 
+// StackOpts contains options for Query.Stack
+type StackOpts struct {
+	ID StackID
+}
+
+// Loads a stack from ID.
+//
+// Null ID returns an empty stack.
+//
+// Currently experimental; set _EXPERIMENTAL_DAGGER_SERVICES_DNS=0 to disable.
+func (r *Client) Stack(opts ...StackOpts) *Stack {
+	q := r.q.Select("stack")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `id` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ID) {
+			q = q.Arg("id", opts[i].ID)
+		}
+	}
+
+	return &Stack{
+		q: q,
+		c: r.c,
+	}
+}
+
 // A reference to a secret value, which can be handled more safely than the value itself.
 type Secret struct {
 	q *querybuilder.Selection
@@ -2791,6 +3784,127 @@ func (r *Secret) Plaintext(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// A container running its default command as a long-running service, which can
+// be started and stopped explicitly.
+type Service struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	endpoint *string
+	hostname *string
+	id       *ServiceID
+	start    *ServiceID
+	stop     *ServiceID
+}
+
+// The container that the service runs.
+func (r *Service) Container() *Container {
+	q := r.q.Select("container")
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// ServiceEndpointOpts contains options for Service.Endpoint
+type ServiceEndpointOpts struct {
+	// The exposed port number for the endpoint
+	Port int
+	// Return a URL with the given scheme, eg. http for http://
+	Scheme string
+}
+
+// Retrieves an endpoint that clients can use to reach this service.
+//
+// If no port is specified, the first exposed port is used. If none exist an error is returned.
+//
+// If a scheme is specified, a URL is returned. Otherwise, a host:port pair is returned.
+func (r *Service) Endpoint(ctx context.Context, opts ...ServiceEndpointOpts) (string, error) {
+	if r.endpoint != nil {
+		return *r.endpoint, nil
+	}
+	q := r.q.Select("endpoint")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `port` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Port) {
+			q = q.Arg("port", opts[i].Port)
+		}
+		// `scheme` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Scheme) {
+			q = q.Arg("scheme", opts[i].Scheme)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves a hostname which can be used by clients to reach this service.
+func (r *Service) Hostname(ctx context.Context) (string, error) {
+	if r.hostname != nil {
+		return *r.hostname, nil
+	}
+	q := r.q.Select("hostname")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// A unique identifier for this service.
+func (r *Service) ID(ctx context.Context) (ServiceID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response ServiceID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Service) XXX_GraphQLType() string {
+	return "Service"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Service) XXX_GraphQLIDType() string {
+	return "ServiceID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Service) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// Starts the service and waits for its exposed ports to accept connections.
+//
+// The service keeps running until it is stopped or the session ends. Starting
+// a service which is already running has no effect.
+func (r *Service) Start(ctx context.Context) (*Service, error) {
+	q := r.q.Select("start")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// Stops the service, if it was started with start.
+func (r *Service) Stop(ctx context.Context) (*Service, error) {
+	q := r.q.Select("stop")
+
+	return r, q.Execute(ctx, r.c)
+}
+
+// A Unix or TCP/IP socket that can be mounted into a container.
 type Socket struct {
 	q *querybuilder.Selection
 	c graphql.Client
@@ -2830,6 +3944,111 @@ func (r *Socket) XXX_GraphQLID(ctx context.Context) (string, error) {
 	return string(id), nil
 }
 
+// A named set of service containers which are started and stopped together,
+// in the style of a Compose file.
+type Stack struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	id *StackID
+}
+
+// A unique identifier for this stack.
+func (r *Stack) ID(ctx context.Context) (StackID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.q.Select("id")
+
+	var response StackID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Stack) XXX_GraphQLType() string {
+	return "Stack"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Stack) XXX_GraphQLIDType() string {
+	return "StackID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Stack) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+// Retrieves the named service's container, with its dependencies bound to it.
+func (r *Stack) Service(name string) *Container {
+	q := r.q.Select("service")
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The names of the stack's services, in the order they were added.
+func (r *Stack) Services(ctx context.Context) ([]string, error) {
+	q := r.q.Select("services")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// StackWithServiceOpts contains options for Stack.WithService
+type StackWithServiceOpts struct {
+	// Names of the services which must be running before this service starts.
+	DependsOn []string
+}
+
+// Adds a service to the stack, replacing any existing service with the same name.
+//
+// The service runs the container's default command once each of the services
+// it depends on is reachable from it by their names.
+func (r *Stack) WithService(name string, container *Container, opts ...StackWithServiceOpts) *Stack {
+	q := r.q.Select("withService")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dependsOn` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DependsOn) {
+			q = q.Arg("dependsOn", opts[i].DependsOn)
+		}
+	}
+	q = q.Arg("name", name)
+	q = q.Arg("container", container)
+
+	return &Stack{
+		q: q,
+		c: r.c,
+	}
+}
+
+type ArchiveCompression string
+
+const (
+	DeflateCompression ArchiveCompression = "DEFLATE_COMPRESSION"
+	GzipCompression    ArchiveCompression = "GZIP_COMPRESSION"
+	NoCompression      ArchiveCompression = "NO_COMPRESSION"
+	ZstdCompression    ArchiveCompression = "ZSTD_COMPRESSION"
+)
+
+type ArchiveFormat string
+
+const (
+	Tar ArchiveFormat = "TAR"
+	Zip ArchiveFormat = "ZIP"
+)
+
 type CacheSharingMode string
 
 const (
@@ -2847,6 +4066,22 @@ const (
 	Zstd         ImageLayerCompression = "Zstd"
 )
 
+type ImageMediaTypes string
+
+const (
+	Dockermediatypes ImageMediaTypes = "DockerMediaTypes"
+	Ocimediatypes    ImageMediaTypes = "OCIMediaTypes"
+)
+
+type MountType string
+
+const (
+	CacheMount     MountType = "CACHE_MOUNT"
+	DirectoryMount MountType = "DIRECTORY_MOUNT"
+	FileMount      MountType = "FILE_MOUNT"
+	TmpfsMount     MountType = "TMPFS_MOUNT"
+)
+
 type NetworkProtocol string
 
 const (